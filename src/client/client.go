@@ -580,7 +580,8 @@ func NewInCluster(options ...Option) (*APIClient, error) {
 }
 
 // NewInWorker constructs a new APIClient intended to be used from a worker
-// to talk to the sidecar pachd container
+// to talk to the sidecar pachd container, or to pachd itself if the pipeline
+// runs without a sidecar
 func NewInWorker(options ...Option) (*APIClient, error) {
 	cfg, err := config.Read(false, true)
 	if err != nil {
@@ -591,17 +592,22 @@ func NewInWorker(options ...Option) (*APIClient, error) {
 		return nil, errors.Wrap(err, "could not get active context")
 	}
 
-	if localPort, ok := os.LookupEnv("PEER_PORT"); ok {
-		client, err := NewFromAddress(fmt.Sprintf("127.0.0.1:%s", localPort), options...)
-		if err != nil {
-			return nil, errors.Wrap(err, "could not create client")
-		}
-		if context.SessionToken != "" {
-			client.authenticationToken = context.SessionToken
+	address, ok := os.LookupEnv(PPSPachdAddressEnv)
+	if !ok {
+		localPort, ok := os.LookupEnv("PEER_PORT")
+		if !ok {
+			return nil, errors.New("PEER_PORT not set")
 		}
-		return client, nil
+		address = fmt.Sprintf("127.0.0.1:%s", localPort)
+	}
+	client, err := NewFromAddress(address, options...)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create client")
 	}
-	return nil, errors.New("PEER_PORT not set")
+	if context.SessionToken != "" {
+		client.authenticationToken = context.SessionToken
+	}
+	return client, nil
 }
 
 // Close the connection to gRPC
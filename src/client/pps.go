@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"io"
@@ -45,6 +46,9 @@ const (
 	// PPSWorkerPortEnv is environment variable name for the port that workers
 	// use for their gRPC server
 	PPSWorkerPortEnv = "PPS_WORKER_GRPC_PORT"
+	// PPSPachdAddressEnv is the environment variable that holds the address
+	// of pachd, for workers of pipelines that run without a sidecar.
+	PPSPachdAddressEnv = "PPS_PACHD_ADDRESS"
 	// PPSWorkerVolume is the name of the volume in which workers store
 	// data.
 	PPSWorkerVolume = "pachyderm-worker"
@@ -63,6 +67,31 @@ const (
 	// OutputCommitIDEnv is an env var that is added to the environment of user
 	// pipelined code and indicates the id of the output commit.
 	OutputCommitIDEnv = "PACH_OUTPUT_COMMIT_ID"
+	// ProgressURLEnv is an env var that is added to the environment of user
+	// code, and holds the URL of the worker's local HTTP server through which
+	// the user code can report its progress and custom counters.
+	ProgressURLEnv = "PACH_PROGRESS_URL"
+	// StatsdAddrEnv is an env var that is added to the environment of user
+	// code, and holds the UDP address, on localhost, that the user code can
+	// send statsd lines to, which the worker exports as Prometheus metrics.
+	StatsdAddrEnv = "PACH_STATSD_ADDR"
+	// MetricsFileEnv is an env var that is added to the environment of user
+	// code, and names a file that the user code can write statsd lines to
+	// instead. The worker reads the file after each datum.
+	MetricsFileEnv = "PACH_METRICS_FILE"
+	// PartialOutputEnv is an env var that is added to the environment of user
+	// code in pipelines with a cancel grace period, and names the directory
+	// that the user code can flush partial output to when its job is stopped.
+	// The worker uploads the directory to the job's output commit.
+	PartialOutputEnv = "PACH_PARTIAL_OUTPUT"
+	// DatumBatchNextEnv is an env var that is added to the environment of user
+	// code in pipelines with datum batching, and names the fifo from which the
+	// user code reads the ID of each datum to process.
+	DatumBatchNextEnv = "PACH_DATUM_BATCH_NEXT"
+	// DatumBatchDoneEnv is an env var that is added to the environment of user
+	// code in pipelines with datum batching, and names the fifo to which the
+	// user code writes the exit status of each datum.
+	DatumBatchDoneEnv = "PACH_DATUM_BATCH_DONE"
 	// PeerPortEnv is the env var that sets a custom peer port
 	PeerPortEnv = "PEER_PORT"
 )
@@ -122,6 +151,19 @@ func NewS3PFSInput(name string, repo string, branch string) *pps.Input {
 	}
 }
 
+// NewWindowInput returns a new window input. Each datum it produces holds the
+// files under a path matching 'glob' that changed within the last 'commits'
+// commits to 'repo'.
+func NewWindowInput(repo string, glob string, commits int64) *pps.Input {
+	return &pps.Input{
+		Window: &pps.WindowInput{
+			Repo:    repo,
+			Glob:    glob,
+			Commits: commits,
+		},
+	}
+}
+
 // NewCrossInput returns an input which is the cross product of other inputs.
 // That means that all combination of datums will be seen by the job /
 // pipeline.
@@ -354,6 +396,43 @@ func (c APIClient) FlushJob(commits []*pfs.Commit, toPipelines []string, f func(
 	}
 }
 
+// WatchJobStatus calls f with the status of each worker processing a job, each
+// time it changes, until the job's workers go away or c's context is
+// cancelled. Statuses with an empty JobID indicate that a worker is idle.
+func (c APIClient) WatchJobStatus(jobID string, f func(*pps.WorkerStatus) error) error {
+	client, err := c.PpsAPIClient.WatchJobStatus(c.Ctx(), &pps.WatchJobStatusRequest{
+		Job: NewJob(jobID),
+	})
+	if err != nil {
+		return grpcutil.ScrubGRPC(err)
+	}
+	for {
+		status, err := client.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return grpcutil.ScrubGRPC(err)
+		}
+		if err := f(status); err != nil {
+			return err
+		}
+	}
+}
+
+// InspectCommitSet returns every job descended from a commit, along with
+// their aggregate state. If block is set, it waits for all of those jobs to
+// finish first.
+func (c APIClient) InspectCommitSet(repoName, commitID string, block bool) (*pps.CommitSetInfo, error) {
+	commitSetInfo, err := c.PpsAPIClient.InspectCommitSet(
+		c.Ctx(),
+		&pps.InspectCommitSetRequest{
+			Commit: NewCommit(repoName, commitID),
+			Block:  block,
+		})
+	return commitSetInfo, grpcutil.ScrubGRPC(err)
+}
+
 // FlushJobAll returns all the jobs which were triggered by commits.
 // If toPipelines is non-nil then only the jobs between commits and those
 // pipelines in the DAG will be returned.
@@ -390,6 +469,18 @@ func (c APIClient) StopJob(jobID string) error {
 	return grpcutil.ScrubGRPC(err)
 }
 
+// EgressJob retries the egress of a finished job whose egress failed, and
+// returns the job's updated egress status.
+func (c APIClient) EgressJob(jobID string) (*pps.EgressStatus, error) {
+	egressStatus, err := c.PpsAPIClient.EgressJob(
+		c.Ctx(),
+		&pps.EgressJobRequest{
+			Job: NewJob(jobID),
+		},
+	)
+	return egressStatus, grpcutil.ScrubGRPC(err)
+}
+
 // StopJobOutputCommit stops a job associated with an output commit.
 func (c APIClient) StopJobOutputCommit(repo, commit string) (retErr error) {
 	defer func() {
@@ -404,6 +495,56 @@ func (c APIClient) StopJobOutputCommit(repo, commit string) (retErr error) {
 	return err
 }
 
+// DebugDatum runs an interactive shell in the environment of a failed datum
+// that is being held for debugging (see pps.DebugOnFailure). Input for the
+// shell is read from 'stdin', and its output is written to 'stdout'.
+func (c APIClient) DebugDatum(jobID, datumID string, stdin io.Reader, stdout io.Writer) (retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	ctx, cancel := context.WithCancel(c.Ctx())
+	defer cancel()
+	client, err := c.PpsAPIClient.DebugDatum(ctx)
+	if err != nil {
+		return err
+	}
+	if err := client.Send(&pps.DebugDatumRequest{
+		Job:     NewJob(jobID),
+		DatumID: datumID,
+	}); err != nil {
+		return err
+	}
+	go func() {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := stdin.Read(buf)
+			if n > 0 {
+				input := make([]byte, n)
+				copy(input, buf[:n])
+				if err := client.Send(&pps.DebugDatumRequest{Stdin: input}); err != nil {
+					return
+				}
+			}
+			if err != nil {
+				client.CloseSend()
+				return
+			}
+		}
+	}()
+	for {
+		resp, err := client.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if _, err := stdout.Write(resp.Output); err != nil {
+			return err
+		}
+	}
+}
+
 // RestartDatum restarts a datum that's being processed as part of a job.
 // datumFilter is a slice of strings which are matched against either the Path
 // or Hash of the datum, the order of the strings in datumFilter is irrelevant.
@@ -449,6 +590,57 @@ func (c APIClient) ListDatum(job string, cb func(*pps.DatumInfo) error) (retErr
 	}
 }
 
+// ListDatumInput returns info about the datums that the pipeline 'pipeline'
+// with the given input would create, without creating the pipeline.
+// 'pipeline' names the repos of cron and SQL inputs, so it may be empty if
+// 'input' has none.
+func (c APIClient) ListDatumInput(pipeline string, input *pps.Input, cb func(*pps.DatumInfo) error) (retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	client, err := c.PpsAPIClient.ListDatum(
+		c.Ctx(),
+		&pps.ListDatumRequest{
+			Input:    input,
+			Pipeline: NewPipeline(pipeline),
+		},
+	)
+	if err != nil {
+		return err
+	}
+	for {
+		di, err := client.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := cb(di); err != nil {
+			if errors.Is(err, errutil.ErrBreak) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// ListDatumInputAll returns info about the datums that the pipeline
+// 'pipeline' with the given input would create.
+func (c APIClient) ListDatumInputAll(pipeline string, input *pps.Input) (_ []*pps.DatumInfo, retErr error) {
+	defer func() {
+		retErr = grpcutil.ScrubGRPC(retErr)
+	}()
+	var dis []*pps.DatumInfo
+	if err := c.ListDatumInput(pipeline, input, func(di *pps.DatumInfo) error {
+		dis = append(dis, di)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return dis, nil
+}
+
 // ListDatumAll returns info about datums in a job.
 func (c APIClient) ListDatumAll(job string) (_ []*pps.DatumInfo, retErr error) {
 	defer func() {
@@ -481,6 +673,21 @@ func (c APIClient) InspectDatum(jobID string, datumID string) (*pps.DatumInfo, e
 	return datumInfo, nil
 }
 
+// InspectFileLineage returns the job and datums that produced a file in a
+// pipeline's output repo, along with the input files each datum read.
+func (c APIClient) InspectFileLineage(repoName, commitID, path string) (*pps.FileLineage, error) {
+	lineage, err := c.PpsAPIClient.InspectFileLineage(
+		c.Ctx(),
+		&pps.InspectFileLineageRequest{
+			File: NewFile(repoName, commitID, path),
+		},
+	)
+	if err != nil {
+		return nil, grpcutil.ScrubGRPC(err)
+	}
+	return lineage, nil
+}
+
 // LogsIter iterates through log messages returned from pps.GetLogs. Logs can
 // be fetched with 'Next()'. The log message received can be examined with
 // 'Message()', and any errors can be examined with 'Err()'.
@@ -575,8 +782,16 @@ func (c APIClient) getLogs(
 			ID:  datumID,
 		}
 	}
+	return c.QueryLogs(&request)
+}
+
+// QueryLogs gets the logs selected by 'request', which can filter logs by
+// time range, level, message and worker, as well as by the filters that
+// GetLogs takes. Logs are returned in timestamp order unless they're being
+// followed.
+func (c APIClient) QueryLogs(request *pps.GetLogsRequest) *LogsIter {
 	resp := &LogsIter{}
-	resp.logsClient, resp.err = c.PpsAPIClient.GetLogs(c.Ctx(), &request)
+	resp.logsClient, resp.err = c.PpsAPIClient.GetLogs(c.Ctx(), request)
 	resp.err = grpcutil.ScrubGRPC(resp.err)
 	return resp
 }
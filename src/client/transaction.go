@@ -417,12 +417,21 @@ func (c *ppsBuilderClient) ListJob(ctx context.Context, req *pps.ListJobRequest,
 func (c *ppsBuilderClient) FlushJob(ctx context.Context, req *pps.FlushJobRequest, opts ...grpc.CallOption) (pps.API_FlushJobClient, error) {
 	return nil, unsupportedError("FlushJob")
 }
+func (c *ppsBuilderClient) WatchJobStatus(ctx context.Context, req *pps.WatchJobStatusRequest, opts ...grpc.CallOption) (pps.API_WatchJobStatusClient, error) {
+	return nil, unsupportedError("WatchJobStatus")
+}
+func (c *ppsBuilderClient) InspectCommitSet(ctx context.Context, req *pps.InspectCommitSetRequest, opts ...grpc.CallOption) (*pps.CommitSetInfo, error) {
+	return nil, unsupportedError("InspectCommitSet")
+}
 func (c *ppsBuilderClient) DeleteJob(ctx context.Context, req *pps.DeleteJobRequest, opts ...grpc.CallOption) (*types.Empty, error) {
 	return nil, unsupportedError("DeleteJob")
 }
 func (c *ppsBuilderClient) StopJob(ctx context.Context, req *pps.StopJobRequest, opts ...grpc.CallOption) (*types.Empty, error) {
 	return nil, unsupportedError("StopJob")
 }
+func (c *ppsBuilderClient) EgressJob(ctx context.Context, req *pps.EgressJobRequest, opts ...grpc.CallOption) (*pps.EgressStatus, error) {
+	return nil, unsupportedError("EgressJob")
+}
 func (c *ppsBuilderClient) InspectDatum(ctx context.Context, req *pps.InspectDatumRequest, opts ...grpc.CallOption) (*pps.DatumInfo, error) {
 	return nil, unsupportedError("InspectDatum")
 }
@@ -432,6 +441,12 @@ func (c *ppsBuilderClient) ListDatum(ctx context.Context, req *pps.ListDatumRequ
 func (c *ppsBuilderClient) RestartDatum(ctx context.Context, req *pps.RestartDatumRequest, opts ...grpc.CallOption) (*types.Empty, error) {
 	return nil, unsupportedError("RestartDatum")
 }
+func (c *ppsBuilderClient) DebugDatum(ctx context.Context, opts ...grpc.CallOption) (pps.API_DebugDatumClient, error) {
+	return nil, unsupportedError("DebugDatum")
+}
+func (c *ppsBuilderClient) InspectFileLineage(ctx context.Context, req *pps.InspectFileLineageRequest, opts ...grpc.CallOption) (*pps.FileLineage, error) {
+	return nil, unsupportedError("InspectFileLineage")
+}
 func (c *ppsBuilderClient) CreatePipeline(ctx context.Context, req *pps.CreatePipelineRequest, opts ...grpc.CallOption) (*types.Empty, error) {
 	return nil, unsupportedError("CreatePipeline")
 }
@@ -441,6 +456,9 @@ func (c *ppsBuilderClient) InspectPipeline(ctx context.Context, req *pps.Inspect
 func (c *ppsBuilderClient) ListPipeline(ctx context.Context, req *pps.ListPipelineRequest, opts ...grpc.CallOption) (*pps.PipelineInfos, error) {
 	return nil, unsupportedError("ListPipeline")
 }
+func (c *ppsBuilderClient) ListPipelineHistory(ctx context.Context, req *pps.ListPipelineHistoryRequest, opts ...grpc.CallOption) (*pps.PipelineInfos, error) {
+	return nil, unsupportedError("ListPipelineHistory")
+}
 func (c *ppsBuilderClient) DeletePipeline(ctx context.Context, req *pps.DeletePipelineRequest, opts ...grpc.CallOption) (*types.Empty, error) {
 	return nil, unsupportedError("DeletePipeline")
 }
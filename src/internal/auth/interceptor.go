@@ -180,34 +180,40 @@ var authHandlers = map[string]authHandler{
 
 	// TODO: Add per-repo permissions checks for these
 	// TODO: split GetLogs into master and not-master and add check for pipeline permissions
-	"/pps.API/CreateJob":       authDisabledOr(authenticated),
-	"/pps.API/InspectJob":      authDisabledOr(authenticated),
-	"/pps.API/ListJob":         authDisabledOr(authenticated),
-	"/pps.API/ListJobStream":   authDisabledOr(authenticated),
-	"/pps.API/FlushJob":        authDisabledOr(authenticated),
-	"/pps.API/DeleteJob":       authDisabledOr(authenticated),
-	"/pps.API/StopJob":         authDisabledOr(authenticated),
-	"/pps.API/InspectDatum":    authDisabledOr(authenticated),
-	"/pps.API/ListDatum":       authDisabledOr(authenticated),
-	"/pps.API/ListDatumStream": authDisabledOr(authenticated),
-	"/pps.API/RestartDatum":    authDisabledOr(authenticated),
-	"/pps.API/CreatePipeline":  authDisabledOr(authenticated),
-	"/pps.API/InspectPipeline": authDisabledOr(authenticated),
-	"/pps.API/DeletePipeline":  authDisabledOr(authenticated),
-	"/pps.API/StartPipeline":   authDisabledOr(authenticated),
-	"/pps.API/StopPipeline":    authDisabledOr(authenticated),
-	"/pps.API/RunPipeline":     authDisabledOr(authenticated),
-	"/pps.API/RunCron":         authDisabledOr(authenticated),
-	"/pps.API/CreateSecret":    authDisabledOr(authenticated),
-	"/pps.API/DeleteSecret":    authDisabledOr(authenticated),
-	"/pps.API/ListSecret":      authDisabledOr(authenticated),
-	"/pps.API/InspectSecret":   authDisabledOr(authenticated),
-	"/pps.API/GetLogs":         authDisabledOr(authenticated),
-	"/pps.API/GarbageCollect":  authDisabledOr(authenticated),
-	"/pps.API/UpdateJobState":  authDisabledOr(authenticated),
-	"/pps.API/ListPipeline":    authDisabledOr(authenticated),
-	"/pps.API/ActivateAuth":    clusterPermissions(auth.Permission_CLUSTER_AUTH_ACTIVATE),
-	"/pps.API/DeleteAll":       authDisabledOr(clusterPermissions(auth.Permission_CLUSTER_DELETE_ALL)),
+	"/pps.API/CreateJob":           authDisabledOr(authenticated),
+	"/pps.API/InspectJob":          authDisabledOr(authenticated),
+	"/pps.API/ListJob":             authDisabledOr(authenticated),
+	"/pps.API/ListJobStream":       authDisabledOr(authenticated),
+	"/pps.API/FlushJob":            authDisabledOr(authenticated),
+	"/pps.API/WatchJobStatus":      authDisabledOr(authenticated),
+	"/pps.API/InspectCommitSet":    authDisabledOr(authenticated),
+	"/pps.API/DeleteJob":           authDisabledOr(authenticated),
+	"/pps.API/StopJob":             authDisabledOr(authenticated),
+	"/pps.API/EgressJob":           authDisabledOr(authenticated),
+	"/pps.API/InspectDatum":        authDisabledOr(authenticated),
+	"/pps.API/ListDatum":           authDisabledOr(authenticated),
+	"/pps.API/ListDatumStream":     authDisabledOr(authenticated),
+	"/pps.API/RestartDatum":        authDisabledOr(authenticated),
+	"/pps.API/DebugDatum":          authDisabledOr(authenticated),
+	"/pps.API/InspectFileLineage":  authDisabledOr(authenticated),
+	"/pps.API/CreatePipeline":      authDisabledOr(authenticated),
+	"/pps.API/InspectPipeline":     authDisabledOr(authenticated),
+	"/pps.API/DeletePipeline":      authDisabledOr(authenticated),
+	"/pps.API/StartPipeline":       authDisabledOr(authenticated),
+	"/pps.API/StopPipeline":        authDisabledOr(authenticated),
+	"/pps.API/RunPipeline":         authDisabledOr(authenticated),
+	"/pps.API/RunCron":             authDisabledOr(authenticated),
+	"/pps.API/CreateSecret":        authDisabledOr(authenticated),
+	"/pps.API/DeleteSecret":        authDisabledOr(authenticated),
+	"/pps.API/ListSecret":          authDisabledOr(authenticated),
+	"/pps.API/InspectSecret":       authDisabledOr(authenticated),
+	"/pps.API/GetLogs":             authDisabledOr(authenticated),
+	"/pps.API/GarbageCollect":      authDisabledOr(authenticated),
+	"/pps.API/UpdateJobState":      authDisabledOr(authenticated),
+	"/pps.API/ListPipeline":        authDisabledOr(authenticated),
+	"/pps.API/ListPipelineHistory": authDisabledOr(authenticated),
+	"/pps.API/ActivateAuth":        clusterPermissions(auth.Permission_CLUSTER_AUTH_ACTIVATE),
+	"/pps.API/DeleteAll":           authDisabledOr(clusterPermissions(auth.Permission_CLUSTER_DELETE_ALL)),
 
 	//
 	// TransactionAPI
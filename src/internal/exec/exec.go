@@ -32,6 +32,7 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 )
@@ -115,6 +116,18 @@ type Cmd struct {
 	// available after a call to Wait or Run.
 	ProcessState *os.ProcessState
 
+	// GracePeriod, if non-zero, changes how the process is stopped when the
+	// context passed to CommandContext becomes done. Instead of being killed
+	// immediately, the process is sent SIGTERM, and is only killed once
+	// OnCancel has returned and the grace period has elapsed (or the process
+	// has exited).
+	GracePeriod time.Duration
+
+	// OnCancel, if set, is called after the process is sent SIGTERM, with a
+	// context that becomes done when the grace period elapses. It's only
+	// called if GracePeriod is non-zero.
+	OnCancel func(ctx context.Context)
+
 	ctx             context.Context // nil means none
 	lookPathErr     error           // LookPath error, if any.
 	finished        bool            // when Wait was called
@@ -388,7 +401,7 @@ func (c *Cmd) Start() error {
 		go func() {
 			select {
 			case <-c.ctx.Done():
-				c.Process.Kill()
+				c.stop()
 			case <-c.waitDone:
 			}
 		}()
@@ -397,6 +410,29 @@ func (c *Cmd) Start() error {
 	return nil
 }
 
+// stop stops the process after its context becomes done, giving it the
+// command's grace period to exit if it has one.
+func (c *Cmd) stop() {
+	if c.GracePeriod <= 0 {
+		c.Process.Kill()
+		return
+	}
+	if err := c.Process.Signal(syscall.SIGTERM); err != nil {
+		// The process can't be signalled (e.g. it has already exited)
+		c.Process.Kill()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.GracePeriod)
+	defer cancel()
+	if c.OnCancel != nil {
+		c.OnCancel(ctx)
+	}
+	select {
+	case <-ctx.Done():
+	case <-c.waitDone:
+	}
+	c.Process.Kill()
+}
+
 // An ExitError reports an unsuccessful exit by a command.
 type ExitError struct {
 	*os.ProcessState
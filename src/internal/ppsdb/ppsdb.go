@@ -11,8 +11,9 @@ import (
 )
 
 const (
-	pipelinesPrefix = "/pipelines"
-	jobsPrefix      = "/jobs"
+	pipelinesPrefix     = "/pipelines"
+	jobsPrefix          = "/jobs"
+	notificationsPrefix = "/notifications"
 )
 
 var (
@@ -25,6 +26,10 @@ var (
 
 	// JobsOutputIndex maps job outputs to the job that create them.
 	JobsOutputIndex = &col.Index{Field: "OutputCommit", Multi: false}
+
+	// NotificationsPipelineIndex maps pipeline to the notifications sent for
+	// the pipeline
+	NotificationsPipelineIndex = &col.Index{Field: "Pipeline", Multi: false}
 )
 
 // Pipelines returns a Collection of pipelines
@@ -50,3 +55,15 @@ func Jobs(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
 		nil,
 	)
 }
+
+// Notifications returns a Collection of pipeline notifications
+func Notifications(etcdClient *etcd.Client, etcdPrefix string) col.Collection {
+	return col.NewCollection(
+		etcdClient,
+		path.Join(etcdPrefix, notificationsPrefix),
+		[]*col.Index{NotificationsPipelineIndex},
+		&pps.NotificationInfo{},
+		nil,
+		nil,
+	)
+}
@@ -0,0 +1,84 @@
+package ppsutil
+
+import (
+	"fmt"
+	"strings"
+
+	glob "github.com/pachyderm/ohmyglob"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errutil"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+)
+
+// ValidatePathFilter returns an error if 'pathFilter' is not a valid glob
+// pattern.
+func ValidatePathFilter(pathFilter string) error {
+	if _, err := glob.Compile(pathFilter, '/'); err != nil {
+		return errors.Wrapf(err, "invalid path_filter %q", pathFilter)
+	}
+	return nil
+}
+
+// PathFilterSkipReason determines whether a job with the given input can be
+// skipped because of its inputs' path filters. A job is only skippable if
+// every input in it is a PFS input with 'path_filter' set, and none of those
+// inputs changed a path matching its filter relative to the parent of the
+// input commit. If the job can be skipped, the returned string explains why,
+// otherwise it is empty.
+func PathFilterSkipReason(pachClient *client.APIClient, input *pps.Input) (string, error) {
+	var filtered []*pps.PFSInput
+	unfiltered := false
+	pps.VisitInput(input, func(input *pps.Input) {
+		switch {
+		case input.Pfs != nil && input.Pfs.PathFilter != "":
+			filtered = append(filtered, input.Pfs)
+		case input.Pfs != nil, input.Cron != nil, input.Git != nil, input.Window != nil, input.SQL != nil:
+			unfiltered = true
+		}
+	})
+	if unfiltered || len(filtered) == 0 {
+		return "", nil
+	}
+	var names []string
+	for _, pfsInput := range filtered {
+		changed, err := pathFilterMatched(pachClient, pfsInput)
+		if err != nil {
+			return "", err
+		}
+		if changed {
+			return "", nil
+		}
+		names = append(names, fmt.Sprintf("%s (%s)", pfsInput.Name, pfsInput.PathFilter))
+	}
+	return fmt.Sprintf("no changed paths matched the path_filter of inputs: %s", strings.Join(names, ", ")), nil
+}
+
+// pathFilterMatched returns true if the commit in 'input' changed at least one
+// path that matches the input's path filter.
+func pathFilterMatched(pachClient *client.APIClient, input *pps.PFSInput) (bool, error) {
+	if input.Commit == "" {
+		// This can happen if a pipeline with multiple inputs has been
+		// triggered before all inputs have commits.
+		return false, nil
+	}
+	g, err := glob.Compile(input.PathFilter, '/')
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid path_filter %q", input.PathFilter)
+	}
+	matched := false
+	if err := pachClient.DiffFile(input.Repo, input.Commit, "/", "", "", "", false, func(newFile, oldFile *pfs.FileInfo) error {
+		for _, fi := range []*pfs.FileInfo{newFile, oldFile} {
+			if fi != nil && fi.FileType == pfs.FileType_FILE && g.Match(fi.File.Path) {
+				matched = true
+				return errutil.ErrBreak
+			}
+		}
+		return nil
+	}); err != nil && !errors.Is(err, errutil.ErrBreak) {
+		return false, errors.Wrapf(err, "error diffing commit %s@%s", input.Repo, input.Commit)
+	}
+	return matched, nil
+}
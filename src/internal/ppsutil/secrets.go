@@ -0,0 +1,31 @@
+package ppsutil
+
+import (
+	"net/url"
+	"path"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+)
+
+// ParseSecretRef parses a reference to a secret in an external secret store
+// (see pps.SecretMount.External), and returns an error if it isn't of a
+// supported form: "file:///path[#key]" or "vault://path#key".
+func ParseSecretRef(ref string) (*url.URL, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid secret reference %q", ref)
+	}
+	switch u.Scheme {
+	case "file":
+		if u.Host != "" || !path.IsAbs(u.Path) {
+			return nil, errors.Errorf("invalid secret reference %q: file secrets must have the form file:///path[#key]", ref)
+		}
+	case "vault":
+		if u.Host == "" || u.Fragment == "" {
+			return nil, errors.Errorf("invalid secret reference %q: vault secrets must have the form vault://path#key", ref)
+		}
+	default:
+		return nil, errors.Errorf("invalid secret reference %q: unsupported secret store %q", ref, u.Scheme)
+	}
+	return u, nil
+}
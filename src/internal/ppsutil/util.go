@@ -288,11 +288,21 @@ func JobInput(pipelineInfo *pps.PipelineInfo, outputCommitInfo *pfs.CommitInfo)
 				input.Cron.Commit = commit.ID
 			}
 		}
+		if input.SQL != nil {
+			if commit, ok := branchToCommit[key(input.SQL.Repo, "master")]; ok {
+				input.SQL.Commit = commit.ID
+			}
+		}
 		if input.Git != nil {
 			if commit, ok := branchToCommit[key(input.Git.Name, input.Git.Branch)]; ok {
 				input.Git.Commit = commit.ID
 			}
 		}
+		if input.Window != nil {
+			if commit, ok := branchToCommit[key(input.Window.Repo, input.Window.Branch)]; ok {
+				input.Window.Commit = commit.ID
+			}
+		}
 	})
 	return jobInput
 }
@@ -317,6 +327,7 @@ func PipelineReqFromInfo(pipelineInfo *pps.PipelineInfo) *pps.CreatePipelineRequ
 		ChunkSpec:             pipelineInfo.ChunkSpec,
 		DatumTimeout:          pipelineInfo.DatumTimeout,
 		JobTimeout:            pipelineInfo.JobTimeout,
+		TimeoutPolicy:         pipelineInfo.TimeoutPolicy,
 		Salt:                  pipelineInfo.Salt,
 		PodSpec:               pipelineInfo.PodSpec,
 		PodPatch:              pipelineInfo.PodPatch,
@@ -326,15 +337,58 @@ func PipelineReqFromInfo(pipelineInfo *pps.PipelineInfo) *pps.CreatePipelineRequ
 		Standby:               pipelineInfo.Standby,
 		S3Out:                 pipelineInfo.S3Out,
 		Metadata:              pipelineInfo.Metadata,
+		Notifications:         pipelineInfo.Notifications,
+		State:                 pipelineInfo.StateSpec,
+		DebugOnFailure:        pipelineInfo.DebugOnFailure,
+		Prefetch:              pipelineInfo.Prefetch,
+		NoSidecar:             pipelineInfo.NoSidecar,
+	}
+}
+
+// IsStateful returns 'true' if the pipeline carries state from one job to the
+// next in /pfs/state.
+func IsStateful(pipelineInfo *pps.PipelineInfo) bool {
+	return pipelineInfo.StateSpec != nil && pipelineInfo.StateSpec.Enabled
+}
+
+// PrefetchLimits returns the scratch space and memory limits, in bytes, of
+// 'spec'. Limits that aren't set are zero.
+func PrefetchLimits(spec *pps.PrefetchSpec) (disk, memory uint64, retErr error) {
+	parse := func(name, quantity string) uint64 {
+		if quantity == "" || retErr != nil {
+			return 0
+		}
+		q, err := resource.ParseQuantity(quantity)
+		if err != nil {
+			retErr = errors.Wrapf(err, "could not parse %s", name)
+			return 0
+		}
+		if q.Sign() < 0 {
+			retErr = errors.Errorf("%s must not be negative", name)
+			return 0
+		}
+		return uint64(q.Value())
+	}
+	disk = parse("disk", spec.Disk)
+	memory = parse("memory", spec.Memory)
+	return disk, memory, retErr
+}
+
+// CancelGracePeriod returns how long cancelled user code of 'transform' has to
+// shut down before it's killed, or zero if it's killed immediately.
+func CancelGracePeriod(transform *pps.Transform) (time.Duration, error) {
+	if transform == nil || transform.CancelGracePeriod == nil {
+		return 0, nil
 	}
+	return types.DurationFromProto(transform.CancelGracePeriod)
 }
 
 // IsTerminal returns 'true' if 'state' indicates that the job is done (i.e.
-// the state will not change later: SUCCESS, FAILURE, KILLED) and 'false'
-// otherwise.
+// the state will not change later: SUCCESS, FAILURE, KILLED, SKIPPED) and
+// 'false' otherwise.
 func IsTerminal(state pps.JobState) bool {
 	switch state {
-	case pps.JobState_JOB_SUCCESS, pps.JobState_JOB_FAILURE, pps.JobState_JOB_KILLED:
+	case pps.JobState_JOB_SUCCESS, pps.JobState_JOB_FAILURE, pps.JobState_JOB_KILLED, pps.JobState_JOB_SKIPPED:
 		return true
 	case pps.JobState_JOB_STARTING, pps.JobState_JOB_RUNNING, pps.JobState_JOB_EGRESSING:
 		return false
@@ -418,6 +472,7 @@ func WriteJobInfo(pachClient *client.APIClient, jobInfo *pps.JobInfo) error {
 		DataFailed:    jobInfo.DataFailed,
 		DataRecovered: jobInfo.DataRecovered,
 		Stats:         jobInfo.Stats,
+		EgressStatus:  jobInfo.EgressStatus,
 	})
 	return err
 }
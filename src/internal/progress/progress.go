@@ -228,3 +228,57 @@ func (f *File) Finish() {
 		f.bar.SetTotal(0, true)
 	}
 }
+
+// Bar is a progress bar whose progress is set explicitly, rather than by
+// reading or writing a file.
+type Bar struct {
+	bar   *mpb.Bar
+	total int64
+
+	mu   sync.Mutex
+	info string
+}
+
+// NewBar creates a progress bar labeled 'name' which is complete at 'total'.
+func NewBar(name string, total int64) *Bar {
+	initContainer()
+	b := &Bar{total: total}
+	if container != nil {
+		b.bar = container.AddBar(total,
+			mpb.PrependDecorators(decor.Name(name),
+				decor.Name(" "),
+				decor.Percentage()),
+			mpb.AppendDecorators(decor.Any(func(decor.Statistics) string {
+				b.mu.Lock()
+				defer b.mu.Unlock()
+				return b.info
+			})))
+	}
+	return b
+}
+
+// SetCurrent sets the progress of the bar. The bar is held just short of its
+// total until Finish is called, because a complete bar stops updating.
+func (b *Bar) SetCurrent(n int64) {
+	if b.bar == nil {
+		return
+	}
+	if n >= b.total {
+		n = b.total - 1
+	}
+	b.bar.SetCurrent(n)
+}
+
+// SetInfo sets the text that is printed after the bar.
+func (b *Bar) SetInfo(info string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.info = info
+}
+
+// Finish completes the bar.
+func (b *Bar) Finish() {
+	if b.bar != nil {
+		b.bar.SetCurrent(b.total)
+	}
+}
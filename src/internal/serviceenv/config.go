@@ -68,6 +68,7 @@ type PachdSpecificConfiguration struct {
 	DeploymentID               string `env:"CLUSTER_DEPLOYMENT_ID,default="`
 	RequireCriticalServersOnly bool   `env:"REQUIRE_CRITICAL_SERVERS_ONLY",default=false"`
 	MetricsEndpoint            string `env:"METRICS_ENDPOINT",default="`
+	PPSTeamBudgets             string `env:"PPS_TEAM_BUDGETS,default="`
 	// TODO: Merge this with the worker specific pod name (PPS_POD_NAME) into a global configuration pod name.
 	PachdPodName        string `env:"PACHD_POD_NAME,required"`
 	PostgresServiceHost string `env:"POSTGRES_SERVICE_HOST"`
@@ -109,6 +110,8 @@ type WorkerSpecificConfiguration struct {
 	PPSPipelineName string `env:"PPS_PIPELINE_NAME,required"`
 	// The name of this pod
 	PodName string `env:"PPS_POD_NAME,required"`
+	// The address of pachd, if this worker's pipeline runs without a sidecar
+	PachdAddress string `env:"PPS_PACHD_ADDRESS"`
 }
 
 // FeatureFlags contains the configuration for feature flags.  XXX: if you're
@@ -77,6 +77,10 @@ type ServiceEnv struct {
 func InitPachOnlyEnv(config *Configuration) *ServiceEnv {
 	env := &ServiceEnv{Configuration: config}
 	env.pachAddress = net.JoinHostPort("127.0.0.1", fmt.Sprintf("%d", env.PeerPort))
+	if env.WorkerSpecificConfiguration != nil && env.PachdAddress != "" {
+		// This worker's pipeline runs without a sidecar, so talk to pachd
+		env.pachAddress = env.PachdAddress
+	}
 	env.pachEg.Go(env.initPachClient)
 	return env // env is not ready yet
 }
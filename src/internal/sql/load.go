@@ -0,0 +1,162 @@
+package sql
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/dbutil"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+)
+
+// LoadRows loads the rows in 'r', which is in 'format', into 'table'. The rows
+// are first copied into a temporary table, and then inserted into 'table'. If
+// 'primaryKey' is set, rows that conflict with an existing row on those
+// columns replace it. The load is atomic, either every row is loaded or none
+// are.
+func LoadRows(ctx context.Context, db *sqlx.DB, table string, primaryKey []string, format string, r io.Reader) error {
+	columns, rows, err := readRows(r, format)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+	return dbutil.WithTx(ctx, db, func(tx *sqlx.Tx) error {
+		const tmpTable = "pachyderm_egress"
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("CREATE TEMP TABLE %s (LIKE %s INCLUDING DEFAULTS) ON COMMIT DROP",
+			tmpTable, quoteTable(table))); err != nil {
+			return errors.EnsureStack(err)
+		}
+		stmt, err := tx.PrepareContext(ctx, pq.CopyIn(tmpTable, columns...))
+		if err != nil {
+			return errors.EnsureStack(err)
+		}
+		for _, row := range rows {
+			if _, err := stmt.ExecContext(ctx, row...); err != nil {
+				stmt.Close()
+				return errors.EnsureStack(err)
+			}
+		}
+		if _, err := stmt.ExecContext(ctx); err != nil {
+			stmt.Close()
+			return errors.EnsureStack(err)
+		}
+		if err := stmt.Close(); err != nil {
+			return errors.EnsureStack(err)
+		}
+		quoted := quoteAll(columns)
+		query := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s",
+			quoteTable(table), strings.Join(quoted, ", "), strings.Join(quoted, ", "), tmpTable)
+		if len(primaryKey) > 0 {
+			var updates []string
+			for _, c := range quoted {
+				updates = append(updates, fmt.Sprintf("%s = EXCLUDED.%s", c, c))
+			}
+			query += fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s",
+				strings.Join(quoteAll(primaryKey), ", "), strings.Join(updates, ", "))
+		}
+		_, err = tx.ExecContext(ctx, query)
+		return errors.EnsureStack(err)
+	})
+}
+
+// readRows reads the column names and rows from 'r'.
+func readRows(r io.Reader, format string) ([]string, [][]interface{}, error) {
+	switch format {
+	case CSVFormat:
+		cr := csv.NewReader(r)
+		columns, err := cr.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, nil, nil
+			}
+			return nil, nil, errors.EnsureStack(err)
+		}
+		var rows [][]interface{}
+		for {
+			record, err := cr.Read()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					return columns, rows, nil
+				}
+				return nil, nil, errors.EnsureStack(err)
+			}
+			row := make([]interface{}, len(record))
+			for i, v := range record {
+				// CSV can't distinguish NULL from an empty string, empty
+				// fields are loaded as NULL (matching how WriteRows writes NULL)
+				if v != "" {
+					row[i] = v
+				}
+			}
+			rows = append(rows, row)
+		}
+	case JSONLinesFormat:
+		var objects []map[string]interface{}
+		seen := make(map[string]bool)
+		var columns []string
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(nil, 64*1024*1024)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			object := make(map[string]interface{})
+			if err := json.Unmarshal([]byte(line), &object); err != nil {
+				return nil, nil, errors.Wrapf(err, "invalid JSON line")
+			}
+			for c := range object {
+				if !seen[c] {
+					seen[c] = true
+					columns = append(columns, c)
+				}
+			}
+			objects = append(objects, object)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, nil, errors.EnsureStack(err)
+		}
+		sort.Strings(columns)
+		var rows [][]interface{}
+		for _, object := range objects {
+			row := make([]interface{}, len(columns))
+			for i, c := range columns {
+				switch v := object[c].(type) {
+				case map[string]interface{}, []interface{}:
+					data, err := json.Marshal(v)
+					if err != nil {
+						return nil, nil, errors.EnsureStack(err)
+					}
+					row[i] = string(data)
+				default:
+					row[i] = v
+				}
+			}
+			rows = append(rows, row)
+		}
+		return columns, rows, nil
+	}
+	return nil, nil, ValidateFormat(format)
+}
+
+func quoteTable(table string) string {
+	return strings.Join(quoteAll(strings.Split(table, ".")), ".")
+}
+
+func quoteAll(identifiers []string) []string {
+	var quoted []string
+	for _, identifier := range identifiers {
+		quoted = append(quoted, pq.QuoteIdentifier(identifier))
+	}
+	return quoted
+}
@@ -0,0 +1,170 @@
+package sql
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+)
+
+const (
+	// CSVFormat writes query results as CSV, with a header row containing the
+	// column names.
+	CSVFormat = "csv"
+	// JSONLinesFormat writes query results as one JSON object per line, keyed
+	// by column name.
+	JSONLinesFormat = "jsonl"
+)
+
+// ValidateFormat returns an error if 'format' is not a supported format for
+// query results.
+func ValidateFormat(format string) error {
+	switch format {
+	case CSVFormat, JSONLinesFormat:
+		return nil
+	}
+	return errors.Errorf("unsupported format %q, must be %q or %q", format, CSVFormat, JSONLinesFormat)
+}
+
+// WriteRows writes 'rows' in 'format', splitting them into chunks of at most
+// 'rowsPerChunk' rows (or a single chunk if 'rowsPerChunk' is 0). 'cb' is
+// called with each chunk in order, CSV chunks each begin with a header row.
+// At least one chunk is always written, so an empty result still produces a
+// (possibly header-only) chunk.
+func WriteRows(rows *sql.Rows, format string, rowsPerChunk int64, cb func(r io.Reader) error) error {
+	if err := ValidateFormat(format); err != nil {
+		return err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	buf := &bytes.Buffer{}
+	var w rowWriter
+	var n, chunks int64
+	flush := func() error {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if err := cb(buf); err != nil {
+			return err
+		}
+		buf = &bytes.Buffer{}
+		w, n = nil, 0
+		chunks++
+		return nil
+	}
+	values := make([]interface{}, len(columns))
+	ptrs := make([]interface{}, len(columns))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+	for rows.Next() {
+		if w == nil {
+			if w, err = newRowWriter(buf, format, columns); err != nil {
+				return err
+			}
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return errors.EnsureStack(err)
+		}
+		if err := w.WriteRow(values); err != nil {
+			return err
+		}
+		n++
+		if rowsPerChunk > 0 && n >= rowsPerChunk {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.EnsureStack(err)
+	}
+	if w == nil {
+		if chunks > 0 {
+			return nil
+		}
+		if w, err = newRowWriter(buf, format, columns); err != nil {
+			return err
+		}
+	}
+	return flush()
+}
+
+type rowWriter interface {
+	WriteRow(values []interface{}) error
+	Flush() error
+}
+
+func newRowWriter(w io.Writer, format string, columns []string) (rowWriter, error) {
+	switch format {
+	case CSVFormat:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+		return &csvWriter{w: cw, record: make([]string, len(columns))}, nil
+	case JSONLinesFormat:
+		return &jsonLinesWriter{enc: json.NewEncoder(w), columns: columns}, nil
+	}
+	return nil, ValidateFormat(format)
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (cw *csvWriter) WriteRow(values []interface{}) error {
+	for i, v := range values {
+		switch v := normalize(v).(type) {
+		case nil:
+			cw.record[i] = ""
+		case string:
+			cw.record[i] = v
+		default:
+			cw.record[i] = fmt.Sprint(v)
+		}
+	}
+	return errors.EnsureStack(cw.w.Write(cw.record))
+}
+
+func (cw *csvWriter) Flush() error {
+	cw.w.Flush()
+	return errors.EnsureStack(cw.w.Error())
+}
+
+type jsonLinesWriter struct {
+	enc     *json.Encoder
+	columns []string
+}
+
+func (jw *jsonLinesWriter) WriteRow(values []interface{}) error {
+	row := make(map[string]interface{}, len(values))
+	for i, v := range values {
+		row[jw.columns[i]] = normalize(v)
+	}
+	return errors.EnsureStack(jw.enc.Encode(row))
+}
+
+func (jw *jsonLinesWriter) Flush() error {
+	return nil
+}
+
+// normalize converts the values returned by database drivers into values that
+// serialize naturally as text.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return v
+}
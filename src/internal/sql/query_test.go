@@ -0,0 +1,85 @@
+package sql
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/dbutil"
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+)
+
+func TestWriteRows(t *testing.T) {
+	db := dbutil.NewTestDB(t)
+	db.MustExec("CREATE TABLE test_rows (id INT, name TEXT)")
+	db.MustExec("INSERT INTO test_rows VALUES (1, 'a'), (2, NULL), (3, 'c, d')")
+	writeRows := func(format string, rowsPerChunk int64) []string {
+		rows, err := db.Query("SELECT id, name FROM test_rows ORDER BY id")
+		require.NoError(t, err)
+		defer rows.Close()
+		var chunks []string
+		require.NoError(t, WriteRows(rows, format, rowsPerChunk, func(r io.Reader) error {
+			data, err := ioutil.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			chunks = append(chunks, string(data))
+			return nil
+		}))
+		return chunks
+	}
+	t.Run("CSV", func(t *testing.T) {
+		require.Equal(t, []string{"id,name\n1,a\n2,\n3,\"c, d\"\n"}, writeRows(CSVFormat, 0))
+	})
+	t.Run("CSVChunks", func(t *testing.T) {
+		require.Equal(t, []string{"id,name\n1,a\n2,\n", "id,name\n3,\"c, d\"\n"}, writeRows(CSVFormat, 2))
+	})
+	t.Run("JSONLines", func(t *testing.T) {
+		require.Equal(t, []string{
+			"{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":null}\n{\"id\":3,\"name\":\"c, d\"}\n",
+		}, writeRows(JSONLinesFormat, 0))
+	})
+	t.Run("Empty", func(t *testing.T) {
+		rows, err := db.Query("SELECT id, name FROM test_rows WHERE id > 10")
+		require.NoError(t, err)
+		defer rows.Close()
+		var chunks []string
+		require.NoError(t, WriteRows(rows, CSVFormat, 0, func(r io.Reader) error {
+			data, err := ioutil.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			chunks = append(chunks, string(data))
+			return nil
+		}))
+		require.Equal(t, []string{"id,name\n"}, chunks)
+	})
+	t.Run("InvalidFormat", func(t *testing.T) {
+		require.YesError(t, ValidateFormat("xml"))
+	})
+}
+
+func TestLoadRows(t *testing.T) {
+	db := dbutil.NewTestDB(t)
+	db.MustExec("CREATE TABLE test_load (id INT PRIMARY KEY, name TEXT)")
+	ctx := context.Background()
+	require.NoError(t, LoadRows(ctx, db, "test_load", []string{"id"}, CSVFormat,
+		strings.NewReader("id,name\n1,a\n2,\n")))
+	// Loading again upserts on the primary key
+	require.NoError(t, LoadRows(ctx, db, "test_load", []string{"id"}, JSONLinesFormat,
+		strings.NewReader("{\"id\":2,\"name\":\"b\"}\n{\"id\":3,\"name\":\"c\"}\n")))
+	var rows []struct {
+		ID   int     `db:"id"`
+		Name *string `db:"name"`
+	}
+	require.NoError(t, db.Select(&rows, "SELECT id, name FROM test_load ORDER BY id"))
+	require.Equal(t, 3, len(rows))
+	require.Equal(t, "a", *rows[0].Name)
+	require.Equal(t, "b", *rows[1].Name)
+	require.Equal(t, "c", *rows[2].Name)
+	// Without a primary key, conflicting rows are an error
+	require.YesError(t, LoadRows(ctx, db, "test_load", nil, CSVFormat,
+		strings.NewReader("id,name\n1,z\n")))
+}
@@ -679,15 +679,21 @@ type createJobFunc func(context.Context, *pps.CreateJobRequest) (*pps.Job, error
 type inspectJobFunc func(context.Context, *pps.InspectJobRequest) (*pps.JobInfo, error)
 type listJobFunc func(*pps.ListJobRequest, pps.API_ListJobServer) error
 type flushJobFunc func(*pps.FlushJobRequest, pps.API_FlushJobServer) error
+type watchJobStatusFunc func(*pps.WatchJobStatusRequest, pps.API_WatchJobStatusServer) error
+type inspectCommitSetFunc func(context.Context, *pps.InspectCommitSetRequest) (*pps.CommitSetInfo, error)
 type deleteJobFunc func(context.Context, *pps.DeleteJobRequest) (*types.Empty, error)
 type stopJobFunc func(context.Context, *pps.StopJobRequest) (*types.Empty, error)
+type egressJobFunc func(context.Context, *pps.EgressJobRequest) (*pps.EgressStatus, error)
 type updateJobStateFunc func(context.Context, *pps.UpdateJobStateRequest) (*types.Empty, error)
 type inspectDatumFunc func(context.Context, *pps.InspectDatumRequest) (*pps.DatumInfo, error)
 type listDatumFunc func(*pps.ListDatumRequest, pps.API_ListDatumServer) error
 type restartDatumFunc func(context.Context, *pps.RestartDatumRequest) (*types.Empty, error)
+type debugDatumFunc func(pps.API_DebugDatumServer) error
+type inspectFileLineageFunc func(context.Context, *pps.InspectFileLineageRequest) (*pps.FileLineage, error)
 type createPipelineFunc func(context.Context, *pps.CreatePipelineRequest) (*types.Empty, error)
 type inspectPipelineFunc func(context.Context, *pps.InspectPipelineRequest) (*pps.PipelineInfo, error)
 type listPipelineFunc func(context.Context, *pps.ListPipelineRequest) (*pps.PipelineInfos, error)
+type listPipelineHistoryFunc func(context.Context, *pps.ListPipelineHistoryRequest) (*pps.PipelineInfos, error)
 type deletePipelineFunc func(context.Context, *pps.DeletePipelineRequest) (*types.Empty, error)
 type startPipelineFunc func(context.Context, *pps.StartPipelineRequest) (*types.Empty, error)
 type stopPipelineFunc func(context.Context, *pps.StopPipelineRequest) (*types.Empty, error)
@@ -705,15 +711,21 @@ type mockCreateJob struct{ handler createJobFunc }
 type mockInspectJob struct{ handler inspectJobFunc }
 type mockListJob struct{ handler listJobFunc }
 type mockFlushJob struct{ handler flushJobFunc }
+type mockWatchJobStatus struct{ handler watchJobStatusFunc }
+type mockInspectCommitSet struct{ handler inspectCommitSetFunc }
 type mockDeleteJob struct{ handler deleteJobFunc }
 type mockStopJob struct{ handler stopJobFunc }
+type mockEgressJob struct{ handler egressJobFunc }
 type mockUpdateJobState struct{ handler updateJobStateFunc }
 type mockInspectDatum struct{ handler inspectDatumFunc }
 type mockListDatum struct{ handler listDatumFunc }
 type mockRestartDatum struct{ handler restartDatumFunc }
+type mockDebugDatum struct{ handler debugDatumFunc }
+type mockInspectFileLineage struct{ handler inspectFileLineageFunc }
 type mockCreatePipeline struct{ handler createPipelineFunc }
 type mockInspectPipeline struct{ handler inspectPipelineFunc }
 type mockListPipeline struct{ handler listPipelineFunc }
+type mockListPipelineHistory struct{ handler listPipelineHistoryFunc }
 type mockDeletePipeline struct{ handler deletePipelineFunc }
 type mockStartPipeline struct{ handler startPipelineFunc }
 type mockStopPipeline struct{ handler stopPipelineFunc }
@@ -727,63 +739,75 @@ type mockDeleteAllPPS struct{ handler deleteAllPPSFunc }
 type mockGetLogs struct{ handler getLogsFunc }
 type mockActivateAuthPPS struct{ handler activateAuthPPSFunc }
 
-func (mock *mockCreateJob) Use(cb createJobFunc)             { mock.handler = cb }
-func (mock *mockInspectJob) Use(cb inspectJobFunc)           { mock.handler = cb }
-func (mock *mockListJob) Use(cb listJobFunc)                 { mock.handler = cb }
-func (mock *mockFlushJob) Use(cb flushJobFunc)               { mock.handler = cb }
-func (mock *mockDeleteJob) Use(cb deleteJobFunc)             { mock.handler = cb }
-func (mock *mockStopJob) Use(cb stopJobFunc)                 { mock.handler = cb }
-func (mock *mockUpdateJobState) Use(cb updateJobStateFunc)   { mock.handler = cb }
-func (mock *mockInspectDatum) Use(cb inspectDatumFunc)       { mock.handler = cb }
-func (mock *mockListDatum) Use(cb listDatumFunc)             { mock.handler = cb }
-func (mock *mockRestartDatum) Use(cb restartDatumFunc)       { mock.handler = cb }
-func (mock *mockCreatePipeline) Use(cb createPipelineFunc)   { mock.handler = cb }
-func (mock *mockInspectPipeline) Use(cb inspectPipelineFunc) { mock.handler = cb }
-func (mock *mockListPipeline) Use(cb listPipelineFunc)       { mock.handler = cb }
-func (mock *mockDeletePipeline) Use(cb deletePipelineFunc)   { mock.handler = cb }
-func (mock *mockStartPipeline) Use(cb startPipelineFunc)     { mock.handler = cb }
-func (mock *mockStopPipeline) Use(cb stopPipelineFunc)       { mock.handler = cb }
-func (mock *mockRunPipeline) Use(cb runPipelineFunc)         { mock.handler = cb }
-func (mock *mockRunCron) Use(cb runCronFunc)                 { mock.handler = cb }
-func (mock *mockCreateSecret) Use(cb createSecretFunc)       { mock.handler = cb }
-func (mock *mockDeleteSecret) Use(cb deleteSecretFunc)       { mock.handler = cb }
-func (mock *mockInspectSecret) Use(cb inspectSecretFunc)     { mock.handler = cb }
-func (mock *mockListSecret) Use(cb listSecretFunc)           { mock.handler = cb }
-func (mock *mockDeleteAllPPS) Use(cb deleteAllPPSFunc)       { mock.handler = cb }
-func (mock *mockGetLogs) Use(cb getLogsFunc)                 { mock.handler = cb }
-func (mock *mockActivateAuthPPS) Use(cb activateAuthPPSFunc) { mock.handler = cb }
+func (mock *mockCreateJob) Use(cb createJobFunc)                     { mock.handler = cb }
+func (mock *mockInspectJob) Use(cb inspectJobFunc)                   { mock.handler = cb }
+func (mock *mockListJob) Use(cb listJobFunc)                         { mock.handler = cb }
+func (mock *mockFlushJob) Use(cb flushJobFunc)                       { mock.handler = cb }
+func (mock *mockWatchJobStatus) Use(cb watchJobStatusFunc)           { mock.handler = cb }
+func (mock *mockInspectCommitSet) Use(cb inspectCommitSetFunc)       { mock.handler = cb }
+func (mock *mockDeleteJob) Use(cb deleteJobFunc)                     { mock.handler = cb }
+func (mock *mockStopJob) Use(cb stopJobFunc)                         { mock.handler = cb }
+func (mock *mockEgressJob) Use(cb egressJobFunc)                     { mock.handler = cb }
+func (mock *mockUpdateJobState) Use(cb updateJobStateFunc)           { mock.handler = cb }
+func (mock *mockInspectDatum) Use(cb inspectDatumFunc)               { mock.handler = cb }
+func (mock *mockListDatum) Use(cb listDatumFunc)                     { mock.handler = cb }
+func (mock *mockRestartDatum) Use(cb restartDatumFunc)               { mock.handler = cb }
+func (mock *mockDebugDatum) Use(cb debugDatumFunc)                   { mock.handler = cb }
+func (mock *mockInspectFileLineage) Use(cb inspectFileLineageFunc)   { mock.handler = cb }
+func (mock *mockCreatePipeline) Use(cb createPipelineFunc)           { mock.handler = cb }
+func (mock *mockInspectPipeline) Use(cb inspectPipelineFunc)         { mock.handler = cb }
+func (mock *mockListPipeline) Use(cb listPipelineFunc)               { mock.handler = cb }
+func (mock *mockListPipelineHistory) Use(cb listPipelineHistoryFunc) { mock.handler = cb }
+func (mock *mockDeletePipeline) Use(cb deletePipelineFunc)           { mock.handler = cb }
+func (mock *mockStartPipeline) Use(cb startPipelineFunc)             { mock.handler = cb }
+func (mock *mockStopPipeline) Use(cb stopPipelineFunc)               { mock.handler = cb }
+func (mock *mockRunPipeline) Use(cb runPipelineFunc)                 { mock.handler = cb }
+func (mock *mockRunCron) Use(cb runCronFunc)                         { mock.handler = cb }
+func (mock *mockCreateSecret) Use(cb createSecretFunc)               { mock.handler = cb }
+func (mock *mockDeleteSecret) Use(cb deleteSecretFunc)               { mock.handler = cb }
+func (mock *mockInspectSecret) Use(cb inspectSecretFunc)             { mock.handler = cb }
+func (mock *mockListSecret) Use(cb listSecretFunc)                   { mock.handler = cb }
+func (mock *mockDeleteAllPPS) Use(cb deleteAllPPSFunc)               { mock.handler = cb }
+func (mock *mockGetLogs) Use(cb getLogsFunc)                         { mock.handler = cb }
+func (mock *mockActivateAuthPPS) Use(cb activateAuthPPSFunc)         { mock.handler = cb }
 
 type ppsServerAPI struct {
 	mock *mockPPSServer
 }
 
 type mockPPSServer struct {
-	api             ppsServerAPI
-	CreateJob       mockCreateJob
-	InspectJob      mockInspectJob
-	ListJob         mockListJob
-	FlushJob        mockFlushJob
-	DeleteJob       mockDeleteJob
-	StopJob         mockStopJob
-	UpdateJobState  mockUpdateJobState
-	InspectDatum    mockInspectDatum
-	ListDatum       mockListDatum
-	RestartDatum    mockRestartDatum
-	CreatePipeline  mockCreatePipeline
-	InspectPipeline mockInspectPipeline
-	ListPipeline    mockListPipeline
-	DeletePipeline  mockDeletePipeline
-	StartPipeline   mockStartPipeline
-	StopPipeline    mockStopPipeline
-	RunPipeline     mockRunPipeline
-	RunCron         mockRunCron
-	CreateSecret    mockCreateSecret
-	DeleteSecret    mockDeleteSecret
-	InspectSecret   mockInspectSecret
-	ListSecret      mockListSecret
-	DeleteAll       mockDeleteAllPPS
-	GetLogs         mockGetLogs
-	ActivateAuth    mockActivateAuthPPS
+	api                 ppsServerAPI
+	CreateJob           mockCreateJob
+	InspectJob          mockInspectJob
+	ListJob             mockListJob
+	FlushJob            mockFlushJob
+	WatchJobStatus      mockWatchJobStatus
+	InspectCommitSet    mockInspectCommitSet
+	DeleteJob           mockDeleteJob
+	StopJob             mockStopJob
+	EgressJob           mockEgressJob
+	UpdateJobState      mockUpdateJobState
+	InspectDatum        mockInspectDatum
+	ListDatum           mockListDatum
+	RestartDatum        mockRestartDatum
+	DebugDatum          mockDebugDatum
+	InspectFileLineage  mockInspectFileLineage
+	CreatePipeline      mockCreatePipeline
+	InspectPipeline     mockInspectPipeline
+	ListPipeline        mockListPipeline
+	ListPipelineHistory mockListPipelineHistory
+	DeletePipeline      mockDeletePipeline
+	StartPipeline       mockStartPipeline
+	StopPipeline        mockStopPipeline
+	RunPipeline         mockRunPipeline
+	RunCron             mockRunCron
+	CreateSecret        mockCreateSecret
+	DeleteSecret        mockDeleteSecret
+	InspectSecret       mockInspectSecret
+	ListSecret          mockListSecret
+	DeleteAll           mockDeleteAllPPS
+	GetLogs             mockGetLogs
+	ActivateAuth        mockActivateAuthPPS
 }
 
 func (api *ppsServerAPI) CreateJob(ctx context.Context, req *pps.CreateJobRequest) (*pps.Job, error) {
@@ -810,6 +834,18 @@ func (api *ppsServerAPI) FlushJob(req *pps.FlushJobRequest, serv pps.API_FlushJo
 	}
 	return errors.Errorf("unhandled pachd mock pps.FlushJob")
 }
+func (api *ppsServerAPI) WatchJobStatus(req *pps.WatchJobStatusRequest, serv pps.API_WatchJobStatusServer) error {
+	if api.mock.WatchJobStatus.handler != nil {
+		return api.mock.WatchJobStatus.handler(req, serv)
+	}
+	return errors.Errorf("unhandled pachd mock pps.WatchJobStatus")
+}
+func (api *ppsServerAPI) InspectCommitSet(ctx context.Context, req *pps.InspectCommitSetRequest) (*pps.CommitSetInfo, error) {
+	if api.mock.InspectCommitSet.handler != nil {
+		return api.mock.InspectCommitSet.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pps.InspectCommitSet")
+}
 func (api *ppsServerAPI) DeleteJob(ctx context.Context, req *pps.DeleteJobRequest) (*types.Empty, error) {
 	if api.mock.DeleteJob.handler != nil {
 		return api.mock.DeleteJob.handler(ctx, req)
@@ -828,6 +864,12 @@ func (api *ppsServerAPI) StopJob(ctx context.Context, req *pps.StopJobRequest) (
 	}
 	return nil, errors.Errorf("unhandled pachd mock pps.StopJob")
 }
+func (api *ppsServerAPI) EgressJob(ctx context.Context, req *pps.EgressJobRequest) (*pps.EgressStatus, error) {
+	if api.mock.EgressJob.handler != nil {
+		return api.mock.EgressJob.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pps.EgressJob")
+}
 func (api *ppsServerAPI) InspectDatum(ctx context.Context, req *pps.InspectDatumRequest) (*pps.DatumInfo, error) {
 	if api.mock.InspectDatum.handler != nil {
 		return api.mock.InspectDatum.handler(ctx, req)
@@ -846,6 +888,18 @@ func (api *ppsServerAPI) RestartDatum(ctx context.Context, req *pps.RestartDatum
 	}
 	return nil, errors.Errorf("unhandled pachd mock pps.RestartDatum")
 }
+func (api *ppsServerAPI) DebugDatum(serv pps.API_DebugDatumServer) error {
+	if api.mock.DebugDatum.handler != nil {
+		return api.mock.DebugDatum.handler(serv)
+	}
+	return errors.Errorf("unhandled pachd mock pps.DebugDatum")
+}
+func (api *ppsServerAPI) InspectFileLineage(ctx context.Context, req *pps.InspectFileLineageRequest) (*pps.FileLineage, error) {
+	if api.mock.InspectFileLineage.handler != nil {
+		return api.mock.InspectFileLineage.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pps.InspectFileLineage")
+}
 func (api *ppsServerAPI) CreatePipeline(ctx context.Context, req *pps.CreatePipelineRequest) (*types.Empty, error) {
 	if api.mock.CreatePipeline.handler != nil {
 		return api.mock.CreatePipeline.handler(ctx, req)
@@ -864,6 +918,12 @@ func (api *ppsServerAPI) ListPipeline(ctx context.Context, req *pps.ListPipeline
 	}
 	return nil, errors.Errorf("unhandled pachd mock pps.ListPipeline")
 }
+func (api *ppsServerAPI) ListPipelineHistory(ctx context.Context, req *pps.ListPipelineHistoryRequest) (*pps.PipelineInfos, error) {
+	if api.mock.ListPipelineHistory.handler != nil {
+		return api.mock.ListPipelineHistory.handler(ctx, req)
+	}
+	return nil, errors.Errorf("unhandled pachd mock pps.ListPipelineHistory")
+}
 func (api *ppsServerAPI) DeletePipeline(ctx context.Context, req *pps.DeletePipelineRequest) (*types.Empty, error) {
 	if api.mock.DeletePipeline.handler != nil {
 		return api.mock.DeletePipeline.handler(ctx, req)
@@ -63,6 +63,8 @@ func InputName(input *Input) string {
 		return ""
 	case input.Pfs != nil:
 		return input.Pfs.Name
+	case input.Window != nil:
+		return input.Window.Name
 	case input.Cross != nil:
 		if len(input.Cross) > 0 {
 			return InputName(input.Cross[0])
@@ -118,12 +120,24 @@ func InputBranches(input *Input) []*pfs.Branch {
 				Name: "master",
 			})
 		}
+		if input.SQL != nil {
+			result = append(result, &pfs.Branch{
+				Repo: &pfs.Repo{Name: input.SQL.Repo},
+				Name: "master",
+			})
+		}
 		if input.Git != nil {
 			result = append(result, &pfs.Branch{
 				Repo: &pfs.Repo{Name: input.Git.Name},
 				Name: input.Git.Branch,
 			})
 		}
+		if input.Window != nil {
+			result = append(result, &pfs.Branch{
+				Repo: &pfs.Repo{Name: input.Window.Repo},
+				Name: input.Window.Branch,
+			})
+		}
 	})
 	return result
 }
@@ -1064,6 +1064,62 @@ func TestStopJob(t *testing.T) {
 	})
 }
 
+// TestDebugDatum checks that only users who can write to a pipeline's output
+// repo can open a debug shell on its held datums
+func TestDebugDatum(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	tu.DeleteAll(t)
+	alice, bob := robot(tu.UniqueString("alice")), robot(tu.UniqueString("bob"))
+	aliceClient, bobClient := tu.GetAuthenticatedPachClient(t, alice), tu.GetAuthenticatedPachClient(t, bob)
+
+	// alice creates a repo and a pipeline
+	repo := tu.UniqueString(t.Name())
+	require.NoError(t, aliceClient.CreateRepo(repo))
+	require.NoError(t, aliceClient.PutFile(repo, "master", "/file", strings.NewReader("test")))
+	pipeline := tu.UniqueString("alice-pipeline")
+	require.NoError(t, aliceClient.CreatePipeline(
+		pipeline,
+		"", // default image: ubuntu:16.04
+		[]string{"bash"},
+		[]string{"sleep 600"},
+		&pps.ParallelismSpec{Constant: 1},
+		client.NewPFSInput(repo, "/*"),
+		"", // default output branch: master
+		false,
+	))
+	var jobID string
+	require.NoErrorWithinTRetry(t, 30*time.Second, func() error {
+		jobs, err := aliceClient.ListJob(pipeline, nil /*inputs*/, nil /*output*/, -1 /*history*/, true /* full */)
+		if err != nil {
+			return err
+		}
+		if len(jobs) != 1 {
+			return errors.Errorf("expected one job but got %d", len(jobs))
+		}
+		jobID = jobs[0].Job.ID
+		return nil
+	})
+
+	// bob can't debug alice's pipeline's datums
+	err := bobClient.DebugDatum(jobID, "datum", strings.NewReader(""), &bytes.Buffer{})
+	require.YesError(t, err)
+	require.Matches(t, "not authorized", err.Error())
+
+	// a reader of the output repo can't either
+	require.NoError(t, aliceClient.ModifyRepoRoleBinding(pipeline, bob, []string{auth.RepoReaderRole}))
+	err = bobClient.DebugDatum(jobID, "datum", strings.NewReader(""), &bytes.Buffer{})
+	require.YesError(t, err)
+	require.Matches(t, "not authorized", err.Error())
+
+	// alice gets past the auth check, and fails because no worker is holding
+	// the datum
+	err = aliceClient.DebugDatum(jobID, "datum", strings.NewReader(""), &bytes.Buffer{})
+	require.YesError(t, err)
+	require.False(t, strings.Contains(err.Error(), "not authorized"))
+}
+
 // Test ListRepo checks that the auth information returned by ListRepo and
 // InspectRepo is correct.
 // TODO(msteffen): This should maybe go in pachyderm_test, since ListRepo isn't
@@ -1541,6 +1597,66 @@ func TestListDatum(t *testing.T) {
 	}, files)
 }
 
+// TestInspectFileLineage tests that you must have READER access to all of a
+// job's input repos and its output repo to call InspectFileLineage on a file
+// that the job wrote
+func TestInspectFileLineage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	tu.DeleteAll(t)
+	defer tu.DeleteAll(t)
+	alice, bob := robot(tu.UniqueString("alice")), robot(tu.UniqueString("bob"))
+	aliceClient, bobClient := tu.GetAuthenticatedPachClient(t, alice), tu.GetAuthenticatedPachClient(t, bob)
+
+	// alice creates a repo and a pipeline
+	repo := tu.UniqueString(t.Name())
+	require.NoError(t, aliceClient.CreateRepo(repo))
+	pipeline := tu.UniqueString("alice-pipeline")
+	require.NoError(t, aliceClient.CreatePipeline(
+		pipeline,
+		"", // default image: ubuntu:16.04
+		[]string{"bash"},
+		[]string{"cp /pfs/*/* /pfs/out/"},
+		&pps.ParallelismSpec{Constant: 1},
+		client.NewPFSInput(repo, "/*"),
+		"", // default output branch: master
+		false,
+	))
+
+	// alice commits to the input repo, and the pipeline runs successfully
+	require.NoError(t, aliceClient.PutFile(repo, "master", "/file", strings.NewReader("test")))
+	require.NoErrorWithinT(t, 45*time.Second, func() error {
+		_, err := aliceClient.FlushCommitAll(
+			[]*pfs.Commit{client.NewCommit(repo, "master")},
+			[]*pfs.Repo{client.NewRepo(pipeline)},
+		)
+		return err
+	})
+
+	// bob can't call InspectFileLineage with READER on only the input repo, or
+	// only the output repo
+	_, err := bobClient.InspectFileLineage(pipeline, "master", "/file")
+	require.YesError(t, err)
+	require.True(t, auth.IsErrNotAuthorized(err), err.Error())
+	require.NoError(t, aliceClient.ModifyRepoRoleBinding(repo, bob, []string{auth.RepoReaderRole}))
+	_, err = bobClient.InspectFileLineage(pipeline, "master", "/file")
+	require.YesError(t, err)
+	require.True(t, auth.IsErrNotAuthorized(err), err.Error())
+	require.NoError(t, aliceClient.ModifyRepoRoleBinding(repo, bob, []string{}))
+	require.NoError(t, aliceClient.ModifyRepoRoleBinding(pipeline, bob, []string{auth.RepoReaderRole}))
+	_, err = bobClient.InspectFileLineage(pipeline, "master", "/file")
+	require.YesError(t, err)
+	require.True(t, auth.IsErrNotAuthorized(err), err.Error())
+
+	// alice adds bob to both repos, and now bob can call InspectFileLineage
+	require.NoError(t, aliceClient.ModifyRepoRoleBinding(repo, bob, []string{auth.RepoReaderRole}))
+	lineage, err := bobClient.InspectFileLineage(pipeline, "master", "/file")
+	require.NoError(t, err)
+	require.Equal(t, 1, len(lineage.Datums))
+	require.Equal(t, "/file", lineage.Datums[0].Data[0].File.Path)
+}
+
 // TestListJob tests that you must have READER access to a pipeline's output
 // repo to call ListJob on that pipeline, but a blank ListJob always succeeds
 // (but doesn't return a given job if you don't have access to the job's output
@@ -776,6 +776,18 @@ This resets the cluster to its initial state.`,
 	}
 	subcommands = append(subcommands, cmdutil.CreateAlias(flushDocs, "flush"))
 
+	waitDocs := &cobra.Command{
+		Short: "Wait for a Pachyderm resource to finish.",
+		Long:  "Wait for a Pachyderm resource to finish.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(waitDocs, "wait"))
+
+	rollbackDocs := &cobra.Command{
+		Short: "Roll back a Pachyderm resource to a previous version.",
+		Long:  "Roll back a Pachyderm resource to a previous version.",
+	}
+	subcommands = append(subcommands, cmdutil.CreateAlias(rollbackDocs, "rollback"))
+
 	subscribeDocs := &cobra.Command{
 		Short: "Wait for notifications of changes to a Pachyderm resource.",
 		Long:  "Wait for notifications of changes to a Pachyderm resource.",
@@ -78,7 +78,8 @@ func do(config interface{}) error {
 	tracing.InstallJaegerTracerFromEnv()
 	env := serviceenv.InitServiceEnv(serviceenv.NewConfiguration(config))
 
-	// Construct a client that connects to the sidecar.
+	// Construct a client that connects to the sidecar (or to pachd, if the
+	// pipeline runs without one).
 	pachClient := env.GetPachClient(context.Background())
 	pipelineInfo, err := getPipelineInfo(pachClient, env) // get pipeline creds for pachClient
 	if err != nil {
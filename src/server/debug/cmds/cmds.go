@@ -104,6 +104,23 @@ func Cmds() []*cobra.Command {
 	dump.Flags().Int64VarP(&limit, "limit", "l", 0, "Limit sets the limit for the number of commits / jobs that are returned for each repo / pipeline in the dump.")
 	commands = append(commands, cmdutil.CreateAlias(dump, "debug dump"))
 
+	debugDatum := &cobra.Command{
+		Use:   "{{alias}} <job> <datum>",
+		Short: "Open a shell in the environment of a failed datum.",
+		Long: "Open an interactive shell in the environment of a failed datum, " +
+			"with its inputs mounted, in a pipeline with debug_on_failure enabled. " +
+			"The datum is marked failed when the shell exits.",
+		Run: cmdutil.RunFixedArgs(2, func(args []string) error {
+			client, err := client.NewOnUserMachine("debug-datum")
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			return client.DebugDatum(args[0], args[1], os.Stdin, os.Stdout)
+		}),
+	}
+	commands = append(commands, cmdutil.CreateAlias(debugDatum, "debug datum"))
+
 	debug := &cobra.Command{
 		Short: "Debug commands for analyzing a running cluster.",
 		Long:  "Debug commands for analyzing a running cluster.",
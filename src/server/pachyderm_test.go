@@ -1342,6 +1342,47 @@ func TestEgressFailure(t *testing.T) {
 	require.True(t, strings.Contains(jobInfo.Reason, "egress"))
 }
 
+func TestEgressJob(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	dataRepo := tu.UniqueString("TestEgressJob_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	require.NoError(t, c.PutFile(dataRepo, "master", "file", strings.NewReader("foo\n")))
+
+	// Egress to the invalid URL fails after one attempt
+	pipeline := tu.UniqueString("pipeline")
+	_, err := c.PpsAPIClient.CreatePipeline(
+		context.Background(),
+		&pps.CreatePipelineRequest{
+			Pipeline: client.NewPipeline(pipeline),
+			Transform: &pps.Transform{
+				Cmd: []string{"cp", path.Join("/pfs", dataRepo, "file"), "/pfs/out/file"},
+			},
+			Input:  client.NewPFSInput(dataRepo, "/"),
+			Egress: &pps.Egress{URL: "invalid://blahblah", MaxAttempts: 1},
+		})
+	require.NoError(t, err)
+
+	jobInfos, err := c.FlushJobAll([]*pfs.Commit{client.NewCommit(dataRepo, "master")}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jobInfos))
+	jobInfo := jobInfos[0]
+	require.NotNil(t, jobInfo.EgressStatus)
+	require.True(t, jobInfo.EgressStatus.Failed)
+
+	// The egress is retried through pachd, and fails again
+	egressStatus, err := c.EgressJob(jobInfo.Job.ID)
+	require.NoError(t, err)
+	require.Equal(t, jobInfo.EgressStatus.Attempts+1, egressStatus.Attempts)
+	require.True(t, egressStatus.Failed)
+	require.NotEqual(t, "", egressStatus.LastError)
+}
+
 func TestLazyPipelinePropagation(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
@@ -1942,6 +1983,65 @@ func TestFlushCommitFailures(t *testing.T) {
 	}
 }
 
+func TestInspectCommitSet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+	dataRepo := tu.UniqueString("TestInspectCommitSet")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	prefix := tu.UniqueString("TestInspectCommitSet")
+	pipelineName := func(i int) string { return prefix + fmt.Sprintf("%d", i) }
+
+	require.NoError(t, c.CreatePipeline(
+		pipelineName(0),
+		"",
+		[]string{"sh"},
+		[]string{fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo)},
+		&pps.ParallelismSpec{
+			Constant: 1,
+		},
+		client.NewPFSInput(dataRepo, "/*"),
+		"",
+		false,
+	))
+	require.NoError(t, c.CreatePipeline(
+		pipelineName(1),
+		"",
+		[]string{"sh"},
+		[]string{
+			fmt.Sprintf("if [ -f /pfs/%s/file1 ]; then exit 1; fi", pipelineName(0)),
+			fmt.Sprintf("cp /pfs/%s/* /pfs/out/", pipelineName(0)),
+		},
+		&pps.ParallelismSpec{
+			Constant: 1,
+		},
+		client.NewPFSInput(pipelineName(0), "/*"),
+		"",
+		false,
+	))
+
+	for i := 0; i < 2; i++ {
+		commit, err := c.StartCommit(dataRepo, "master")
+		require.NoError(t, err)
+		require.NoError(t, c.PutFile(dataRepo, commit.ID, fmt.Sprintf("file%d", i), strings.NewReader("foo\n")))
+		require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+		commitSetInfo, err := c.InspectCommitSet(dataRepo, commit.ID, true)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(commitSetInfo.Jobs))
+		for _, ji := range commitSetInfo.Jobs {
+			require.NotNil(t, ji.OutputCommit)
+		}
+		if i == 0 {
+			require.Equal(t, pps.JobState_JOB_SUCCESS.String(), commitSetInfo.State.String())
+		} else {
+			require.Equal(t, pps.JobState_JOB_FAILURE.String(), commitSetInfo.State.String())
+		}
+	}
+}
+
 func TestFlushCommitAfterCreatePipeline(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
@@ -6231,6 +6331,148 @@ func TestSkippedDatums(t *testing.T) {
 	*/
 }
 
+func TestPathFilter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+	dataRepo := tu.UniqueString("TestPathFilter_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	pipelineName := tu.UniqueString("pipeline")
+	input := client.NewPFSInput(dataRepo, "/*")
+	input.Pfs.PathFilter = "/configs/*.yaml"
+	_, err := c.PpsAPIClient.CreatePipeline(context.Background(),
+		&pps.CreatePipelineRequest{
+			Pipeline: client.NewPipeline(pipelineName),
+			Transform: &pps.Transform{
+				Cmd: []string{"bash"},
+				Stdin: []string{
+					fmt.Sprintf("cp -r /pfs/%s/* /pfs/out/", dataRepo),
+				},
+			},
+			Input: input,
+		})
+	require.NoError(t, err)
+
+	// A change matching the filter runs the job.
+	commit1, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.PutFile(dataRepo, commit1.ID, "configs/a.yaml", strings.NewReader("foo\n")))
+	require.NoError(t, c.FinishCommit(dataRepo, commit1.ID))
+	jis, err := c.FlushJobAll([]*pfs.Commit{commit1}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jis))
+	require.Equal(t, pps.JobState_JOB_SUCCESS.String(), jis[0].State.String())
+
+	// A change that doesn't match the filter skips the job.
+	commit2, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.PutFile(dataRepo, commit2.ID, "data/b.txt", strings.NewReader("bar\n")))
+	require.NoError(t, c.FinishCommit(dataRepo, commit2.ID))
+	jis, err = c.FlushJobAll([]*pfs.Commit{commit2}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jis))
+	require.Equal(t, pps.JobState_JOB_SKIPPED.String(), jis[0].State.String())
+	require.True(t, strings.Contains(jis[0].Reason, "path_filter"))
+	// The skipped job's output is the same as its parent's.
+	var buf bytes.Buffer
+	require.NoError(t, c.GetFile(pipelineName, jis[0].OutputCommit.ID, "configs/a.yaml", &buf))
+	require.Equal(t, "foo\n", buf.String())
+
+	// Invalid filters are rejected.
+	input = client.NewPFSInput(dataRepo, "/*")
+	input.Pfs.PathFilter = "/[a"
+	_, err = c.PpsAPIClient.CreatePipeline(context.Background(),
+		&pps.CreatePipelineRequest{
+			Pipeline:  client.NewPipeline(tu.UniqueString("pipeline")),
+			Transform: &pps.Transform{Cmd: []string{"true"}},
+			Input:     input,
+		})
+	require.YesError(t, err)
+}
+
+func TestStatefulPipeline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+	dataRepo := tu.UniqueString("TestStatefulPipeline_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	pipelineName := tu.UniqueString("pipeline")
+	// Each datum records the state it saw, then adds its own file to the state
+	_, err := c.PpsAPIClient.CreatePipeline(context.Background(),
+		&pps.CreatePipelineRequest{
+			Pipeline: client.NewPipeline(pipelineName),
+			Transform: &pps.Transform{
+				Cmd: []string{"bash"},
+				Stdin: []string{
+					fmt.Sprintf("for f in /pfs/%s/*; do", dataRepo),
+					"  name=$(basename $f)",
+					"  ls /pfs/state > /pfs/out/$name",
+					"  touch /pfs/state/$name",
+					"  if [ $name = fail ]; then exit 1; fi",
+					"done",
+				},
+			},
+			Input: client.NewPFSInput(dataRepo, "/*"),
+			State: &pps.StateSpec{Enabled: true},
+		})
+	require.NoError(t, err)
+
+	putFile := func(file string) *pps.JobInfo {
+		commit, err := c.StartCommit(dataRepo, "master")
+		require.NoError(t, err)
+		require.NoError(t, c.PutFile(dataRepo, commit.ID, file, strings.NewReader("")))
+		require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+		jis, err := c.FlushJobAll([]*pfs.Commit{commit}, nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(jis))
+		return jis[0]
+	}
+	getOutput := func(ji *pps.JobInfo, file string) string {
+		var buf bytes.Buffer
+		require.NoError(t, c.GetFile(pipelineName, ji.OutputCommit.ID, file, &buf))
+		return buf.String()
+	}
+
+	ji := putFile("a")
+	require.Equal(t, pps.JobState_JOB_SUCCESS.String(), ji.State.String())
+	require.Equal(t, "", getOutput(ji, "a"))
+	ji = putFile("b")
+	require.Equal(t, pps.JobState_JOB_SUCCESS.String(), ji.State.String())
+	require.Equal(t, "a\n", getOutput(ji, "b"))
+
+	// The failed job's changes to the state are discarded
+	ji = putFile("fail")
+	require.Equal(t, pps.JobState_JOB_FAILURE.String(), ji.State.String())
+	commit, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.DeleteFile(dataRepo, commit.ID, "fail"))
+	require.NoError(t, c.PutFile(dataRepo, commit.ID, "c", strings.NewReader("")))
+	require.NoError(t, c.FinishCommit(dataRepo, commit.ID))
+	jis, err := c.FlushJobAll([]*pfs.Commit{commit}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jis))
+	require.Equal(t, pps.JobState_JOB_SUCCESS.String(), jis[0].State.String())
+	require.Equal(t, "a\nb\n", getOutput(jis[0], "c"))
+
+	// Inputs can't shadow /pfs/state
+	input := client.NewPFSInput(dataRepo, "/*")
+	input.Pfs.Name = "state"
+	_, err = c.PpsAPIClient.CreatePipeline(context.Background(),
+		&pps.CreatePipelineRequest{
+			Pipeline:  client.NewPipeline(tu.UniqueString("pipeline")),
+			Transform: &pps.Transform{Cmd: []string{"true"}},
+			Input:     input,
+			State:     &pps.StateSpec{Enabled: true},
+		})
+	require.YesError(t, err)
+}
+
 func TestCronPipeline(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
@@ -8106,6 +8348,110 @@ func TestPipelineWithDatumTimeout(t *testing.T) {
 	require.Equal(t, timeout, seconds)
 }
 
+func TestInspectFileLineage(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	dataRepo := tu.UniqueString("TestInspectFileLineage_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+	commit1, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.PutFile(dataRepo, commit1.ID, "a", strings.NewReader("foo")))
+	require.NoError(t, c.PutFile(dataRepo, commit1.ID, "b", strings.NewReader("bar")))
+	require.NoError(t, c.FinishCommit(dataRepo, commit1.ID))
+	pipeline := tu.UniqueString("TestInspectFileLineage_pipeline")
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"bash"},
+		[]string{
+			fmt.Sprintf("for f in /pfs/%s/*; do cp $f /pfs/out/model_$(basename $f); done", dataRepo),
+		},
+		&pps.ParallelismSpec{
+			Constant: 1,
+		},
+		client.NewPFSInput(dataRepo, "/*"),
+		"",
+		false,
+	))
+	jobInfos, err := c.FlushJobAll([]*pfs.Commit{commit1}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jobInfos))
+
+	lineage, err := c.InspectFileLineage(pipeline, "master", "model_b")
+	require.NoError(t, err)
+	require.Equal(t, jobInfos[0].Job.ID, lineage.Job.ID)
+	require.Equal(t, 1, len(lineage.Datums))
+	require.Equal(t, 1, len(lineage.Datums[0].Data))
+	require.Equal(t, "/b", lineage.Datums[0].Data[0].File.Path)
+	require.Equal(t, commit1.ID, lineage.Datums[0].Data[0].File.Commit.ID)
+
+	// Files that weren't written by any datum have no lineage
+	_, err = c.InspectFileLineage(pipeline, "master", "model_c")
+	require.YesError(t, err)
+}
+
+func TestListDatumInput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	repoA := tu.UniqueString("TestListDatumInput_A")
+	repoB := tu.UniqueString("TestListDatumInput_B")
+	require.NoError(t, c.CreateRepo(repoA))
+	require.NoError(t, c.CreateRepo(repoB))
+	require.NoError(t, c.PutFile(repoA, "master", "a-1", strings.NewReader("foo")))
+	require.NoError(t, c.PutFile(repoA, "master", "a-2", strings.NewReader("foo")))
+	require.NoError(t, c.PutFile(repoB, "master", "b-1", strings.NewReader("bar")))
+	require.NoError(t, c.PutFile(repoB, "master", "b-3", strings.NewReader("bar")))
+
+	// No pipeline is created, the datums are computed from the input alone
+	dis, err := c.ListDatumInputAll("", client.NewJoinInput(
+		client.NewPFSInputOpts("", repoA, "", "/a-(*)", "$1", "", false, false, nil),
+		client.NewPFSInputOpts("", repoB, "", "/b-(*)", "$1", "", false, false, nil),
+	))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(dis))
+	require.Equal(t, []string{"1"}, dis[0].JoinOn)
+	require.Equal(t, 2, len(dis[0].Data))
+	require.Equal(t, pps.DatumState_STARTING, dis[0].State)
+
+	dis, err = c.ListDatumInputAll("", client.NewPFSInput(repoA, "/*"))
+	require.NoError(t, err)
+	require.Equal(t, 2, len(dis))
+
+	pipelineInfos, err := c.ListPipeline()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(pipelineInfos))
+
+	// Cron inputs read from a repo that's created with their pipeline, so
+	// they can't be listed before it exists
+	pipeline := tu.UniqueString("TestListDatumInput_cron")
+	cronInput := client.NewCronInput("tick", "@every 1h")
+	_, err = c.ListDatumInputAll(pipeline, cronInput)
+	require.YesError(t, err)
+	require.Matches(t, "doesn't exist yet", err.Error())
+	require.NoError(t, c.CreatePipeline(
+		pipeline,
+		"",
+		[]string{"true"},
+		nil,
+		nil,
+		client.NewCronInput("tick", "@every 1h"),
+		"",
+		false,
+	))
+	_, err = c.ListDatumInputAll(pipeline, cronInput)
+	require.NoError(t, err)
+}
+
 func TestListDatumDuringJob(t *testing.T) {
 	// TODO: Implement list open commits.
 	t.Skip("List open commits not implemented in V2")
@@ -8271,6 +8617,62 @@ func TestPipelineWithJobTimeout(t *testing.T) {
 	require.True(t, math.Abs((finished.Sub(started)-(time.Second*20)).Seconds()) <= 1.0)
 }
 
+func TestPipelineWithTimeoutPolicyPartial(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	c := tu.GetPachClient(t)
+	require.NoError(t, c.DeleteAll())
+
+	dataRepo := tu.UniqueString("TestPipelineWithTimeoutPolicyPartial_data")
+	require.NoError(t, c.CreateRepo(dataRepo))
+
+	commit1, err := c.StartCommit(dataRepo, "master")
+	require.NoError(t, err)
+	require.NoError(t, c.PutFile(dataRepo, commit1.ID, "fast", strings.NewReader("foo")))
+	require.NoError(t, c.PutFile(dataRepo, commit1.ID, "slow", strings.NewReader("bar")))
+	require.NoError(t, c.FinishCommit(dataRepo, commit1.ID))
+	pipeline := tu.UniqueString("pipeline")
+	_, err = c.PpsAPIClient.CreatePipeline(
+		context.Background(),
+		&pps.CreatePipelineRequest{
+			Pipeline: client.NewPipeline(pipeline),
+			Transform: &pps.Transform{
+				Cmd: []string{"bash"},
+				Stdin: []string{
+					fmt.Sprintf("if [ -f /pfs/%s/slow ]; then sleep 600; fi", dataRepo),
+					fmt.Sprintf("cp /pfs/%s/* /pfs/out/", dataRepo),
+				},
+			},
+			Input:         client.NewPFSInput(dataRepo, "/*"),
+			DatumTimeout:  types.DurationProto(10 * time.Second),
+			TimeoutPolicy: pps.TimeoutPolicy_TIMEOUT_PARTIAL,
+		},
+	)
+	require.NoError(t, err)
+
+	commitInfos, err := c.FlushCommitAll([]*pfs.Commit{commit1}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(commitInfos))
+
+	jobs, err := c.ListJob(pipeline, nil, nil, -1, true)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(jobs))
+	jobInfo, err := c.InspectJob(jobs[0].Job.ID, true)
+	require.NoError(t, err)
+	// The job succeeds with the output of the datum that didn't time out
+	require.Equal(t, pps.JobState_JOB_SUCCESS, jobInfo.State)
+	require.Equal(t, int64(1), jobInfo.DataProcessed)
+	require.Equal(t, int64(1), jobInfo.DataFailed)
+	require.Equal(t, "1 datums timed out", jobInfo.Reason)
+	var buf bytes.Buffer
+	require.NoError(t, c.GetFile(pipeline, "master", "fast", &buf))
+	require.Equal(t, "foo", buf.String())
+	_, err = c.InspectFile(pipeline, "master", "slow")
+	require.YesError(t, err)
+}
+
 func TestCommitDescription(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration tests in short mode")
@@ -9662,6 +10064,29 @@ func TestPipelineHistory(t *testing.T) {
 	pipelineInfos, err = c.ListPipelineHistory(pipelineName2, -1)
 	require.NoError(t, err)
 	require.Equal(t, 1, len(pipelineInfos))
+
+	// ListPipelineHistory returns every version of one pipeline, newest first
+	history, err := c.PpsAPIClient.ListPipelineHistory(c.Ctx(), &pps.ListPipelineHistoryRequest{
+		Pipeline: client.NewPipeline(pipelineName),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(history.PipelineInfo))
+	for i, pipelineInfo := range history.PipelineInfo {
+		require.Equal(t, uint64(3-i), pipelineInfo.Version)
+		require.NotNil(t, pipelineInfo.Transform)
+	}
+	history, err = c.PpsAPIClient.ListPipelineHistory(c.Ctx(), &pps.ListPipelineHistoryRequest{
+		Pipeline: client.NewPipeline(pipelineName),
+		Version:  2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(history.PipelineInfo))
+	require.Equal(t, uint64(2), history.PipelineInfo[0].Version)
+	_, err = c.PpsAPIClient.ListPipelineHistory(c.Ctx(), &pps.ListPipelineHistoryRequest{
+		Pipeline: client.NewPipeline(pipelineName),
+		Version:  4,
+	})
+	require.YesError(t, err)
 }
 
 // TODO: Make work with V2?
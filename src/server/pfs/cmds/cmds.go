@@ -29,6 +29,7 @@ import (
 	pfsclient "github.com/pachyderm/pachyderm/v2/src/pfs"
 	"github.com/pachyderm/pachyderm/v2/src/server/cmd/pachctl/shell"
 	"github.com/pachyderm/pachyderm/v2/src/server/pfs/pretty"
+	ppspretty "github.com/pachyderm/pachyderm/v2/src/server/pps/pretty"
 	txncmds "github.com/pachyderm/pachyderm/v2/src/server/transaction/cmds"
 
 	"github.com/spf13/cobra"
@@ -982,10 +983,18 @@ $ {{alias}} foo@master^2:XXX`,
 	shell.RegisterCompletionFunc(getFile, shell.FileCompletion)
 	commands = append(commands, cmdutil.CreateAlias(getFile, "get file"))
 
+	var lineage bool
 	inspectFile := &cobra.Command{
 		Use:   "{{alias}} <repo>@<branch-or-commit>:<path/in/pfs>",
 		Short: "Return info about a file.",
 		Long:  "Return info about a file.",
+		Example: `
+# return info about file "foo" on branch "master" in repo "bar"
+$ {{alias}} bar@master:foo
+
+# return the job, datum and input files that produced file "foo" in the
+# output repo of pipeline "bar"
+$ {{alias}} bar@master:foo --lineage`,
 		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
 			file, err := cmdutil.ParseFile(args[0])
 			if err != nil {
@@ -996,6 +1005,17 @@ $ {{alias}} foo@master^2:XXX`,
 				return err
 			}
 			defer c.Close()
+			if lineage {
+				fileLineage, err := c.InspectFileLineage(file.Commit.Repo.Name, file.Commit.ID, file.Path)
+				if err != nil {
+					return err
+				}
+				if raw {
+					return marshaller.Marshal(os.Stdout, fileLineage)
+				}
+				ppspretty.PrintDetailedFileLineage(os.Stdout, fileLineage)
+				return nil
+			}
 			fileInfo, err := c.InspectFile(file.Commit.Repo.Name, file.Commit.ID, file.Path)
 			if err != nil {
 				return err
@@ -1010,6 +1030,7 @@ $ {{alias}} foo@master^2:XXX`,
 		}),
 	}
 	inspectFile.Flags().AddFlagSet(rawFlags)
+	inspectFile.Flags().BoolVar(&lineage, "lineage", false, "Return the job, datums and input files that produced the file, rather than info about the file itself.")
 	shell.RegisterCompletionFunc(inspectFile, shell.FileCompletion)
 	commands = append(commands, cmdutil.CreateAlias(inspectFile, "inspect file"))
 
@@ -9,7 +9,9 @@ import (
 	"io/ioutil"
 	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -17,8 +19,10 @@ import (
 	pachdclient "github.com/pachyderm/pachyderm/v2/src/client"
 	"github.com/pachyderm/pachyderm/v2/src/internal/cmdutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/grpcutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/pager"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfssync"
 	"github.com/pachyderm/pachyderm/v2/src/internal/ppsutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/progress"
 	"github.com/pachyderm/pachyderm/v2/src/internal/serde"
@@ -30,6 +34,7 @@ import (
 	"github.com/pachyderm/pachyderm/v2/src/server/cmd/pachctl/shell"
 	"github.com/pachyderm/pachyderm/v2/src/server/pps/pretty"
 	txncmds "github.com/pachyderm/pachyderm/v2/src/server/transaction/cmds"
+	"github.com/pachyderm/pachyderm/v2/src/server/worker/common"
 	"github.com/pachyderm/pachyderm/v2/src/version"
 
 	prompt "github.com/c-bata/go-prompt"
@@ -106,6 +111,7 @@ If the job fails, the output commit will not be populated with data.`,
 	commands = append(commands, cmdutil.CreateDocsAlias(jobDocs, "job", " job$"))
 
 	var block bool
+	var watch bool
 	inspectJob := &cobra.Command{
 		Use:   "{{alias}} <job>",
 		Short: "Return info about a job.",
@@ -124,6 +130,9 @@ If the job fails, the output commit will not be populated with data.`,
 				cmdutil.ErrorAndExit("job %s not found.", args[0])
 			}
 			if raw {
+				if watch {
+					cmdutil.ErrorAndExit("cannot set --watch with --raw")
+				}
 				return encoder(output).EncodeProto(jobInfo)
 			} else if output != "" {
 				cmdutil.ErrorAndExit("cannot set --output (-o) without --raw")
@@ -132,10 +141,17 @@ If the job fails, the output commit will not be populated with data.`,
 				JobInfo:        jobInfo,
 				FullTimestamps: fullTimestamps,
 			}
-			return pretty.PrintDetailedJobInfo(os.Stdout, ji)
+			if err := pretty.PrintDetailedJobInfo(os.Stdout, ji); err != nil {
+				return err
+			}
+			if watch && !ppsutil.IsTerminal(jobInfo.State) {
+				return watchJob(client, jobInfo.Job.ID)
+			}
+			return nil
 		}),
 	}
 	inspectJob.Flags().BoolVarP(&block, "block", "b", false, "block until the job has either succeeded or failed")
+	inspectJob.Flags().BoolVarP(&watch, "watch", "w", false, "show the live progress of each worker until the job finishes")
 	inspectJob.Flags().AddFlagSet(outputFlags)
 	inspectJob.Flags().AddFlagSet(fullTimestampsFlags)
 	shell.RegisterCompletionFunc(inspectJob, shell.JobCompletion)
@@ -146,6 +162,8 @@ If the job fails, the output commit will not be populated with data.`,
 	var inputCommitStrs []string
 	var history string
 	var stateStrs []string
+	var cost bool
+	var costSince, costUntil string
 	listJob := &cobra.Command{
 		Short: "Return info about jobs.",
 		Long:  "Return info about jobs.",
@@ -163,7 +181,10 @@ $ {{alias}} -p foo --history all
 $ {{alias}} -i foo@XXX -i bar@YYY
 
 # Return all jobs in pipeline foo and whose input commits include bar@YYY
-$ {{alias}} -p foo -i bar@YYY`,
+$ {{alias}} -p foo -i bar@YYY
+
+# Return the resources used by each pipeline's jobs over the last week
+$ {{alias}} --cost --since 168h`,
 		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
 			commits, err := cmdutil.ParseCommits(inputCommitStrs)
 			if err != nil {
@@ -194,6 +215,14 @@ $ {{alias}} -p foo -i bar@YYY`,
 			}
 			defer client.Close()
 
+			if cost {
+				if raw {
+					cmdutil.ErrorAndExit("cannot set --raw with --cost")
+				}
+				return listJobCost(client, pipelineName, commits, outputCommit, history, filter, costSince, costUntil)
+			} else if costSince != "" || costUntil != "" {
+				cmdutil.ErrorAndExit("--since and --until can only be set with --cost")
+			}
 			return pager.Page(noPager, os.Stdout, func(w io.Writer) error {
 				if raw {
 					e := encoder(output)
@@ -225,6 +254,9 @@ $ {{alias}} -p foo -i bar@YYY`,
 	listJob.Flags().AddFlagSet(noPagerFlags)
 	listJob.Flags().StringVar(&history, "history", "none", "Return jobs from historical versions of pipelines.")
 	listJob.Flags().StringArrayVar(&stateStrs, "state", []string{}, "Return only jobs with the specified state. Can be repeated to include multiple states")
+	listJob.Flags().BoolVar(&cost, "cost", false, "Return the total resources used by jobs (wall time, CPU time, memory high-water mark and data transferred), grouped by pipeline.")
+	listJob.Flags().StringVar(&costSince, "since", "", "With --cost, only include jobs that started less than this long ago (e.g. 24h).")
+	listJob.Flags().StringVar(&costUntil, "until", "", "With --cost, only include jobs that started more than this long ago (e.g. 1h).")
 	shell.RegisterCompletionFunc(listJob,
 		func(flag, text string, maxCompletions int64) ([]prompt.Suggest, shell.CacheFunc) {
 			if flag == "-p" || flag == "--pipeline" {
@@ -298,6 +330,54 @@ $ {{alias}} foo@XXX -p bar -p baz`,
 		})
 	commands = append(commands, cmdutil.CreateAlias(flushJob, "flush job"))
 
+	waitCommitSet := &cobra.Command{
+		Use:   "{{alias}} <repo>@<branch-or-commit>",
+		Short: "Wait for all jobs descended from a commit to finish.",
+		Long:  "Wait for all jobs descended from a commit (transitively, across the whole DAG) to finish and return them. Exits with a non-zero status if any of the jobs failed or was killed.",
+		Example: `
+# Wait for everything triggered by foo@XXX, failing if any job failed.
+$ {{alias}} foo@XXX`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			if output != "" && !raw {
+				cmdutil.ErrorAndExit("cannot set --output (-o) without --raw")
+			}
+			commit, err := cmdutil.ParseCommit(args[0])
+			if err != nil {
+				return err
+			}
+			c, err := pachdclient.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer c.Close()
+			commitSetInfo, err := c.InspectCommitSet(commit.Repo.Name, commit.ID, true)
+			if err != nil {
+				return err
+			}
+			if raw {
+				if err := encoder(output).EncodeProto(commitSetInfo); err != nil {
+					return err
+				}
+			} else {
+				writer := tabwriter.NewWriter(os.Stdout, pretty.JobHeader)
+				for _, ji := range commitSetInfo.Jobs {
+					pretty.PrintJobInfo(writer, ji, fullTimestamps)
+				}
+				if err := writer.Flush(); err != nil {
+					return err
+				}
+			}
+			if commitSetInfo.State == ppsclient.JobState_JOB_FAILURE {
+				return errors.Errorf("a job descended from %s@%s failed", commitSetInfo.Commit.Repo.Name, commitSetInfo.Commit.ID)
+			}
+			return nil
+		}),
+	}
+	waitCommitSet.Flags().AddFlagSet(outputFlags)
+	waitCommitSet.Flags().AddFlagSet(fullTimestampsFlags)
+	shell.RegisterCompletionFunc(waitCommitSet, shell.BranchCompletion)
+	commands = append(commands, cmdutil.CreateAlias(waitCommitSet, "wait commitset"))
+
 	deleteJob := &cobra.Command{
 		Use:   "{{alias}} <job>",
 		Short: "Delete a job.",
@@ -336,6 +416,29 @@ $ {{alias}} foo@XXX -p bar -p baz`,
 	shell.RegisterCompletionFunc(stopJob, shell.JobCompletion)
 	commands = append(commands, cmdutil.CreateAlias(stopJob, "stop job"))
 
+	egressJob := &cobra.Command{
+		Use:   "{{alias}} <job>",
+		Short: "Retry the egress of a job.",
+		Long:  "Retry the egress of a job whose egress failed after the pipeline's egress.max_attempts.",
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			client, err := pachdclient.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			egressStatus, err := client.EgressJob(args[0])
+			if err != nil {
+				cmdutil.ErrorAndExit("error from EgressJob: %s", err.Error())
+			}
+			if egressStatus.LastError != "" {
+				cmdutil.ErrorAndExit("egress failed: %s", egressStatus.LastError)
+			}
+			return nil
+		}),
+	}
+	shell.RegisterCompletionFunc(egressJob, shell.JobCompletion)
+	commands = append(commands, cmdutil.CreateAlias(egressJob, "egress job"))
+
 	datumDocs := &cobra.Command{
 		Short: "Docs for datums.",
 		Long: `Datums are the small independent units of processing for Pachyderm jobs.
@@ -375,16 +478,46 @@ each datum.`,
 	}
 	commands = append(commands, cmdutil.CreateAlias(restartDatum, "restart datum"))
 
+	var datumSpecPath string
 	listDatum := &cobra.Command{
 		Use:   "{{alias}} <job>",
 		Short: "Return the datums in a job.",
-		Long:  "Return the datums in a job.",
+		Long: `Return the datums in a job.
+
+With --from-spec, return the datums that the input of a pipeline spec would create,
+without creating the pipeline, along with their total size and a warning if
+one datum is much larger than the rest. This is useful for trying out glob
+patterns, join_on and group_by.`,
+		Example: `
+# List the datums in a job
+$ {{alias}} 7f3cd988429894000bdad549dfe2d09b
+
+# List the datums that the pipeline in pipeline.json would create
+$ {{alias}} --from-spec pipeline.json`,
 		Run: cmdutil.RunBoundedArgs(0, 1, func(args []string) (retErr error) {
 			client, err := pachdclient.NewOnUserMachine("user")
 			if err != nil {
 				return err
 			}
 			defer client.Close()
+			if datumSpecPath != "" {
+				if len(args) != 0 {
+					return errors.Errorf("cannot specify a job with --from-spec")
+				}
+				pipeline, input, err := readSpecInput(datumSpecPath)
+				if err != nil {
+					return err
+				}
+				if raw {
+					e := encoder(output)
+					return client.ListDatumInput(pipeline, input, func(di *ppsclient.DatumInfo) error {
+						return e.EncodeProto(di)
+					})
+				} else if output != "" {
+					cmdutil.ErrorAndExit("cannot set --output (-o) without --raw")
+				}
+				return listDatumPlan(client, pipeline, input)
+			}
 			var printF func(*ppsclient.DatumInfo) error
 			if !raw {
 				if output != "" {
@@ -413,6 +546,7 @@ each datum.`,
 		}),
 	}
 	listDatum.Flags().AddFlagSet(outputFlags)
+	listDatum.Flags().StringVar(&datumSpecPath, "from-spec", "", "List the datums that the input of this pipeline spec would create.")
 	shell.RegisterCompletionFunc(listDatum, shell.JobCompletion)
 	commands = append(commands, cmdutil.CreateAlias(listDatum, "list datum"))
 
@@ -478,6 +612,8 @@ each datum.`,
 
 	}
 
+	var from, to, messageRegex string
+	var levels, workerPods []string
 	getLogs := &cobra.Command{
 		Use:   "{{alias}} [--pipeline=<pipeline>|--job=<job>] [--datum=<datum>]",
 		Short: "Return logs from a job.",
@@ -490,7 +626,10 @@ each datum.`,
 	$ {{alias}} --job=aedfa12aedf
 	
 	# Return logs emitted by the pipeline \"filter\" while processing /apple.txt and a file with the hash 123aef
-	$ {{alias}} --pipeline=filter --inputs=/apple.txt,123aef`,
+	$ {{alias}} --pipeline=filter --inputs=/apple.txt,123aef
+
+	# Return errors logged by the pipeline "filter" in a time range that mention "timeout"
+	$ {{alias}} --pipeline=filter --level=error --grep=timeout --from=2021-03-01T10:00:00Z --to=2021-03-01T11:00:00Z`,
 		Run: cmdutil.RunFixedArgs(0, func(args []string) error {
 			client, err := pachdclient.NewOnUserMachine("user")
 			if err != nil {
@@ -518,8 +657,42 @@ each datum.`,
 				return errors.Errorf("tail has been deprecated and removed from Pachyderm, use --since instead")
 			}
 
+			request := &ppsclient.GetLogsRequest{
+				DataFilters:  data,
+				Master:       master,
+				Follow:       follow,
+				Since:        types.DurationProto(since),
+				MessageRegex: messageRegex,
+				Workers:      workerPods,
+			}
+			if pipelineName != "" {
+				request.Pipeline = pachdclient.NewPipeline(pipelineName)
+			}
+			if jobID != "" {
+				request.Job = pachdclient.NewJob(jobID)
+			}
+			if datumID != "" {
+				request.Datum = &ppsclient.Datum{
+					Job: pachdclient.NewJob(jobID),
+					ID:  datumID,
+				}
+			}
+			if request.From, err = parseLogTime(from); err != nil {
+				return errors.Wrapf(err, "error parsing from(%q)", from)
+			}
+			if request.To, err = parseLogTime(to); err != nil {
+				return errors.Wrapf(err, "error parsing to(%q)", to)
+			}
+			for _, level := range levels {
+				value, ok := ppsclient.LogLevel_value["LOG_"+strings.ToUpper(level)]
+				if !ok {
+					return errors.Errorf("unrecognized log level %q, must be one of info or error", level)
+				}
+				request.Levels = append(request.Levels, ppsclient.LogLevel(value))
+			}
+
 			// Issue RPC
-			iter := client.GetLogs(pipelineName, jobID, data, datumID, master, follow, since)
+			iter := client.QueryLogs(request)
 			var buf bytes.Buffer
 			encoder := json.NewEncoder(&buf)
 			for iter.Next() {
@@ -557,6 +730,11 @@ each datum.`,
 	getLogs.Flags().BoolVarP(&follow, "follow", "f", false, "Follow logs as more are created.")
 	getLogs.Flags().Int64VarP(&tail, "tail", "t", 0, "Lines of recent logs to display.")
 	getLogs.Flags().StringVar(&since, "since", "24h", "Return log messages more recent than \"since\".")
+	getLogs.Flags().StringVar(&from, "from", "", "Return log messages logged at or after this time (RFC 3339). Overrides --since.")
+	getLogs.Flags().StringVar(&to, "to", "", "Return log messages logged at or before this time (RFC 3339).")
+	getLogs.Flags().StringArrayVar(&levels, "level", []string{}, "Return only log messages with this level (info or error). Can be repeated to include multiple levels.")
+	getLogs.Flags().StringVar(&messageRegex, "grep", "", "Return only log messages that match this regular expression.")
+	getLogs.Flags().StringArrayVar(&workerPods, "worker-pod", []string{}, "Return only log messages from this worker pod. Can be repeated to include multiple pods.")
 	shell.RegisterCompletionFunc(getLogs,
 		func(flag, text string, maxCompletions int64) ([]prompt.Suggest, shell.CacheFunc) {
 			if flag == "--pipeline" || flag == "-p" {
@@ -618,6 +796,62 @@ All jobs created by a pipeline will create commits in the pipeline's output repo
 	updatePipeline.Flags().BoolVar(&reprocess, "reprocess", false, "If true, reprocess datums that were already processed by previous version of the pipeline.")
 	commands = append(commands, cmdutil.CreateAlias(updatePipeline, "update pipeline"))
 
+	var toVersion uint64
+	rollbackPipeline := &cobra.Command{
+		Use:   "{{alias}} <pipeline>",
+		Short: "Roll back a pipeline to a previous version of its spec.",
+		Long:  "Roll back a pipeline to a previous version of its spec. The old spec is read from the pipeline's history and re-applied as an update, so the rollback itself creates a new version of the pipeline.",
+		Example: `
+# Roll back pipeline "foo" to version 2
+$ {{alias}} foo --to-version 2
+
+# Roll back pipeline "foo" to version 2, and reprocess all of its datums
+$ {{alias}} foo --to-version 2 --reprocess`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			if toVersion == 0 {
+				return errors.Errorf("must specify --to-version")
+			}
+			client, err := pachdclient.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			current, err := client.InspectPipeline(args[0])
+			if err != nil {
+				return err
+			}
+			if current.Version == toVersion {
+				return errors.Errorf("pipeline %s is already at version %d", args[0], toVersion)
+			}
+			// Only the complete spec of the target version is returned, so
+			// the rollback can't silently drop fields
+			history, err := client.PpsAPIClient.ListPipelineHistory(
+				client.Ctx(),
+				&ppsclient.ListPipelineHistoryRequest{
+					Pipeline: pachdclient.NewPipeline(args[0]),
+					Version:  toVersion,
+				},
+			)
+			if err != nil {
+				return grpcutil.ScrubGRPC(err)
+			}
+			request := ppsutil.PipelineReqFromInfo(history.PipelineInfo[0])
+			request.Update = true
+			request.Reprocess = reprocess
+			return txncmds.WithActiveTransaction(client, func(txClient *pachdclient.APIClient) error {
+				_, err := txClient.PpsAPIClient.CreatePipeline(
+					txClient.Ctx(),
+					request,
+				)
+				return grpcutil.ScrubGRPC(err)
+			})
+		}),
+	}
+	rollbackPipeline.Flags().Uint64Var(&toVersion, "to-version", 0, "The version of the pipeline's spec to roll back to.")
+	rollbackPipeline.Flags().BoolVar(&reprocess, "reprocess", false, "If true, reprocess datums that were already processed by previous versions of the pipeline.")
+	shell.RegisterCompletionFunc(rollbackPipeline, shell.PipelineCompletion)
+	commands = append(commands, cmdutil.CreateAlias(rollbackPipeline, "rollback pipeline"))
+
 	runPipeline := &cobra.Command{
 		Use:   "{{alias}} <pipeline> [<repo>@[<branch>|<commit>|<branch>=<commit>]...]",
 		Short: "Run an existing Pachyderm pipeline on the specified commits-branch pairs.",
@@ -661,7 +895,7 @@ All jobs created by a pipeline will create commits in the pipeline's output repo
 	runCron := &cobra.Command{
 		Use:   "{{alias}} <pipeline>",
 		Short: "Run an existing Pachyderm cron pipeline now",
-		Long:  "Run an existing Pachyderm cron pipeline now",
+		Long:  "Run an existing Pachyderm cron pipeline now. This also runs the queries of any sql inputs the pipeline has.",
 		Example: `
 		# Run a cron pipeline "clock" now
 		$ {{alias}} clock`,
@@ -680,6 +914,97 @@ All jobs created by a pipeline will create commits in the pipeline's output repo
 	}
 	commands = append(commands, cmdutil.CreateAlias(runCron, "run cron"))
 
+	var localDatumIDs []string
+	var localDir string
+	var uploadBranch string
+	runLocal := &cobra.Command{
+		Use:   "{{alias}} <pipeline-spec>",
+		Short: "Run a pipeline's transform on this machine.",
+		Long: `Run a pipeline's transform on this machine, on datums from the pipeline's inputs. Each datum's inputs are downloaded to <dir>/<datum-id>/<input>, and the transform's cmd is run in its working_dir, with its env and the same input environment variables that it gets in a worker, with its output written to <dir>/<datum-id>/out. Input branches are read at their head commits, lazy inputs are downloaded in full, and secrets are not injected. There's no job, so PACH_JOB_ID is a random ID, and PACH_OUTPUT_COMMIT_ID is the ID of the commit that --upload writes to, or a random ID without --upload.
+
+Because the datum's files aren't under /pfs, the transform should find its inputs with the input environment variables rather than hardcoded paths.`,
+		Example: `
+		# Run the transform of the pipeline in "edges.json" on its first datum
+		$ {{alias}} edges.json
+
+		# Run it on two specific datums (see "pachctl list datum --from-spec edges.json")
+		$ {{alias}} edges.json --datum <datum-id> --datum <datum-id>
+
+		# Run it on the datums and upload their output to the "scratch" branch
+		# of the pipeline's output repo, to compare with the output on master
+		$ {{alias}} edges.json --datum <datum-id> --upload scratch`,
+		Run: cmdutil.RunFixedArgs(1, func(args []string) error {
+			pipelineReader, err := ppsutil.NewPipelineManifestReader(args[0])
+			if err != nil {
+				return err
+			}
+			request, err := pipelineReader.NextCreatePipelineRequest()
+			if err != nil {
+				return err
+			}
+			if request.Input == nil {
+				return errors.Errorf("pipeline spec has no input")
+			}
+			if request.Transform == nil || len(request.Transform.Cmd) == 0 {
+				return errors.Errorf("pipeline spec has no transform cmd")
+			}
+			if uploadBranch != "" {
+				if err := validateUploadBranch(request, uploadBranch); err != nil {
+					return err
+				}
+			}
+			client, err := pachdclient.NewOnUserMachine("user")
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+			dis, err := localDatums(client, request.Pipeline.Name, request.Input, localDatumIDs)
+			if err != nil {
+				return err
+			}
+			// There's no job, so the transform gets a random job ID, and the
+			// ID of the commit that its output is uploaded to (or a random ID)
+			jobID, outputCommitID := uuid.NewWithoutDashes(), uuid.NewWithoutDashes()
+			var outputCommit *pfs.Commit
+			if uploadBranch != "" {
+				outputCommit, err = client.StartCommit(request.Pipeline.Name, uploadBranch)
+				if err != nil {
+					return err
+				}
+				outputCommitID = outputCommit.ID
+			}
+			if err := func() error {
+				for _, di := range dis {
+					datumID := di.Datum.ID
+					fmt.Fprintf(os.Stderr, "running datum %s\n", datumID)
+					if err := runLocalDatum(client, request.Transform, filepath.Join(localDir, datumID), jobID, outputCommitID, di); err != nil {
+						return errors.Wrapf(err, "datum %s", datumID)
+					}
+				}
+				if outputCommit == nil {
+					return nil
+				}
+				return uploadLocalOutput(client, outputCommit, localDir, dis)
+			}(); err != nil {
+				if outputCommit != nil {
+					// don't leave the upload branch with a partial commit
+					if err := client.SquashCommit(outputCommit.Repo.Name, outputCommit.ID); err != nil {
+						fmt.Fprintf(os.Stderr, "could not delete commit %s: %v\n", outputCommit.ID, err)
+					}
+				}
+				return err
+			}
+			if outputCommit == nil {
+				return nil
+			}
+			return client.FinishCommit(outputCommit.Repo.Name, outputCommit.ID)
+		}),
+	}
+	runLocal.Flags().StringArrayVar(&localDatumIDs, "datum", []string{}, "The ID of a datum to run. Can be repeated to run multiple datums. Defaults to the pipeline's first datum.")
+	runLocal.Flags().StringVar(&localDir, "dir", "pfs", "The directory to download each datum's inputs and write its output to.")
+	runLocal.Flags().StringVar(&uploadBranch, "upload", "", "Upload the datums' output to this branch of the pipeline's output repo, replacing the branch's contents. The pipeline's output branch can't be used.")
+	commands = append(commands, cmdutil.CreateAlias(runLocal, "run local"))
+
 	inspectPipeline := &cobra.Command{
 		Use:   "{{alias}} <pipeline>",
 		Short: "Return info about a pipeline.",
@@ -1417,3 +1742,340 @@ func ParsePipelineStates(stateStrs []string) (string, error) {
 	}
 	return validateJQConditionString(strings.Join(conditions, " or "))
 }
+
+// readSpecInput returns the name and input of the first pipeline in the pipeline spec
+// at 'specPath'.
+func readSpecInput(specPath string) (string, *ppsclient.Input, error) {
+	pipelineReader, err := ppsutil.NewPipelineManifestReader(specPath)
+	if err != nil {
+		return "", nil, err
+	}
+	request, err := pipelineReader.NextCreatePipelineRequest()
+	if err != nil {
+		return "", nil, err
+	}
+	if request.Input == nil {
+		return "", nil, errors.Errorf("pipeline spec has no input")
+	}
+	var pipeline string
+	if request.Pipeline != nil {
+		pipeline = request.Pipeline.Name
+	}
+	return pipeline, request.Input, nil
+}
+
+// parseLogTime parses an RFC 3339 time for GetLogs, or returns nil if 't' is
+// empty.
+func parseLogTime(t string) (*types.Timestamp, error) {
+	if t == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, t)
+	if err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	return types.TimestampProto(parsed)
+}
+
+// localDatums returns the datums that a pipeline named 'pipelineName' with
+// 'input' would create whose IDs are in 'datumIDs', or just its first datum if
+// 'datumIDs' is empty. Each input branch is read at its head commit.
+func localDatums(client *pachdclient.APIClient, pipelineName string, input *ppsclient.Input, datumIDs []string) ([]*ppsclient.DatumInfo, error) {
+	wanted := make(map[string]bool)
+	for _, id := range datumIDs {
+		wanted[id] = true
+	}
+	var dis []*ppsclient.DatumInfo
+	if err := client.ListDatumInput(pipelineName, input, func(di *ppsclient.DatumInfo) error {
+		id := di.Datum.ID
+		if len(datumIDs) == 0 || wanted[id] {
+			dis = append(dis, di)
+			delete(wanted, id)
+		}
+		if len(datumIDs) == 0 || len(wanted) == 0 {
+			return errutil.ErrBreak
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(datumIDs) == 0 && len(dis) == 0 {
+		return nil, errors.Errorf("pipeline has no datums")
+	}
+	if len(wanted) > 0 {
+		var missing []string
+		for id := range wanted {
+			missing = append(missing, id)
+		}
+		sort.Strings(missing)
+		return nil, errors.Errorf("datums not found: %s", strings.Join(missing, ", "))
+	}
+	return dis, nil
+}
+
+// localDatumInputs returns the inputs of the datum 'di', as a worker would see
+// them.
+func localDatumInputs(di *ppsclient.DatumInfo) ([]*common.Input, error) {
+	if len(di.Inputs) != len(di.Data) {
+		return nil, errors.Errorf("datum %s has no input info; pachd may be out of date", di.Datum.ID)
+	}
+	var inputs []*common.Input
+	for i, fileInfo := range di.Data {
+		inputs = append(inputs, &common.Input{
+			FileInfo:   fileInfo,
+			Name:       di.Inputs[i].Name,
+			Dir:        di.Inputs[i].Dir,
+			EmptyFiles: di.Inputs[i].EmptyFiles,
+		})
+	}
+	return inputs, nil
+}
+
+// localUserCodeEnv returns the environment that 'transform' is run with
+// locally: its env, which a worker gets from its pod spec, then the input
+// environment variables of 'inputs', which have been downloaded to 'pfsDir',
+// plus the job and output commit IDs, like driver.UserCodeEnv.
+func localUserCodeEnv(transform *ppsclient.Transform, pfsDir, jobID, outputCommitID string, inputs []*common.Input) []string {
+	env := os.Environ()
+	var names []string
+	for name := range transform.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, fmt.Sprintf("%s=%s", name, transform.Env[name]))
+	}
+	env = append(env, common.InputEnv(pfsDir, inputs)...)
+	env = append(env, fmt.Sprintf("%s=%s", pachdclient.JobIDEnv, jobID))
+	return append(env, fmt.Sprintf("%s=%s", pachdclient.OutputCommitIDEnv, outputCommitID))
+}
+
+// localUserCodeCmd returns the command that runs 'transform' locally on
+// 'inputs', in the transform's working dir.
+func localUserCodeCmd(transform *ppsclient.Transform, pfsDir, jobID, outputCommitID string, inputs []*common.Input) *exec.Cmd {
+	cmd := exec.Command(transform.Cmd[0], transform.Cmd[1:]...)
+	if transform.Stdin != nil {
+		cmd.Stdin = strings.NewReader(strings.Join(transform.Stdin, "\n") + "\n")
+	}
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = localUserCodeEnv(transform, pfsDir, jobID, outputCommitID, inputs)
+	cmd.Dir = transform.WorkingDir
+	return cmd
+}
+
+// runLocalDatum downloads the inputs of the datum 'di' to 'pfsDir', and
+// runs 'transform' on them, with its output written to 'pfsDir'/out.
+func runLocalDatum(client *pachdclient.APIClient, transform *ppsclient.Transform, pfsDir, jobID, outputCommitID string, di *ppsclient.DatumInfo) error {
+	inputs, err := localDatumInputs(di)
+	if err != nil {
+		return err
+	}
+	// The transform runs in its working dir, so the input environment
+	// variables must not be relative to this one.
+	pfsDir, err = filepath.Abs(pfsDir)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	if err := os.RemoveAll(pfsDir); err != nil {
+		return errors.EnsureStack(err)
+	}
+	if err := os.MkdirAll(filepath.Join(pfsDir, "out"), 0777); err != nil {
+		return errors.EnsureStack(err)
+	}
+	if err := pfssync.WithDownloader(client, func(downloader pfssync.Downloader) error {
+		for _, input := range inputs {
+			var opts []pfssync.DownloadOption
+			if input.EmptyFiles {
+				opts = append(opts, pfssync.WithEmpty())
+			}
+			if err := downloader.Download(filepath.Join(pfsDir, input.Name, input.Dir), input.FileInfo.File, opts...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	cmd := localUserCodeCmd(transform, pfsDir, jobID, outputCommitID, inputs)
+	if err := cmd.Run(); err != nil {
+		exitErr := &exec.ExitError{}
+		if errors.As(err, &exitErr) {
+			for _, returnCode := range transform.AcceptReturnCode {
+				if int(returnCode) == exitErr.ExitCode() {
+					return nil
+				}
+			}
+		}
+		return errors.EnsureStack(err)
+	}
+	return nil
+}
+
+// validateUploadBranch returns an error if the output of a local run may not
+// be uploaded to 'branch' of the output repo of the pipeline in 'request'.
+// Uploading replaces the branch's contents, so it's refused for the branches
+// that the pipeline's jobs write to.
+func validateUploadBranch(request *ppsclient.CreatePipelineRequest, branch string) error {
+	outputBranch := request.OutputBranch
+	if outputBranch == "" {
+		outputBranch = "master"
+	}
+	if branch == outputBranch || branch == "stats" {
+		return errors.Errorf("cannot upload to %q, which holds the output of the pipeline's jobs; upload to a different branch", branch)
+	}
+	return nil
+}
+
+// uploadLocalOutput replaces the contents of the open commit 'commit' with the
+// output of the datums 'dis', which were run in 'dir'. Each datum's output is
+// tagged with its ID, so files written by several datums are merged as they
+// would be in a job.
+func uploadLocalOutput(client *pachdclient.APIClient, commit *pfs.Commit, dir string, dis []*ppsclient.DatumInfo) error {
+	return client.WithModifyFileClient(commit.Repo.Name, commit.ID, func(mf *pachdclient.ModifyFileClient) error {
+		if err := mf.DeleteFile("/"); err != nil {
+			return err
+		}
+		for _, di := range dis {
+			datumID := di.Datum.ID
+			outDir := filepath.Join(dir, datumID, "out")
+			if err := filepath.Walk(outDir, func(filePath string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if info.IsDir() {
+					return nil
+				}
+				relPath, err := filepath.Rel(outDir, filePath)
+				if err != nil {
+					return errors.EnsureStack(err)
+				}
+				f, err := os.Open(filePath)
+				if err != nil {
+					return errors.EnsureStack(err)
+				}
+				defer f.Close()
+				return mf.AppendFile(filepath.ToSlash(relPath), false, f, datumID)
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// listDatumPlan prints the datums that 'input' would create, followed by
+// their totals.
+func listDatumPlan(client *pachdclient.APIClient, pipeline string, input *ppsclient.Input) error {
+	writer := tabwriter.NewWriter(os.Stdout, pretty.DatumPlanHeader)
+	plan := &pretty.DatumPlan{}
+	if err := client.ListDatumInput(pipeline, input, func(di *ppsclient.DatumInfo) error {
+		pretty.PrintDatumPlan(writer, di)
+		plan.Add(di)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	pretty.PrintDatumPlanSummary(os.Stdout, plan)
+	return nil
+}
+
+// listJobCost prints the total resource usage of the jobs matching the given
+// filters, grouped by pipeline. 'since' and 'until' bound the jobs' start
+// times, as durations before now.
+func listJobCost(client *pachdclient.APIClient, pipelineName string, commits []*pfs.Commit, outputCommit *pfs.Commit, history int64, filter, since, until string) error {
+	var after, before time.Time
+	if since != "" {
+		d, err := time.ParseDuration(since)
+		if err != nil {
+			return errors.Wrapf(err, "error parsing since")
+		}
+		after = time.Now().Add(-d)
+	}
+	if until != "" {
+		d, err := time.ParseDuration(until)
+		if err != nil {
+			return errors.Wrapf(err, "error parsing until")
+		}
+		before = time.Now().Add(-d)
+	}
+	costs := make(map[string]*pretty.PipelineCost)
+	if err := client.ListJobFilterF(pipelineName, commits, outputCommit, history, false, filter, func(ji *ppsclient.JobInfo) error {
+		started, err := types.TimestampFromProto(ji.Started)
+		if err != nil {
+			return err
+		}
+		if (!after.IsZero() && started.Before(after)) || (!before.IsZero() && started.After(before)) {
+			return nil
+		}
+		if costs[ji.Pipeline.Name] == nil {
+			costs[ji.Pipeline.Name] = &pretty.PipelineCost{Pipeline: ji.Pipeline.Name}
+		}
+		costs[ji.Pipeline.Name].AddJob(ji)
+		return nil
+	}); err != nil {
+		return err
+	}
+	var pipelines []string
+	for pipeline := range costs {
+		pipelines = append(pipelines, pipeline)
+	}
+	sort.Strings(pipelines)
+	writer := tabwriter.NewWriter(os.Stdout, pretty.CostHeader)
+	for _, pipeline := range pipelines {
+		pretty.PrintPipelineCost(writer, costs[pipeline])
+	}
+	return writer.Flush()
+}
+
+// watchJob shows a progress bar for each worker processing 'jobID', with the
+// progress and counters reported by the worker's user code, until the job
+// finishes.
+func watchJob(client *pachdclient.APIClient, jobID string) error {
+	ctx, cancel := context.WithCancel(client.Ctx())
+	defer cancel()
+	client = client.WithCtx(ctx)
+	go func() {
+		defer cancel()
+		client.InspectJob(jobID, true)
+	}()
+	bars := make(map[string]*progress.Bar)
+	err := client.WatchJobStatus(jobID, func(status *ppsclient.WorkerStatus) error {
+		bar, ok := bars[status.WorkerID]
+		if !ok {
+			bar = progress.NewBar(status.WorkerID, 100)
+			bars[status.WorkerID] = bar
+		}
+		bar.SetCurrent(int64(status.Progress * 100))
+		bar.SetInfo(workerStatusInfo(status))
+		return nil
+	})
+	for _, bar := range bars {
+		bar.Finish()
+	}
+	progress.Wait()
+	if err != nil && ctx.Err() == nil {
+		return err
+	}
+	return nil
+}
+
+// workerStatusInfo summarizes a worker's status for watchJob
+func workerStatusInfo(status *ppsclient.WorkerStatus) string {
+	if status.JobID == "" {
+		return "idle"
+	}
+	var names []string
+	for name := range status.Counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var info []string
+	for _, name := range names {
+		info = append(info, fmt.Sprintf("%s=%d", name, status.Counters[name]))
+	}
+	return strings.Join(info, ", ")
+}
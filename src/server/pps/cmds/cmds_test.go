@@ -28,10 +28,14 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
+	pachdclient "github.com/pachyderm/pachyderm/v2/src/client"
 	"github.com/pachyderm/pachyderm/v2/src/internal/require"
 	tu "github.com/pachyderm/pachyderm/v2/src/internal/testutil"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	ppsclient "github.com/pachyderm/pachyderm/v2/src/pps"
 )
 
 const badJSON1 = `
@@ -615,6 +619,184 @@ func TestEditPipeline(t *testing.T) {
 		`).Run())
 }
 
+func TestRollbackPipeline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	require.NoError(t, tu.BashCmd(`
+		yes | pachctl delete all
+	`).Run())
+	require.NoError(t, tu.BashCmd(`
+		pachctl create repo data
+		pachctl create pipeline <<EOF
+		  pipeline:
+		    name: my-pipeline
+		  input:
+		    pfs:
+		      glob: /*
+		      repo: data
+		  transform:
+		    cmd: [ /bin/bash ]
+		    stdin:
+		      - "echo version-one >/pfs/out/file"
+		  parallelism_spec:
+		    constant: 2
+		EOF
+		pachctl update pipeline <<EOF
+		  pipeline:
+		    name: my-pipeline
+		  input:
+		    pfs:
+		      glob: /*
+		      repo: data
+		  transform:
+		    cmd: [ /bin/bash ]
+		    stdin:
+		      - "echo version-two >/pfs/out/file"
+		EOF
+		`).Run())
+	require.YesError(t, tu.BashCmd(`pachctl rollback pipeline my-pipeline --to-version 2`).Run())
+	require.YesError(t, tu.BashCmd(`pachctl rollback pipeline my-pipeline --to-version 5`).Run())
+	// The rollback restores the whole spec of version 1 as a new version
+	require.NoError(t, tu.BashCmd(`
+		pachctl rollback pipeline my-pipeline --to-version 1
+		pachctl inspect pipeline my-pipeline --raw \
+		| match '"version": "3"' \
+		| match 'version-one' \
+		| match '"constant": "2"'
+		`).Run())
+}
+
+func TestValidateUploadBranch(t *testing.T) {
+	request := &ppsclient.CreatePipelineRequest{Pipeline: pachdclient.NewPipeline("edges")}
+	require.YesError(t, validateUploadBranch(request, "master"))
+	require.YesError(t, validateUploadBranch(request, "stats"))
+	require.NoError(t, validateUploadBranch(request, "scratch"))
+
+	request.OutputBranch = "out"
+	require.YesError(t, validateUploadBranch(request, "out"))
+	require.NoError(t, validateUploadBranch(request, "master"))
+}
+
+func TestLocalDatumInputs(t *testing.T) {
+	di := &ppsclient.DatumInfo{
+		Datum: &ppsclient.Datum{ID: "datum"},
+		Data: []*pfs.FileInfo{
+			{File: pachdclient.NewFile("images", "c1", "/a.png")},
+			{File: pachdclient.NewFile("images", "c1", "/dir/b.png")},
+		},
+		Inputs: []*ppsclient.DatumInput{
+			{Name: "left"},
+			{Name: "right", Dir: "dir", EmptyFiles: true},
+		},
+	}
+	inputs, err := localDatumInputs(di)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(inputs))
+	require.Equal(t, "left", inputs[0].Name)
+	require.Equal(t, "/a.png", inputs[0].FileInfo.File.Path)
+	require.Equal(t, "right", inputs[1].Name)
+	require.Equal(t, "dir", inputs[1].Dir)
+	require.True(t, inputs[1].EmptyFiles)
+
+	transform := &ppsclient.Transform{
+		Cmd:        []string{"sh"},
+		Env:        map[string]string{"FOO": "bar"},
+		WorkingDir: "/work",
+	}
+	cmd := localUserCodeCmd(transform, "/tmp/pfs", "job", "commit", inputs)
+	require.Equal(t, "/work", cmd.Dir)
+	require.OneOfEquals(t, "FOO=bar", cmd.Env)
+	require.OneOfEquals(t, "left=/tmp/pfs/left/a.png", cmd.Env)
+	require.OneOfEquals(t, "left_COMMIT=c1", cmd.Env)
+	require.OneOfEquals(t, pachdclient.JobIDEnv+"=job", cmd.Env)
+	require.OneOfEquals(t, pachdclient.OutputCommitIDEnv+"=commit", cmd.Env)
+
+	// datums listed by an older pachd don't say how their files are mounted
+	di.Inputs = nil
+	_, err = localDatumInputs(di)
+	require.YesError(t, err)
+}
+
+func TestRunLocal(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+	require.NoError(t, tu.BashCmd(`
+		yes | pachctl delete all
+	`).Run())
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "spec.yaml")
+	require.NoError(t, tu.BashCmd(`
+		pachctl create repo data
+		echo foo | pachctl put file data@master:/a
+		echo bar | pachctl put file data@master:/b
+		cat >{{.spec}} <<EOF
+		  pipeline:
+		    name: my-pipeline
+		  input:
+		    pfs:
+		      glob: /*
+		      repo: data
+		  transform:
+		    cmd: [ /bin/bash ]
+		    stdin:
+		      - "out=\$(dirname \$(dirname \$data))/out"
+		      - "cp \$data \$out/"
+		      - "echo \$PACH_JOB_ID >\$out/job-\$(basename \$data)"
+		      - "echo \$PACH_OUTPUT_COMMIT_ID >\$out/commit-\$(basename \$data)"
+		EOF
+		pachctl list datum --from-spec {{.spec}} | match data_a | match data_b
+		`,
+		"spec", spec,
+	).Run())
+
+	// Only the selected datum is run
+	require.NoError(t, tu.BashCmd(`
+		cd {{.dir}}
+		pachctl run local {{.spec}} --datum data_a
+		test "$(cat pfs/data_a/out/a)" = foo
+		test -s pfs/data_a/out/job-a
+		test ! -e pfs/data_b
+		`,
+		"dir", dir,
+		"spec", spec,
+	).Run())
+	require.YesError(t, tu.BashCmd(`
+		cd {{.dir}}
+		pachctl run local {{.spec}} --datum no-such-datum
+		`,
+		"dir", dir,
+		"spec", spec,
+	).Run())
+
+	// The output branch of the pipeline can't be overwritten
+	require.YesError(t, tu.BashCmd(`
+		cd {{.dir}}
+		pachctl run local {{.spec}} --upload master
+		`,
+		"dir", dir,
+		"spec", spec,
+	).Run())
+
+	// Uploading replaces the contents of the branch with the datums' output,
+	// and the transform sees the ID of the commit it's uploaded to
+	require.NoError(t, tu.BashCmd(`
+		cd {{.dir}}
+		pachctl create repo my-pipeline
+		echo stale | pachctl put file my-pipeline@scratch:/stale
+		pachctl run local {{.spec}} --datum data_a --datum data_b --upload scratch
+		pachctl get file my-pipeline@scratch:/a | match foo
+		pachctl get file my-pipeline@scratch:/b | match bar
+		pachctl list file my-pipeline@scratch | match -v stale
+		pachctl get file my-pipeline@scratch:/commit-a \
+		  | match "$(pachctl inspect commit my-pipeline@scratch --raw | jq -r .commit.id)"
+		`,
+		"dir", dir,
+		"spec", spec,
+	).Run())
+}
+
 func TestPipelineBuildLifecyclePython(t *testing.T) {
 	t.Skip("not implemented in V2")
 	require.NoError(t, tu.BashCmd("yes | pachctl delete all").Run())
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 	"text/template"
+	"time"
 
 	units "github.com/docker/go-units"
 	"github.com/fatih/color"
@@ -28,6 +30,10 @@ const (
 	DatumHeader = "ID\tFILES\tSTATUS\tTIME\t\n"
 	// SecretHeader is the header for secrets
 	SecretHeader = "NAME\tTYPE\tCREATED\t\n"
+	// DatumPlanHeader is the header for datums listed from an input spec
+	DatumPlanHeader = "ID\tFILES\tSIZE\tJOIN ON\tGROUP BY\t\n"
+	// CostHeader is the header for job cost reports
+	CostHeader = "PIPELINE\tJOBS\tWALL TIME\tCPU TIME\tMAX MEMORY\tDL\tUL\t\n"
 	// jobReasonLen is the amount of the job reason that we print
 	jobReasonLen = 25
 )
@@ -57,7 +63,8 @@ func PrintJobInfo(w io.Writer, jobInfo *ppsclient.JobInfo, fullTimestamps bool)
 	fmt.Fprintf(w, "%s\t", Progress(jobInfo))
 	fmt.Fprintf(w, "%s\t", pretty.Size(jobInfo.Stats.DownloadBytes))
 	fmt.Fprintf(w, "%s\t", pretty.Size(jobInfo.Stats.UploadBytes))
-	if jobInfo.State == ppsclient.JobState_JOB_FAILURE {
+	// Successful jobs have a reason if they finished with partial output
+	if jobInfo.State == ppsclient.JobState_JOB_FAILURE || jobInfo.State == ppsclient.JobState_JOB_SKIPPED || jobInfo.Reason != "" {
 		fmt.Fprintf(w, "%s: %s\t", JobState(jobInfo.State), safeTrim(jobInfo.Reason, jobReasonLen))
 	} else {
 		fmt.Fprintf(w, "%s\t", JobState(jobInfo.State))
@@ -65,6 +72,56 @@ func PrintJobInfo(w io.Writer, jobInfo *ppsclient.JobInfo, fullTimestamps bool)
 	fmt.Fprintln(w)
 }
 
+// PipelineCost is the total resource usage of a pipeline's jobs.
+type PipelineCost struct {
+	Pipeline       string
+	Jobs           int
+	WallTime       time.Duration
+	CPUTime        time.Duration
+	MaxMemoryBytes uint64
+	DownloadBytes  uint64
+	UploadBytes    uint64
+}
+
+// AddJob adds the resource usage of a job to the cost. Jobs that haven't
+// finished count their wall time up to now.
+func (c *PipelineCost) AddJob(jobInfo *ppsclient.JobInfo) {
+	c.Jobs++
+	if started, err := types.TimestampFromProto(jobInfo.Started); err == nil {
+		finished := time.Now()
+		if jobInfo.Finished != nil {
+			if t, err := types.TimestampFromProto(jobInfo.Finished); err == nil {
+				finished = t
+			}
+		}
+		c.WallTime += finished.Sub(started)
+	}
+	if jobInfo.Stats != nil {
+		c.CPUTime += time.Duration(jobInfo.Stats.CPUSeconds * float64(time.Second))
+		if jobInfo.Stats.MaxMemoryBytes > c.MaxMemoryBytes {
+			c.MaxMemoryBytes = jobInfo.Stats.MaxMemoryBytes
+		}
+		c.DownloadBytes += jobInfo.Stats.DownloadBytes
+		c.UploadBytes += jobInfo.Stats.UploadBytes
+	}
+}
+
+func cpuTime(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond).String()
+}
+
+// PrintPipelineCost pretty-prints a pipeline's cost.
+func PrintPipelineCost(w io.Writer, cost *PipelineCost) {
+	fmt.Fprintf(w, "%s\t", cost.Pipeline)
+	fmt.Fprintf(w, "%d\t", cost.Jobs)
+	fmt.Fprintf(w, "%s\t", cost.WallTime.Round(time.Second))
+	fmt.Fprintf(w, "%s\t", cpuTime(cost.CPUTime.Seconds()))
+	fmt.Fprintf(w, "%s\t", pretty.Size(cost.MaxMemoryBytes))
+	fmt.Fprintf(w, "%s\t", pretty.Size(cost.DownloadBytes))
+	fmt.Fprintf(w, "%s\t", pretty.Size(cost.UploadBytes))
+	fmt.Fprintln(w)
+}
+
 // PrintPipelineInfo pretty-prints pipeline info.
 func PrintPipelineInfo(w io.Writer, pipelineInfo *ppsclient.PipelineInfo, fullTimestamps bool) {
 	if pipelineInfo.Transform == nil {
@@ -146,8 +203,12 @@ Data Uploaded: {{prettySize .Stats.UploadBytes}}
 Download Time: {{prettyDuration .Stats.DownloadTime}}
 Process Time: {{prettyDuration .Stats.ProcessTime}}
 Upload Time: {{prettyDuration .Stats.UploadTime}}
+{{ if .Stats.OverlappedTime }}Overlapped Time: {{prettyDuration .Stats.OverlappedTime}}
+{{end}}CPU Time: {{cpuTime .Stats.CPUSeconds}}
+Max Memory: {{prettySize .Stats.MaxMemoryBytes}}
 Datum Timeout: {{.DatumTimeout}}
 Job Timeout: {{.JobTimeout}}
+Timeout Policy: {{.TimeoutPolicy}}
 Worker Status:
 {{workerStatus .}}Restarts: {{.Restart}}
 ParallelismSpec: {{.ParallelismSpec}}
@@ -171,7 +232,10 @@ Transform:
 {{prettyTransform .Transform}} {{if .OutputCommit}}
 Output Commit: {{.OutputCommit.ID}} {{end}} {{ if .StatsCommit }}
 Stats Commit: {{.StatsCommit.ID}} {{end}} {{ if .Egress }}
-Egress: {{.Egress.URL}} {{end}}
+Egress: {{egress .Egress}} {{end}} {{ if .EgressStatus }}
+Egress Attempts: {{.EgressStatus.Attempts}} {{ if .EgressStatus.Failed }}
+Egress Failed: true {{end}} {{ if .EgressStatus.LastError }}
+Egress Error: {{.EgressStatus.LastError}} {{end}} {{end}}
 `)
 	if err != nil {
 		return err
@@ -216,13 +280,21 @@ Parallelism Spec: {{.ParallelismSpec}}
     Number: {{ .ResourceLimits.Gpu.Number }} {{end}} {{end}}
 Datum Timeout: {{.DatumTimeout}}
 Job Timeout: {{.JobTimeout}}
+Timeout Policy: {{.TimeoutPolicy}}
 Input:
 {{pipelineInput .PipelineInfo}}
 {{ if .GithookURL }}Githook URL: {{.GithookURL}} {{end}}
 Output Branch: {{.OutputBranch}}
-Transform:
+{{ if .StateSpec }}{{ if .StateSpec.Enabled }}State: /pfs/state
+{{end}}{{end}}{{ if .DebugOnFailure }}{{ if .DebugOnFailure.Enabled }}Debug On Failure: true
+{{end}}{{end}}{{ if .Prefetch }}{{ if .Prefetch.Datums }}Prefetch: {{.Prefetch.Datums}} datums
+{{end}}{{end}}{{ if .NoSidecar }}No Sidecar: true
+{{end}}Transform:
 {{prettyTransform .Transform}}
-{{ if .Egress }}Egress: {{.Egress.URL}} {{end}}
+{{ if .Egress }}Egress: {{egress .Egress}} {{end}}
+{{ if .Notifications }}Webhooks:
+{{webhooks .Notifications}}{{end}}{{ if .RecentNotifications }}Recent Notifications:
+{{recentNotifications .RecentNotifications}}{{end}}
 {{if .RecentError}} Recent Error: {{.RecentError}} {{end}}
 Job Counts:
 {{jobCounts .JobCounts}}
@@ -263,6 +335,67 @@ func datumFiles(datumInfo *ppsclient.DatumInfo) string {
 	return builder.String()
 }
 
+// PrintDatumPlan pretty-prints a datum that would be created from an input
+// spec.
+func PrintDatumPlan(w io.Writer, datumInfo *ppsclient.DatumInfo) {
+	fmt.Fprintf(w, "%s\t", datumInfo.Datum.ID)
+	fmt.Fprintf(w, "%s\t", datumFiles(datumInfo))
+	fmt.Fprintf(w, "%s\t", units.BytesSize(float64(datumSize(datumInfo))))
+	fmt.Fprintf(w, "%s\t", strings.Join(datumInfo.JoinOn, ", "))
+	fmt.Fprintf(w, "%s\t", strings.Join(datumInfo.GroupBy, ", "))
+	fmt.Fprintln(w)
+}
+
+func datumSize(datumInfo *ppsclient.DatumInfo) uint64 {
+	var size uint64
+	for _, fi := range datumInfo.Data {
+		size += fi.SizeBytes
+	}
+	return size
+}
+
+// datumSkewFactor is how many times larger than the median a datum has to be
+// for DatumPlan to warn about skew.
+const datumSkewFactor = 10
+
+// DatumPlan summarizes the datums that would be created from an input spec.
+type DatumPlan struct {
+	sizes   []uint64
+	largest *ppsclient.DatumInfo
+}
+
+// Add adds a datum to the plan.
+func (p *DatumPlan) Add(datumInfo *ppsclient.DatumInfo) {
+	size := datumSize(datumInfo)
+	if p.largest == nil || size > datumSize(p.largest) {
+		p.largest = datumInfo
+	}
+	p.sizes = append(p.sizes, size)
+}
+
+// PrintDatumPlanSummary prints the totals of a datum plan, and a warning if
+// one datum is far larger than the median, since that datum will dominate
+// the job's run time.
+func PrintDatumPlanSummary(w io.Writer, plan *DatumPlan) {
+	var total uint64
+	for _, size := range plan.sizes {
+		total += size
+	}
+	fmt.Fprintf(w, "%d datums, %s total\n", len(plan.sizes), units.BytesSize(float64(total)))
+	if len(plan.sizes) == 0 {
+		return
+	}
+	sizes := append([]uint64(nil), plan.sizes...)
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+	median := sizes[len(sizes)/2]
+	largest := datumSize(plan.largest)
+	fmt.Fprintf(w, "median datum size: %s, largest: %s (%s)\n", units.BytesSize(float64(median)), units.BytesSize(float64(largest)), plan.largest.Datum.ID)
+	if median > 0 && largest > datumSkewFactor*median {
+		fmt.Fprintf(w, "%s datum %s is %dx larger than the median, consider a finer glob pattern\n",
+			color.New(color.FgYellow).SprintFunc()("WARNING:"), plan.largest.Datum.ID, largest/median)
+	}
+}
+
 // PrintDetailedDatumInfo pretty-prints detailed info about a datum
 func PrintDetailedDatumInfo(w io.Writer, datumInfo *ppsclient.DatumInfo) {
 	fmt.Fprintf(w, "ID\t%s\n", datumInfo.Datum.ID)
@@ -315,6 +448,22 @@ func PrintDetailedDatumInfo(w io.Writer, datumInfo *ppsclient.DatumInfo) {
 	tw.Flush()
 }
 
+// PrintDetailedFileLineage pretty-prints the lineage of an output file
+func PrintDetailedFileLineage(w io.Writer, lineage *ppsclient.FileLineage) {
+	fmt.Fprintf(w, "File\t%s@%s:%s\n", lineage.File.Commit.Repo.Name, lineage.File.Commit.ID, lineage.File.Path)
+	fmt.Fprintf(w, "Job ID\t%s\n", lineage.Job.ID)
+	for _, datumInfo := range lineage.Datums {
+		fmt.Fprintf(w, "Datum\t%s (job %s)\n", datumInfo.Datum.ID, datumInfo.Datum.Job.ID)
+		fmt.Fprintf(w, "Inputs:\n")
+		tw := ansiterm.NewTabWriter(w, 10, 1, 3, ' ', 0)
+		PrintFileHeader(tw)
+		for _, d := range datumInfo.Data {
+			PrintFile(tw, d.File)
+		}
+		tw.Flush()
+	}
+}
+
 // PrintSecretInfo pretty-prints secret info.
 func PrintSecretInfo(w io.Writer, secretInfo *ppsclient.SecretInfo) {
 	fmt.Fprintf(w, "%s\t%s\t%s\t\n", secretInfo.Secret.Name, secretInfo.Type, pretty.Ago(secretInfo.CreationTimestamp))
@@ -340,6 +489,8 @@ func datumState(datumState ppsclient.DatumState) string {
 		return color.New(color.FgYellow).SprintFunc()("recovered")
 	case ppsclient.DatumState_SUCCESS:
 		return color.New(color.FgGreen).SprintFunc()("success")
+	case ppsclient.DatumState_STARTING:
+		return color.New(color.FgYellow).SprintFunc()("starting")
 	}
 	return "-"
 }
@@ -359,6 +510,8 @@ func JobState(jobState ppsclient.JobState) string {
 		return color.New(color.FgRed).SprintFunc()("killed")
 	case ppsclient.JobState_JOB_EGRESSING:
 		return color.New(color.FgYellow).SprintFunc()("egressing")
+	case ppsclient.JobState_JOB_SKIPPED:
+		return color.New(color.FgCyan).SprintFunc()("skipped")
 
 	}
 	return "-"
@@ -475,10 +628,58 @@ func ShorthandInput(input *ppsclient.Input) string {
 		return "(" + strings.Join(subInput, " ∪ ") + ")"
 	case input.Cron != nil:
 		return fmt.Sprintf("%s:%s", input.Cron.Name, input.Cron.Spec)
+	case input.SQL != nil:
+		return fmt.Sprintf("%s:%s", input.SQL.Name, input.SQL.Spec)
+	case input.Window != nil:
+		var window []string
+		if input.Window.Commits > 0 {
+			window = append(window, fmt.Sprintf("%d commits", input.Window.Commits))
+		}
+		if duration, err := types.DurationFromProto(input.Window.Duration); err == nil {
+			window = append(window, duration.String())
+		}
+		return fmt.Sprintf("%s:%s[%s]", input.Window.Repo, input.Window.Glob, strings.Join(window, ", "))
 	}
 	return ""
 }
 
+func egress(egress *ppsclient.Egress) string {
+	switch {
+	case egress.GetSqlDatabase() != nil:
+		return fmt.Sprintf("%s table %s", egress.GetSqlDatabase().Driver, egress.GetSqlDatabase().Table)
+	case egress.GetFileSystem() != nil:
+		return egress.GetFileSystem().Path
+	}
+	return egress.URL
+}
+
+func webhooks(notifications *ppsclient.Notifications) string {
+	var buffer bytes.Buffer
+	for _, webhook := range notifications.Webhooks {
+		var events []string
+		for _, event := range webhook.Events {
+			events = append(events, event.String())
+		}
+		if len(events) == 0 {
+			events = append(events, "all events")
+		}
+		fmt.Fprintf(&buffer, "  %s (%s)\n", webhook.URL, strings.Join(events, ", "))
+	}
+	return buffer.String()
+}
+
+func recentNotifications(notificationInfos []*ppsclient.NotificationInfo) string {
+	var buffer bytes.Buffer
+	for _, n := range notificationInfos {
+		fmt.Fprintf(&buffer, "  %s %s %s: %s (attempts: %d)", pretty.Ago(n.Created), n.Event, n.Webhook.URL, n.State, n.Attempts)
+		if n.LastError != "" {
+			fmt.Fprintf(&buffer, " error: %s", n.LastError)
+		}
+		buffer.WriteString("\n")
+	}
+	return buffer.String()
+}
+
 var funcMap = template.FuncMap{
 	"pipelineState":        pipelineState,
 	"jobState":             JobState,
@@ -492,4 +693,8 @@ var funcMap = template.FuncMap{
 	"prettySize":           pretty.Size,
 	"jobCounts":            jobCounts,
 	"prettyTransform":      prettyTransform,
+	"egress":               egress,
+	"cpuTime":              cpuTime,
+	"webhooks":             webhooks,
+	"recentNotifications":  recentNotifications,
 }
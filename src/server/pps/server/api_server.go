@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math"
 	"path"
 	"sort"
@@ -31,6 +32,7 @@ import (
 	"github.com/pachyderm/pachyderm/v2/src/internal/ppsutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/serde"
 	"github.com/pachyderm/pachyderm/v2/src/internal/serviceenv"
+	"github.com/pachyderm/pachyderm/v2/src/internal/sql"
 	"github.com/pachyderm/pachyderm/v2/src/internal/testutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/tracing"
 	"github.com/pachyderm/pachyderm/v2/src/internal/tracing/extended"
@@ -48,8 +50,10 @@ import (
 	workerserver "github.com/pachyderm/pachyderm/v2/src/server/worker/server"
 
 	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 	opentracing "github.com/opentracing/opentracing-go"
+	glob "github.com/pachyderm/ohmyglob"
 	"github.com/robfig/cron"
 	logrus "github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
@@ -72,6 +76,11 @@ const (
 	// DefaultLogsFrom is the default duration to return logs from, i.e. by
 	// default we return logs from up to 24 hours ago.
 	DefaultLogsFrom = time.Hour * 24
+
+	// maxStopUserCodeWait is the longest that StopJob and DeleteJob wait for
+	// the user code of a stopped job to shut down, however long its
+	// pipeline's cancel grace period is.
+	maxStopUserCodeWait = time.Minute
 )
 
 var (
@@ -120,8 +129,9 @@ type apiServer struct {
 	peerPort              uint16
 	gcPercent             int
 	// collections
-	pipelines col.Collection
-	jobs      col.Collection
+	pipelines     col.Collection
+	jobs          col.Collection
+	notifications col.Collection
 }
 
 func merge(from, to map[string]bool) {
@@ -144,6 +154,11 @@ func validateNames(names map[string]bool, input *pps.Input) error {
 			return errors.Errorf(`name "%s" was used more than once`, input.Cron.Name)
 		}
 		names[input.Cron.Name] = true
+	case input.SQL != nil:
+		if names[input.SQL.Name] {
+			return errors.Errorf(`name "%s" was used more than once`, input.SQL.Name)
+		}
+		names[input.SQL.Name] = true
 	case input.Union != nil:
 		for _, input := range input.Union {
 			namesCopy := make(map[string]bool)
@@ -180,6 +195,11 @@ func validateNames(names map[string]bool, input *pps.Input) error {
 			return errors.Errorf(`name "%s" was used more than once`, input.Git.Name)
 		}
 		names[input.Git.Name] = true
+	case input.Window != nil:
+		if names[input.Window.Name] {
+			return errors.Errorf(`name "%s" was used more than once`, input.Window.Name)
+		}
+		names[input.Window.Name] = true
 	}
 	return nil
 }
@@ -222,6 +242,15 @@ func (a *apiServer) validateInputInTransaction(txnCtx *txnenv.TransactionContext
 					return errors.Errorf("input cannot specify both 's3' and " +
 						"'empty_files', as 's3' requires input data to be accessed via " +
 						"Pachyderm's S3 gateway rather than the file system")
+				case input.Pfs.PathFilter != "" && input.Pfs.Trigger != nil:
+					return errors.Errorf("input cannot specify both 'path_filter' and " +
+						"'trigger', as a triggered input's commit may span several " +
+						"commits to the input branch")
+				}
+				if input.Pfs.PathFilter != "" {
+					if err := ppsutil.ValidatePathFilter(input.Pfs.PathFilter); err != nil {
+						return err
+					}
 				}
 				if _, err := txnCtx.Pfs().InspectRepoInTransaction(txnCtx, &pfs.InspectRepoRequest{
 					Repo: client.NewRepo(input.Pfs.Repo)}); err != nil {
@@ -290,6 +319,71 @@ func (a *apiServer) validateInputInTransaction(txnCtx *txnenv.TransactionContext
 					return err
 				}
 			}
+			if input.SQL != nil {
+				if set {
+					return errors.Errorf("multiple input types set")
+				}
+				set = true
+				switch {
+				case len(input.SQL.Name) == 0:
+					return errors.Errorf("input must specify a name")
+				case input.SQL.Driver != "postgres":
+					return errors.Errorf("unsupported sql driver %q, only \"postgres\" is supported", input.SQL.Driver)
+				case input.SQL.DSNSecret == "":
+					return errors.Errorf("sql input must specify a 'dsn_secret'")
+				case input.SQL.Query == "":
+					return errors.Errorf("sql input must specify a query")
+				case input.SQL.RowsPerFile < 0:
+					return errors.Errorf("sql input 'rows_per_file' must not be negative")
+				}
+				if err := sql.ValidateFormat(input.SQL.Format); err != nil {
+					return err
+				}
+				if _, err := cron.ParseStandard(input.SQL.Spec); err != nil {
+					return errors.Wrapf(err, "error parsing cron-spec")
+				}
+			}
+			if input.Window != nil {
+				if set {
+					return errors.Errorf("multiple input types set")
+				}
+				set = true
+				switch {
+				case len(input.Window.Name) == 0:
+					return errors.Errorf("input must specify a name")
+				case input.Window.Name == "out":
+					return errors.Errorf("input cannot be named \"out\", as pachyderm " +
+						"already creates /pfs/out to collect job output")
+				case input.Window.Repo == "":
+					return errors.Errorf("input must specify a repo")
+				case input.Window.Branch == "":
+					return errors.Errorf("input must specify a branch")
+				case len(input.Window.Glob) == 0:
+					return errors.Errorf("input must specify a glob")
+				case input.Window.Lazy && input.Window.EmptyFiles:
+					return errors.Errorf("input cannot specify both 'lazy' and 'empty_files'")
+				case input.Window.Commits < 0:
+					return errors.Errorf("window input 'commits' must not be negative")
+				case input.Window.Commits == 0 && input.Window.Duration == nil:
+					return errors.Errorf("window input must specify 'commits', 'duration', or both")
+				}
+				if _, err := glob.Compile(input.Window.Glob, '/'); err != nil {
+					return errors.Wrapf(err, "invalid glob %q", input.Window.Glob)
+				}
+				if input.Window.Duration != nil {
+					duration, err := types.DurationFromProto(input.Window.Duration)
+					if err != nil {
+						return errors.Wrapf(err, "invalid window duration")
+					}
+					if duration <= 0 {
+						return errors.Errorf("window input 'duration' must be positive")
+					}
+				}
+				if _, err := txnCtx.Pfs().InspectRepoInTransaction(txnCtx, &pfs.InspectRepoRequest{
+					Repo: client.NewRepo(input.Window.Repo)}); err != nil {
+					return err
+				}
+			}
 			if !set {
 				return errors.Errorf("no input set")
 			}
@@ -308,6 +402,154 @@ func validateTransform(transform *pps.Transform) error {
 	if transform.Image == "" {
 		return errors.Errorf("pipeline transform must contain an image")
 	}
+	// External secrets are resolved by the workers, so a bad reference would
+	// otherwise only surface as crashing workers
+	for _, secret := range transform.Secrets {
+		if secret.External == "" {
+			continue
+		}
+		if _, err := ppsutil.ParseSecretRef(secret.External); err != nil {
+			return err
+		}
+	}
+	gracePeriod, err := ppsutil.CancelGracePeriod(transform)
+	if err != nil {
+		return err
+	}
+	if gracePeriod < 0 {
+		return errors.Errorf("cancel_grace_period must not be negative")
+	}
+	if len(transform.OnCancel) > 0 && gracePeriod == 0 {
+		return errors.Errorf("on_cancel requires cancel_grace_period, as it's " +
+			"only run while cancelled user code is shutting down")
+	}
+	return nil
+}
+
+// requiresDatums returns an error if 'pipelineInfo' is a spout or a service,
+// which don't process datums and so can't use 'feature'.
+func requiresDatums(pipelineInfo *pps.PipelineInfo, feature string) error {
+	if pipelineInfo.Spout != nil || pipelineInfo.Service != nil {
+		return errors.Errorf("%s is not supported for spouts or services, as "+
+			"they do not process datums", feature)
+	}
+	return nil
+}
+
+func validateDebugOnFailure(pipelineInfo *pps.PipelineInfo) error {
+	spec := pipelineInfo.DebugOnFailure
+	if spec == nil || !spec.Enabled {
+		return nil
+	}
+	if err := requiresDatums(pipelineInfo, "debug_on_failure"); err != nil {
+		return err
+	}
+	if spec.Timeout != nil {
+		timeout, err := types.DurationFromProto(spec.Timeout)
+		if err != nil {
+			return err
+		}
+		if timeout <= 0 {
+			return errors.Errorf("timeout must be positive")
+		}
+	}
+	return nil
+}
+
+func validatePrefetch(pipelineInfo *pps.PipelineInfo) error {
+	spec := pipelineInfo.Prefetch
+	if spec == nil {
+		return nil
+	}
+	if spec.Datums < 0 {
+		return errors.Errorf("datums must not be negative")
+	}
+	if spec.Datums == 0 {
+		return nil
+	}
+	if err := requiresDatums(pipelineInfo, "prefetch"); err != nil {
+		return err
+	}
+	if ppsutil.IsStateful(pipelineInfo) {
+		return errors.Errorf("prefetch is not supported for stateful " +
+			"pipelines, as each datum's state depends on the previous datum")
+	}
+	var lazy bool
+	pps.VisitInput(pipelineInfo.Input, func(input *pps.Input) {
+		if input.Pfs != nil && input.Pfs.Lazy {
+			lazy = true
+		}
+	})
+	if lazy {
+		return errors.Errorf("prefetch is not supported for lazy inputs, as " +
+			"they're only readable while their datum is processed")
+	}
+	_, _, err := ppsutil.PrefetchLimits(spec)
+	return err
+}
+
+func validateState(pipelineInfo *pps.PipelineInfo) error {
+	if !ppsutil.IsStateful(pipelineInfo) {
+		return nil
+	}
+	if err := requiresDatums(pipelineInfo, "state"); err != nil {
+		return err
+	}
+	var err error
+	pps.VisitInput(pipelineInfo.Input, func(input *pps.Input) {
+		var name string
+		switch {
+		case input.Pfs != nil:
+			name = input.Pfs.Name
+		case input.Cron != nil:
+			name = input.Cron.Name
+		case input.Git != nil:
+			name = input.Git.Name
+		case input.SQL != nil:
+			name = input.SQL.Name
+		case input.Window != nil:
+			name = input.Window.Name
+		}
+		if err == nil && name == "state" {
+			err = errors.Errorf("input cannot be named \"state\", as pachyderm " +
+				"already creates /pfs/state to hold the pipeline's state")
+		}
+	})
+	return err
+}
+
+func validateEgress(egress *pps.Egress) error {
+	if egress == nil {
+		return nil
+	}
+	if egress.MaxAttempts < 0 {
+		return errors.Errorf("max_attempts must not be negative")
+	}
+	switch {
+	case egress.Target == nil:
+		if egress.URL == "" {
+			return errors.Errorf("egress must specify a URL or a target")
+		}
+	case egress.URL != "":
+		return errors.Errorf("egress cannot specify both a URL and a target")
+	case egress.GetSqlDatabase() != nil:
+		db := egress.GetSqlDatabase()
+		switch {
+		case db.Driver != "postgres":
+			return errors.Errorf("unsupported sql driver %q, only \"postgres\" is supported", db.Driver)
+		case db.DSNEnvVar == "":
+			return errors.Errorf("sql_database egress must specify a 'dsn_env_var'")
+		case db.Table == "":
+			return errors.Errorf("sql_database egress must specify a table")
+		}
+		if err := sql.ValidateFormat(db.Format); err != nil {
+			return err
+		}
+	case egress.GetFileSystem() != nil:
+		if !path.IsAbs(egress.GetFileSystem().Path) {
+			return errors.Errorf("file_system egress must specify an absolute path")
+		}
+	}
 	return nil
 }
 
@@ -395,7 +637,7 @@ type pipelineOperation uint8
 const (
 	// pipelineOpCreate is required for CreatePipeline
 	pipelineOpCreate pipelineOperation = iota
-	// pipelineOpListDatum is required for ListDatum
+	// pipelineOpListDatum is required for ListDatum and InspectFileLineage
 	pipelineOpListDatum
 	// pipelineOpGetLogs is required for GetLogs
 	pipelineOpGetLogs
@@ -431,9 +673,12 @@ func (a *apiServer) authorizePipelineOpInTransaction(txnCtx *txnenv.TransactionC
 		pps.VisitInput(input, func(in *pps.Input) {
 			var repo string
 
-			if in.Pfs != nil {
+			switch {
+			case in.Pfs != nil:
 				repo = in.Pfs.Repo
-			} else {
+			case in.Window != nil:
+				repo = in.Window.Repo
+			default:
 				return
 			}
 
@@ -527,8 +772,29 @@ func (a *apiServer) UpdateJobStateInTransaction(txnCtx *txnenv.TransactionContex
 	jobPtr.DataRecovered = request.DataRecovered
 	jobPtr.DataTotal = request.DataTotal
 	jobPtr.Stats = request.Stats
+	jobPtr.EgressStatus = request.EgressStatus
 
-	return ppsutil.UpdateJobState(a.pipelines.ReadWrite(txnCtx.Stm), jobs, jobPtr, request.State, request.Reason)
+	pipelines := a.pipelines.ReadWrite(txnCtx.Stm)
+	if err := ppsutil.UpdateJobState(pipelines, jobs, jobPtr, request.State, request.Reason); err != nil {
+		return err
+	}
+	event, ok := jobNotificationEvent(request.State)
+	if !ok {
+		return nil
+	}
+	pipelinePtr := &pps.EtcdPipelineInfo{}
+	if err := pipelines.Get(jobPtr.Pipeline.Name, pipelinePtr); err != nil {
+		return err
+	}
+	if !pipelinePtr.HasNotifications {
+		return nil
+	}
+	// the spec commit must already exist outside of the transaction, so we can retrieve it normally
+	pipelineInfo, err := ppsutil.GetPipelineInfo(txnCtx.Client, jobPtr.Pipeline.Name, pipelinePtr)
+	if err != nil {
+		return err
+	}
+	return addNotifications(a.notifications.ReadWrite(txnCtx.Stm), pipelineInfo, jobPtr.Job, event, request.Reason)
 }
 
 // CreateJob implements the protobuf pps.CreateJob RPC
@@ -666,6 +932,147 @@ func (a *apiServer) InspectJob(ctx context.Context, request *pps.InspectJobReque
 	return jobInfo, nil
 }
 
+// WatchJobStatus implements the protobuf pps.WatchJobStatus RPC
+func (a *apiServer) WatchJobStatus(request *pps.WatchJobStatusRequest, resp pps.API_WatchJobStatusServer) (retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	sent := 0
+	defer func(start time.Time) {
+		a.Log(request, fmt.Sprintf("stream containing %d WorkerStatuses", sent), retErr, time.Since(start))
+	}(time.Now())
+	if request.Job == nil {
+		return errors.Errorf("must specify a job")
+	}
+	pachClient := a.env.GetPachClient(resp.Context())
+	ctx := pachClient.Ctx()
+	jobPtr := &pps.EtcdJobInfo{}
+	if err := a.jobs.ReadOnly(ctx).Get(request.Job.ID, jobPtr); err != nil {
+		return err
+	}
+	jobInfo, err := a.jobInfoFromPtr(pachClient, jobPtr, true)
+	if err != nil {
+		return err
+	}
+	workerPoolID := ppsutil.PipelineRcName(jobInfo.Pipeline.Name, jobInfo.PipelineVersion)
+	workerClients, err := workerserver.Clients(ctx, workerPoolID, a.env.GetEtcdClient(), a.etcdPrefix, a.workerGrpcPort)
+	if err != nil {
+		return err
+	}
+	// Forward the statuses of all workers that are processing this job, or
+	// that are idle (so that clients can tell that a worker finished its part
+	// of the job).
+	var mu sync.Mutex
+	var eg errgroup.Group
+	for _, workerClient := range workerClients {
+		workerClient := workerClient
+		eg.Go(func() error {
+			watchClient, err := workerClient.WatchStatus(ctx, &types.Empty{})
+			if err != nil {
+				logrus.Warnf("error watching worker status: %v", err)
+				return nil
+			}
+			for {
+				status, err := watchClient.Recv()
+				if err != nil {
+					if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+						logrus.Warnf("error watching worker status: %v", err)
+					}
+					return nil
+				}
+				if status.JobID != "" && status.JobID != request.Job.ID {
+					continue
+				}
+				mu.Lock()
+				err = resp.Send(status)
+				sent++
+				mu.Unlock()
+				if err != nil {
+					return err
+				}
+			}
+		})
+	}
+	return eg.Wait()
+}
+
+// DebugDatum implements the protobuf pps.DebugDatum RPC. It finds the worker
+// that is holding the requested datum and proxies the debug session to it.
+func (a *apiServer) DebugDatum(server pps.API_DebugDatumServer) (retErr error) {
+	req, err := server.Recv()
+	if err != nil {
+		return err
+	}
+	func() { a.Log(req, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(req, nil, retErr, time.Since(start)) }(time.Now())
+	if req.Job == nil || req.DatumID == "" {
+		return errors.Errorf("must specify a job and datum")
+	}
+	pachClient := a.env.GetPachClient(server.Context())
+	ctx := pachClient.Ctx()
+	jobPtr := &pps.EtcdJobInfo{}
+	if err := a.jobs.ReadOnly(ctx).Get(req.Job.ID, jobPtr); err != nil {
+		return err
+	}
+	jobInfo, err := a.jobInfoFromPtr(pachClient, jobPtr, true)
+	if err != nil {
+		return err
+	}
+	// The debug shell runs with the pipeline's credentials and can modify its
+	// output, so it requires the same access as updating the pipeline
+	if err := a.authorizePipelineOp(pachClient, pipelineOpUpdate, nil, jobInfo.Pipeline.Name); err != nil {
+		return err
+	}
+	workerPoolID := ppsutil.PipelineRcName(jobInfo.Pipeline.Name, jobInfo.PipelineVersion)
+	workerClients, err := workerserver.Clients(ctx, workerPoolID, a.env.GetEtcdClient(), a.etcdPrefix, a.workerGrpcPort)
+	if err != nil {
+		return err
+	}
+	for _, workerClient := range workerClients {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		workerStream, err := workerClient.DebugDatum(ctx)
+		if err != nil {
+			return err
+		}
+		if err := workerStream.Send(req); err != nil {
+			return err
+		}
+		// workers that aren't holding the datum respond with an error
+		ack, err := workerStream.Recv()
+		if err != nil {
+			cancel()
+			continue
+		}
+		if err := server.Send(ack); err != nil {
+			return err
+		}
+		go func() {
+			for {
+				req, err := server.Recv()
+				if err != nil {
+					workerStream.CloseSend()
+					return
+				}
+				if err := workerStream.Send(req); err != nil {
+					return
+				}
+			}
+		}()
+		for {
+			resp, err := workerStream.Recv()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return err
+			}
+			if err := server.Send(resp); err != nil {
+				return err
+			}
+		}
+	}
+	return errors.Errorf("datum %v of job %v is not being held for debugging", req.DatumID, req.Job.ID)
+}
+
 // listJob is the internal implementation of ListJob shared between ListJob and
 // ListJobStream. When ListJob is removed, this should be inlined into
 // ListJobStream.
@@ -742,11 +1149,18 @@ func (a *apiServer) listJob(pachClient *client.APIClient, pipeline *pps.Pipeline
 		if len(inputCommits) > 0 {
 			found := make([]bool, len(inputCommits))
 			pps.VisitInput(jobInfo.Input, func(in *pps.Input) {
-				if in.Pfs != nil {
-					for i, inputCommit := range inputCommits {
-						if in.Pfs.Commit == inputCommit.ID {
-							found[i] = true
-						}
+				var commit string
+				switch {
+				case in.Pfs != nil:
+					commit = in.Pfs.Commit
+				case in.Window != nil:
+					commit = in.Window.Commit
+				default:
+					return
+				}
+				for i, inputCommit := range inputCommits {
+					if commit == inputCommit.ID {
+						found[i] = true
 					}
 				}
 			})
@@ -800,6 +1214,7 @@ func (a *apiServer) jobInfoFromPtr(pachClient *client.APIClient, jobPtr *pps.Etc
 		Reason:        jobPtr.Reason,
 		Started:       jobPtr.Started,
 		Finished:      jobPtr.Finished,
+		EgressStatus:  jobPtr.EgressStatus,
 	}
 	commitInfo, err := pachClient.InspectCommit(jobPtr.OutputCommit.Repo.Name, jobPtr.OutputCommit.ID)
 	if err != nil {
@@ -853,6 +1268,7 @@ func (a *apiServer) jobInfoFromPtr(pachClient *client.APIClient, jobPtr *pps.Etc
 		result.ChunkSpec = pipelineInfo.ChunkSpec
 		result.DatumTimeout = pipelineInfo.DatumTimeout
 		result.JobTimeout = pipelineInfo.JobTimeout
+		result.TimeoutPolicy = pipelineInfo.TimeoutPolicy
 		result.DatumTries = pipelineInfo.DatumTries
 		result.SchedulingSpec = pipelineInfo.SchedulingSpec
 		result.PodSpec = pipelineInfo.PodSpec
@@ -921,6 +1337,92 @@ func (a *apiServer) FlushJob(request *pps.FlushJobRequest, resp pps.API_FlushJob
 	})
 }
 
+// InspectCommitSet implements the protobuf pps.InspectCommitSet RPC
+func (a *apiServer) InspectCommitSet(ctx context.Context, request *pps.InspectCommitSetRequest) (response *pps.CommitSetInfo, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	pachClient := a.env.GetPachClient(ctx)
+	if request.Commit == nil {
+		return nil, errors.New("must specify a commit")
+	}
+	commitInfo, err := pachClient.InspectCommit(request.Commit.Repo.Name, request.Commit.ID)
+	if err != nil {
+		return nil, err
+	}
+	result := &pps.CommitSetInfo{Commit: commitInfo.Commit}
+	// Walk the subvenance of 'commitInfo' breadth first. Downstream commits may
+	// create more downstream commits when they finish (e.g. due to triggers),
+	// so the subvenance of each visited commit is added as it's visited.
+	visited := make(map[string]bool)
+	var queue []*pfs.Commit
+	addSubvenance := func(ci *pfs.CommitInfo) {
+		for _, subv := range ci.Subvenance {
+			if key := path.Join(subv.Upper.Repo.Name, subv.Upper.ID); !visited[key] {
+				visited[key] = true
+				queue = append(queue, subv.Upper)
+			}
+		}
+	}
+	addSubvenance(commitInfo)
+	for len(queue) > 0 {
+		commit := queue[0]
+		queue = queue[1:]
+		inspect := pachClient.InspectCommit
+		if request.Block {
+			inspect = pachClient.BlockCommit
+		}
+		ci, err := inspect(commit.Repo.Name, commit.ID)
+		if err != nil {
+			if pfsServer.IsCommitNotFoundErr(err) || pfsServer.IsCommitDeletedErr(err) || auth.IsErrNotAuthorized(err) {
+				continue // skip commits that are gone or that we can't access
+			}
+			return nil, err
+		}
+		addSubvenance(ci)
+		var jis []*pps.JobInfo
+		// As in FlushJob, pass -1 for history because we don't know which
+		// version of the pipeline created the output commit.
+		if err := a.listJob(pachClient, nil, ci.Commit, nil, -1, false, "", func(ji *pps.JobInfo) error {
+			jis = append(jis, ji)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		if len(jis) == 0 {
+			continue // e.g. a commit on a stats branch, which isn't a job's output
+		}
+		if len(jis) > 1 {
+			return nil, errors.Errorf("found too many jobs (%d) for output commit: %s/%s", len(jis), ci.Commit.Repo.Name, ci.Commit.ID)
+		}
+		ji := jis[0]
+		if request.Block {
+			// The output commit may be finished before the job is (e.g. while
+			// egressing), so block on the job's state as well.
+			if ji, err = a.InspectJob(ctx, &pps.InspectJobRequest{Job: ji.Job, BlockState: true}); err != nil {
+				return nil, err
+			}
+		}
+		result.Jobs = append(result.Jobs, ji)
+	}
+	result.State = commitSetState(result.Jobs)
+	return result, nil
+}
+
+// commitSetState returns the aggregate state of the jobs in a commit set.
+func commitSetState(jobInfos []*pps.JobInfo) pps.JobState {
+	state := pps.JobState_JOB_SUCCESS
+	for _, ji := range jobInfos {
+		switch ji.State {
+		case pps.JobState_JOB_FAILURE, pps.JobState_JOB_KILLED:
+			return pps.JobState_JOB_FAILURE
+		case pps.JobState_JOB_SUCCESS, pps.JobState_JOB_SKIPPED:
+		default:
+			state = pps.JobState_JOB_RUNNING
+		}
+	}
+	return state
+}
+
 // DeleteJob implements the protobuf pps.DeleteJob RPC
 func (a *apiServer) DeleteJob(ctx context.Context, request *pps.DeleteJobRequest) (response *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
@@ -956,6 +1458,9 @@ func (a *apiServer) stopJob(ctx context.Context, pachClient *client.APIClient, j
 			return err
 		}
 		outputCommit = jobInfo.OutputCommit
+		if err := a.stopUserCode(ctx, jobInfo); err != nil {
+			return err
+		}
 	}
 	commitInfo, err := pachClient.InspectCommit(outputCommit.Repo.Name, outputCommit.ID)
 	if err != nil {
@@ -1003,6 +1508,97 @@ func (a *apiServer) stopJob(ctx context.Context, pachClient *client.APIClient, j
 	return err
 }
 
+// EgressJob implements the protobuf pps.EgressJob RPC. It asks a worker of the
+// job's pipeline, which has the environment that the egress target needs, to
+// egress the job's output commit again, and records the attempt in the job's
+// egress status.
+func (a *apiServer) EgressJob(ctx context.Context, request *pps.EgressJobRequest) (response *pps.EgressStatus, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	pachClient := a.env.GetPachClient(ctx)
+	jobInfo, err := a.InspectJob(ctx, &pps.InspectJobRequest{Job: request.Job, Full: true})
+	if err != nil {
+		return nil, err
+	}
+	if err := a.authorizePipelineOp(pachClient, pipelineOpUpdate, nil, jobInfo.Pipeline.Name); err != nil {
+		return nil, err
+	}
+	if jobInfo.Egress == nil {
+		return nil, errors.Errorf("job %s has no egress", jobInfo.Job.ID)
+	}
+	if jobInfo.EgressStatus == nil || !jobInfo.EgressStatus.Failed {
+		return nil, errors.Errorf("egress of job %s has not failed", jobInfo.Job.ID)
+	}
+	workerPoolID := ppsutil.PipelineRcName(jobInfo.Pipeline.Name, jobInfo.PipelineVersion)
+	workerClients, err := workerserver.Clients(ctx, workerPoolID, a.env.GetEtcdClient(), a.etcdPrefix, a.workerGrpcPort)
+	if err != nil {
+		return nil, err
+	}
+	if len(workerClients) == 0 {
+		return nil, errors.Errorf("pipeline %s has no running workers to egress job %s", jobInfo.Pipeline.Name, jobInfo.Job.ID)
+	}
+	_, egressErr := workerClients[0].Egress(ctx, &workerserver.EgressRequest{
+		Commit: jobInfo.OutputCommit,
+		Egress: jobInfo.Egress,
+	})
+	if err := a.txnEnv.WithWriteContext(ctx, func(txnCtx *txnenv.TransactionContext) error {
+		jobPtr := &pps.EtcdJobInfo{}
+		return a.jobs.ReadWrite(txnCtx.Stm).Update(jobInfo.Job.ID, jobPtr, func() error {
+			if jobPtr.EgressStatus == nil {
+				jobPtr.EgressStatus = &pps.EgressStatus{}
+			}
+			jobPtr.EgressStatus.Attempts++
+			jobPtr.EgressStatus.LastAttempt = types.TimestampNow()
+			if egressErr != nil {
+				jobPtr.EgressStatus.LastError = grpcutil.ScrubGRPC(egressErr).Error()
+			} else {
+				jobPtr.EgressStatus.LastError = ""
+				jobPtr.EgressStatus.Failed = false
+			}
+			response = jobPtr.EgressStatus
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// stopUserCode gives the user code of a running job whose pipeline has a
+// cancel grace period time to shut down, and to upload its partial output to
+// the job's output commit, before stopJob finishes the commit. The commit is
+// finished as empty, like that of any killed job, so downstream pipelines
+// don't process the partial output, but it can still be read from the commit.
+// Partial output that isn't uploaded within maxStopUserCodeWait is lost, as
+// the commit is finished without it.
+func (a *apiServer) stopUserCode(ctx context.Context, jobInfo *pps.JobInfo) error {
+	if ppsutil.IsTerminal(jobInfo.State) {
+		return nil
+	}
+	gracePeriod, err := ppsutil.CancelGracePeriod(jobInfo.Transform)
+	if err != nil {
+		return err
+	}
+	if gracePeriod <= 0 {
+		return nil
+	}
+	// Allow some time for the partial output to be uploaded after the grace
+	// period
+	timeout := 2 * gracePeriod
+	if timeout > maxStopUserCodeWait {
+		timeout = maxStopUserCodeWait
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	workerPoolID := ppsutil.PipelineRcName(jobInfo.Pipeline.Name, jobInfo.PipelineVersion)
+	if err := workerserver.StopJob(ctx, workerPoolID, a.env.GetEtcdClient(), a.etcdPrefix, a.workerGrpcPort, jobInfo.Job.ID); err != nil {
+		// The job is stopped regardless, just without giving its user code
+		// time to shut down
+		logrus.Errorf("error stopping user code of job %s: %v", jobInfo.Job.ID, err)
+	}
+	return nil
+}
+
 // RestartDatum implements the protobuf pps.RestartDatum RPC
 func (a *apiServer) RestartDatum(ctx context.Context, request *pps.RestartDatumRequest) (response *types.Empty, retErr error) {
 	func() { a.Log(request, nil, nil, 0) }()
@@ -1040,11 +1636,85 @@ func (a *apiServer) ListDatum(request *pps.ListDatumRequest, server pps.API_List
 	func() { a.Log(request, nil, nil, 0) }()
 	defer func(start time.Time) { a.Log(request, nil, retErr, time.Since(start)) }(time.Now())
 	// TODO: Auth?
+	if (request.Job == nil) == (request.Input == nil) {
+		return errors.Errorf("exactly one of job or input must be set")
+	}
+	if request.Input != nil {
+		var pipeline string
+		if request.Pipeline != nil {
+			pipeline = request.Pipeline.Name
+		}
+		return a.listDatumInput(server.Context(), pipeline, request.Input, func(meta *datum.Meta) error {
+			di := convertDatumMetaToInfo(meta)
+			di.State = pps.DatumState_STARTING
+			return server.Send(di)
+		})
+	}
 	return a.collectDatums(server.Context(), request.Job, func(meta *datum.Meta, _ *pfs.File) error {
 		return server.Send(convertDatumMetaToInfo(meta))
 	})
 }
 
+// listDatumInput iterates over the datums that the pipeline 'pipeline' with
+// 'input' would create, without creating the pipeline. Each input branch is
+// read at its head commit.
+func (a *apiServer) listDatumInput(ctx context.Context, pipeline string, input *pps.Input, cb func(*datum.Meta) error) error {
+	pachClient := a.env.GetPachClient(ctx)
+	input = proto.Clone(input).(*pps.Input)
+	setInputDefaults(pipeline, input)
+	if err := a.resolveInputCommits(pachClient, input); err != nil {
+		return err
+	}
+	di, err := datum.NewIterator(pachClient, input)
+	if err != nil {
+		return err
+	}
+	return di.Iterate(cb)
+}
+
+// resolveInputCommits sets the commit of each input in 'input' to the head of
+// its branch. Inputs whose branch has no head are left without a commit, so
+// they produce no datums. Cron, SQL and git inputs are read from the repos
+// that their pipeline creates, so they can only be resolved once it exists.
+func (a *apiServer) resolveInputCommits(pachClient *client.APIClient, input *pps.Input) error {
+	var visitErr error
+	pps.VisitInput(input, func(input *pps.Input) {
+		if visitErr != nil {
+			return
+		}
+		resolve := func(repo, branch string) string {
+			commitInfo, err := pachClient.InspectCommit(repo, branch)
+			if err != nil {
+				if !pfsServer.IsNoHeadErr(err) {
+					visitErr = err
+				}
+				return ""
+			}
+			return commitInfo.Commit.ID
+		}
+		resolvePipelineRepo := func(kind, name, repo, branch string) string {
+			commit := resolve(repo, branch)
+			if visitErr != nil && (pfsServer.IsRepoNotFoundErr(visitErr) || pfsServer.IsBranchNotFoundErr(visitErr) || pfsServer.IsCommitNotFoundErr(visitErr)) {
+				visitErr = errors.Errorf("%s input %q reads from repo %q, which doesn't exist yet; it's created with the input's pipeline", kind, name, repo)
+			}
+			return commit
+		}
+		switch {
+		case input.Pfs != nil && input.Pfs.Commit == "":
+			input.Pfs.Commit = resolve(input.Pfs.Repo, input.Pfs.Branch)
+		case input.Window != nil && input.Window.Commit == "":
+			input.Window.Commit = resolve(input.Window.Repo, input.Window.Branch)
+		case input.Cron != nil && input.Cron.Commit == "":
+			input.Cron.Commit = resolvePipelineRepo("cron", input.Cron.Name, input.Cron.Repo, "master")
+		case input.SQL != nil && input.SQL.Commit == "":
+			input.SQL.Commit = resolvePipelineRepo("sql", input.SQL.Name, input.SQL.Repo, "master")
+		case input.Git != nil && input.Git.Commit == "":
+			input.Git.Commit = resolvePipelineRepo("git", input.Git.Name, input.Git.Name, input.Git.Branch)
+		}
+	})
+	return visitErr
+}
+
 func convertDatumMetaToInfo(meta *datum.Meta) *pps.DatumInfo {
 	di := &pps.DatumInfo{
 		Datum: &pps.Datum{
@@ -1056,8 +1726,23 @@ func convertDatumMetaToInfo(meta *datum.Meta) *pps.DatumInfo {
 		State: convertDatumState(meta.State),
 		Stats: meta.Stats,
 	}
+	joinOn := make(map[string]bool)
+	groupBy := make(map[string]bool)
 	for _, input := range meta.Inputs {
 		di.Data = append(di.Data, input.FileInfo)
+		di.Inputs = append(di.Inputs, &pps.DatumInput{
+			Name:       input.Name,
+			Dir:        input.Dir,
+			EmptyFiles: input.EmptyFiles,
+		})
+		if input.JoinOn != "" && !joinOn[input.JoinOn] {
+			joinOn[input.JoinOn] = true
+			di.JoinOn = append(di.JoinOn, input.JoinOn)
+		}
+		if input.GroupBy != "" && !groupBy[input.GroupBy] {
+			groupBy[input.GroupBy] = true
+			di.GroupBy = append(di.GroupBy, input.GroupBy)
+		}
 	}
 	return di
 }
@@ -1098,6 +1783,47 @@ func (a *apiServer) collectDatums(ctx context.Context, job *pps.Job, cb func(*da
 	})
 }
 
+// InspectFileLineage implements the protobuf pps.InspectFileLineage RPC
+func (a *apiServer) InspectFileLineage(ctx context.Context, request *pps.InspectFileLineageRequest) (response *pps.FileLineage, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if request.File == nil || request.File.Commit == nil {
+		return nil, errors.New("must specify a file")
+	}
+	jobInfo, err := a.InspectJob(ctx, &pps.InspectJobRequest{OutputCommit: request.File.Commit})
+	if err != nil {
+		return nil, err
+	}
+	// The lineage lists the input files of the job's datums, so the caller
+	// needs READER on the job's input repos as well as its output repo
+	if err := a.authorizePipelineOp(a.env.GetPachClient(ctx), pipelineOpListDatum, jobInfo.Input, jobInfo.Pipeline.Name); err != nil {
+		return nil, err
+	}
+	response = &pps.FileLineage{
+		File: &pfs.File{Commit: jobInfo.OutputCommit, Path: request.File.Path},
+		Job:  jobInfo.Job,
+	}
+	filePath := path.Join("/", request.File.Path)
+	if err := a.collectDatums(ctx, jobInfo.Job, func(meta *datum.Meta, pfsState *pfs.File) error {
+		for _, output := range meta.Outputs {
+			output = path.Join("/", output)
+			if output == filePath || filePath == "/" || strings.HasPrefix(output, filePath+"/") {
+				di := convertDatumMetaToInfo(meta)
+				di.PfsState = pfsState
+				response.Datums = append(response.Datums, di)
+				return nil
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(response.Datums) == 0 {
+		return nil, errors.Errorf("no datum in job %s wrote %s", jobInfo.Job.ID, filePath)
+	}
+	return response, nil
+}
+
 func (a *apiServer) GetLogs(request *pps.GetLogsRequest, apiGetLogsServer pps.API_GetLogsServer) (retErr error) {
 	pachClient := a.env.GetPachClient(apiGetLogsServer.Context())
 	// Set the default for the `Since` field.
@@ -1165,6 +1891,11 @@ func (a *apiServer) GetLogs(request *pps.GetLogsRequest, apiGetLogsServer pps.AP
 		}
 	}
 
+	filter, err := newLogFilter(request)
+	if err != nil {
+		return err
+	}
+
 	// Get pods managed by the RC we're scraping (either pipeline or pachd)
 	pods, err := a.rcPods(rcName)
 	if err != nil {
@@ -1173,106 +1904,107 @@ func (a *apiServer) GetLogs(request *pps.GetLogsRequest, apiGetLogsServer pps.AP
 	if len(pods) == 0 {
 		return errors.Errorf("no pods belonging to the rc \"%s\" were found", rcName)
 	}
-	// Convert request.From to a usable timestamp.
-	since, err := types.DurationFromProto(request.Since)
-	if err != nil {
-		return errors.Wrapf(err, "invalid from time")
+	if len(request.Workers) > 0 {
+		var selected []v1.Pod
+		for _, pod := range pods {
+			if filter.workers[pod.ObjectMeta.Name] {
+				selected = append(selected, pod)
+			}
+		}
+		if len(selected) == 0 {
+			return errors.Errorf("none of the requested workers belong to the rc \"%s\"", rcName)
+		}
+		pods = selected
+	}
+	sinceSeconds := int64(time.Since(filter.from).Seconds()) + 1
+	if sinceSeconds < 1 {
+		sinceSeconds = 1
 	}
-	sinceSeconds := int64(since.Seconds())
 
-	// Spawn one goroutine per pod. Each goro writes its pod's logs to a channel
-	// and channels are read into the output server in a stable order.
+	// Spawn one goroutine per pod. Each goro writes its pod's logs to its own
+	// channel, and the channels are merged into the output server in timestamp
+	// order (or as logs arrive, if following them).
 	// (sort the pods to make sure that the order of log lines is stable)
 	sort.Sort(podSlice(pods))
-	logCh := make(chan *pps.LogMessage)
-	var eg errgroup.Group
-	var mu sync.Mutex
-	eg.Go(func() error {
-		for _, pod := range pods {
-			pod := pod
-			if !request.Follow {
-				mu.Lock()
+	streams := make([]chan *pps.LogMessage, len(pods))
+	eg, ctx := errgroup.WithContext(ctx)
+	for i, pod := range pods {
+		pod, stream := pod, make(chan *pps.LogMessage)
+		streams[i] = stream
+		eg.Go(func() (retErr error) {
+			defer close(stream)
+			tailLines := &request.Tail
+			if *tailLines <= 0 {
+				tailLines = nil
 			}
-			eg.Go(func() (retErr error) {
-				if !request.Follow {
-					defer mu.Unlock()
-				}
-				tailLines := &request.Tail
-				if *tailLines <= 0 {
-					tailLines = nil
-				}
-				// Get full set of logs from pod i
-				stream, err := a.env.GetKubeClient().CoreV1().Pods(a.namespace).GetLogs(
-					pod.ObjectMeta.Name, &v1.PodLogOptions{
-						Container:    containerName,
-						Follow:       request.Follow,
-						TailLines:    tailLines,
-						SinceSeconds: &sinceSeconds,
-					}).Timeout(10 * time.Second).Stream()
-				if err != nil {
-					return err
+			// Get full set of logs from pod i
+			logs, err := a.env.GetKubeClient().CoreV1().Pods(a.namespace).GetLogs(
+				pod.ObjectMeta.Name, &v1.PodLogOptions{
+					Container:    containerName,
+					Follow:       request.Follow,
+					TailLines:    tailLines,
+					SinceSeconds: &sinceSeconds,
+					// pachd's log lines aren't JSON, so kubernetes timestamps them
+					Timestamps: containerName == "pachd",
+				}).Timeout(10 * time.Second).Stream()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := logs.Close(); err != nil && retErr == nil {
+					retErr = err
 				}
-				defer func() {
-					if err := stream.Close(); err != nil && retErr == nil {
-						retErr = err
-					}
-				}()
-
-				// Parse pods' log lines, and filter out irrelevant ones
-				scanner := bufio.NewScanner(stream)
-				for scanner.Scan() {
-					msg := new(pps.LogMessage)
-					if containerName == "pachd" {
-						msg.Message = scanner.Text()
-					} else {
-						logBytes := scanner.Bytes()
-						if err := jsonpb.Unmarshal(bytes.NewReader(logBytes), msg); err != nil {
-							continue
-						}
-
-						// Filter out log lines that don't match on pipeline or job
-						if request.Pipeline != nil && request.Pipeline.Name != msg.PipelineName {
-							continue
-						}
-						if request.Job != nil && request.Job.ID != msg.JobID {
-							continue
-						}
-						if request.Datum != nil && request.Datum.ID != msg.DatumID {
-							continue
-						}
-						if request.Master != msg.Master {
-							continue
-						}
-						if !common.MatchDatum(request.DataFilters, msg.Data) {
-							continue
+			}()
+
+			// Parse pods' log lines, and filter out irrelevant ones
+			scanner := bufio.NewScanner(logs)
+			for scanner.Scan() {
+				msg := new(pps.LogMessage)
+				if containerName == "pachd" {
+					msg.WorkerID = pod.ObjectMeta.Name
+					msg.Message = scanner.Text()
+					if parts := strings.SplitN(msg.Message, " ", 2); len(parts) == 2 {
+						if ts, err := time.Parse(time.RFC3339Nano, parts[0]); err == nil {
+							msg.Ts, _ = types.TimestampProto(ts)
+							msg.Message = parts[1]
 						}
 					}
-					msg.Message = strings.TrimSuffix(msg.Message, "\n")
+				} else {
+					logBytes := scanner.Bytes()
+					if err := jsonpb.Unmarshal(bytes.NewReader(logBytes), msg); err != nil {
+						continue
+					}
 
-					// Log message passes all filters -- return it
-					select {
-					case logCh <- msg:
-					case <-ctx.Done():
+					// Filter out log lines that don't match on pipeline or job
+					if !filter.matchWorker(msg) {
+						continue
+					}
+				}
+				msg.Message = strings.TrimSuffix(msg.Message, "\n")
+				if msg.Ts != nil {
+					// each pod's logs are in timestamp order
+					if ts, err := types.TimestampFromProto(msg.Ts); err == nil && filter.after(ts) {
 						return nil
 					}
 				}
-				return nil
-			})
-		}
-		return nil
-	})
-	var egErr error
-	go func() {
-		egErr = eg.Wait()
-		close(logCh)
-	}()
+				if !filter.match(msg) {
+					continue
+				}
 
-	for msg := range logCh {
-		if err := apiGetLogsServer.Send(msg); err != nil {
-			return err
-		}
+				// Log message passes all filters -- return it
+				select {
+				case stream <- msg:
+				case <-ctx.Done():
+					return nil
+				}
+			}
+			return nil
+		})
 	}
-	return egErr
+	eg.Go(func() error {
+		return mergeLogs(ctx, streams, !request.Follow, apiGetLogsServer.Send)
+	})
+	return eg.Wait()
 }
 
 func (a *apiServer) getLogsLoki(request *pps.GetLogsRequest, apiGetLogsServer pps.API_GetLogsServer) (retErr error) {
@@ -1287,19 +2019,32 @@ func (a *apiServer) getLogsLoki(request *pps.GetLogsRequest, apiGetLogsServer pp
 	if err != nil {
 		return err
 	}
-	since, err := types.DurationFromProto(request.Since)
+	filter, err := newLogFilter(request)
 	if err != nil {
-		return errors.Wrapf(err, "invalid from time")
+		return err
+	}
+	to := filter.to
+	if to.IsZero() {
+		to = time.Now()
 	}
 	if request.Pipeline == nil && request.Job == nil {
 		if len(request.DataFilters) > 0 || request.Datum != nil {
 			return errors.Errorf("must specify the Job or Pipeline that the datum is from to get logs for it")
 		}
 		// no authorization is done to get logs from master
-		return lokiutil.QueryRange(ctx, loki, `{app="pachd"}`, time.Now().Add(-since), time.Now(), request.Follow, func(t time.Time, line string) error {
-			return apiGetLogsServer.Send(&pps.LogMessage{
+		query := `{app="pachd"}`
+		if filter.message != nil {
+			query += matches(request.MessageRegex)
+		}
+		return lokiutil.QueryRange(ctx, loki, query, filter.from, to, request.Follow, func(t time.Time, line string) error {
+			msg := &pps.LogMessage{
 				Message: strings.TrimSuffix(line, "\n"),
-			})
+			}
+			msg.Ts, _ = types.TimestampProto(t)
+			if !filter.match(msg) {
+				return nil
+			}
+			return apiGetLogsServer.Send(msg)
 		})
 	}
 
@@ -1342,7 +2087,8 @@ func (a *apiServer) getLogsLoki(request *pps.GetLogsRequest, apiGetLogsServer pp
 	for _, filter := range request.DataFilters {
 		query += contains(filter)
 	}
-	return lokiutil.QueryRange(ctx, loki, query, time.Now().Add(-since), time.Now(), request.Follow, func(t time.Time, line string) error {
+	query += filter.lokiFilters()
+	return lokiutil.QueryRange(ctx, loki, query, filter.from, to, request.Follow, func(t time.Time, line string) error {
 		msg := &pps.LogMessage{}
 		// These filters are almost always unnecessary because we apply
 		// them in the Loki request, but many of them are just done with
@@ -1353,22 +2099,10 @@ func (a *apiServer) getLogsLoki(request *pps.GetLogsRequest, apiGetLogsServer pp
 		if err := jsonpb.Unmarshal(strings.NewReader(line), msg); err != nil {
 			return nil
 		}
-		if request.Pipeline != nil && request.Pipeline.Name != msg.PipelineName {
-			return nil
-		}
-		if request.Job != nil && request.Job.ID != msg.JobID {
-			return nil
-		}
-		if request.Datum != nil && request.Datum.ID != msg.DatumID {
-			return nil
-		}
-		if request.Master != msg.Master {
-			return nil
-		}
-		if !common.MatchDatum(request.DataFilters, msg.Data) {
+		msg.Message = strings.TrimSuffix(msg.Message, "\n")
+		if !filter.matchWorker(msg) || !filter.match(msg) {
 			return nil
 		}
-		msg.Message = strings.TrimSuffix(msg.Message, "\n")
 		return apiGetLogsServer.Send(msg)
 	})
 }
@@ -1377,6 +2111,10 @@ func contains(s string) string {
 	return fmt.Sprintf(" |= %q", s)
 }
 
+func matches(regex string) string {
+	return fmt.Sprintf(" |~ %q", regex)
+}
+
 type podSlice []v1.Pod
 
 func (s podSlice) Len() int {
@@ -1469,6 +2207,29 @@ func (a *apiServer) validatePipelineInTransaction(txnCtx *txnenv.TransactionCont
 	if err := a.validateInputInTransaction(txnCtx, pipelineInfo.Pipeline.Name, pipelineInfo.Input); err != nil {
 		return err
 	}
+	if err := validateEgress(pipelineInfo.Egress); err != nil {
+		return errors.Wrapf(err, "invalid egress")
+	}
+	if err := validateNotifications(pipelineInfo.Notifications); err != nil {
+		return errors.Wrapf(err, "invalid notifications")
+	}
+	if err := validateState(pipelineInfo); err != nil {
+		return errors.Wrapf(err, "invalid state")
+	}
+	if err := validatePrefetch(pipelineInfo); err != nil {
+		return errors.Wrapf(err, "invalid prefetch")
+	}
+	if err := validateDebugOnFailure(pipelineInfo); err != nil {
+		return errors.Wrapf(err, "invalid debug_on_failure")
+	}
+	if err := validateNoSidecar(pipelineInfo); err != nil {
+		return errors.Wrapf(err, "invalid no_sidecar")
+	}
+	if pipelineInfo.Transform.DatumBatching {
+		if err := requiresDatums(pipelineInfo, "datum batching"); err != nil {
+			return err
+		}
+	}
 	if pipelineInfo.ParallelismSpec != nil {
 		if pipelineInfo.ParallelismSpec.Coefficient < 0 {
 			return errors.New("ParallelismSpec.Coefficient cannot be negative")
@@ -1537,9 +2298,15 @@ func branchProvenance(input *pps.Input) []*pfs.Branch {
 		if input.Cron != nil {
 			result = append(result, client.NewBranch(input.Cron.Repo, "master"))
 		}
+		if input.SQL != nil {
+			result = append(result, client.NewBranch(input.SQL.Repo, "master"))
+		}
 		if input.Git != nil {
 			result = append(result, client.NewBranch(input.Git.Name, input.Git.Branch))
 		}
+		if input.Window != nil {
+			result = append(result, client.NewBranch(input.Window.Repo, input.Window.Branch))
+		}
 	})
 	return result
 }
@@ -1651,8 +2418,12 @@ func (a *apiServer) fixPipelineInputRepoACLsInTransaction(txnCtx *txnenv.Transac
 				repo = input.Pfs.Repo
 			case input.Cron != nil:
 				repo = input.Cron.Repo
+			case input.SQL != nil:
+				repo = input.SQL.Repo
 			case input.Git != nil:
 				repo = input.Git.Name
+			case input.Window != nil:
+				repo = input.Window.Repo
 			default:
 				return // no scope to set: input is not a repo
 			}
@@ -1680,8 +2451,12 @@ func (a *apiServer) fixPipelineInputRepoACLsInTransaction(txnCtx *txnenv.Transac
 				repo = input.Pfs.Repo
 			case input.Cron != nil:
 				repo = input.Cron.Repo
+			case input.SQL != nil:
+				repo = input.SQL.Repo
 			case input.Git != nil:
 				repo = input.Git.Name
+			case input.Window != nil:
+				repo = input.Window.Repo
 			default:
 				return // no scope to set: input is not a repo
 			}
@@ -1853,6 +2628,7 @@ func (a *apiServer) CreatePipelineInTransaction(txnCtx *txnenv.TransactionContex
 		ChunkSpec:             request.ChunkSpec,
 		DatumTimeout:          request.DatumTimeout,
 		JobTimeout:            request.JobTimeout,
+		TimeoutPolicy:         request.TimeoutPolicy,
 		Standby:               request.Standby,
 		DatumTries:            request.DatumTries,
 		SchedulingSpec:        request.SchedulingSpec,
@@ -1861,6 +2637,11 @@ func (a *apiServer) CreatePipelineInTransaction(txnCtx *txnenv.TransactionContex
 		S3Out:                 request.S3Out,
 		Metadata:              request.Metadata,
 		NoSkip:                request.NoSkip,
+		Notifications:         request.Notifications,
+		StateSpec:             request.State,
+		DebugOnFailure:        request.DebugOnFailure,
+		Prefetch:              request.Prefetch,
+		NoSidecar:             request.NoSidecar,
 	}
 	if err := setPipelineDefaults(pipelineInfo); err != nil {
 		return err
@@ -1881,6 +2662,15 @@ func (a *apiServer) CreatePipelineInTransaction(txnCtx *txnenv.TransactionContex
 				visitErr = err
 			}
 		}
+		if input.SQL != nil {
+			if err := txnCtx.Pfs().CreateRepoInTransaction(txnCtx,
+				&pfs.CreateRepoRequest{
+					Repo:        client.NewRepo(input.SQL.Repo),
+					Description: fmt.Sprintf("SQL input repo for pipeline %s.", request.Pipeline.Name),
+				}); err != nil && !isAlreadyExistsErr(err) {
+				visitErr = err
+			}
+		}
 		if input.Git != nil {
 			if err := txnCtx.Pfs().CreateRepoInTransaction(txnCtx,
 				&pfs.CreateRepoRequest{
@@ -2089,6 +2879,7 @@ func (a *apiServer) CreatePipelineInTransaction(txnCtx *txnenv.TransactionContex
 			pipelinePtr.Reason = ""
 			// Update pipeline parallelism
 			pipelinePtr.Parallelism = uint64(parallelism)
+			pipelinePtr.HasNotifications = hasNotifications(pipelineInfo)
 
 			// Generate new pipeline auth token (added due to & add pipeline to the ACLs of input/output repos
 			if err := func() error {
@@ -2191,9 +2982,10 @@ func (a *apiServer) CreatePipelineInTransaction(txnCtx *txnenv.TransactionContex
 		// pipelinePtr will be written to etcd, pointing at 'commit'. May include an
 		// auth token
 		pipelinePtr := &pps.EtcdPipelineInfo{
-			SpecCommit:  commit,
-			State:       pps.PipelineState_PIPELINE_STARTING,
-			Parallelism: uint64(parallelism),
+			SpecCommit:       commit,
+			State:            pps.PipelineState_PIPELINE_STARTING,
+			Parallelism:      uint64(parallelism),
+			HasNotifications: hasNotifications(pipelineInfo),
 		}
 
 		// Generate pipeline's auth token & add pipeline to the ACLs of input/output
@@ -2319,6 +3111,16 @@ func setPipelineDefaults(pipelineInfo *pps.PipelineInfo) error {
 	if pipelineInfo.Spout != nil && pipelineInfo.Spout.Service != nil && pipelineInfo.Spout.Service.Type == "" {
 		pipelineInfo.Spout.Service.Type = string(v1.ServiceTypeNodePort)
 	}
+	if pipelineInfo.Egress != nil {
+		if db := pipelineInfo.Egress.GetSqlDatabase(); db != nil {
+			if db.Driver == "" {
+				db.Driver = "postgres"
+			}
+			if db.Format == "" {
+				db.Format = sql.CSVFormat
+			}
+		}
+	}
 	return nil
 }
 
@@ -2352,6 +3154,20 @@ func setInputDefaults(pipelineName string, input *pps.Input) {
 				input.Cron.Repo = fmt.Sprintf("%s_%s", pipelineName, input.Cron.Name)
 			}
 		}
+		if input.SQL != nil {
+			if input.SQL.Repo == "" {
+				input.SQL.Repo = fmt.Sprintf("%s_%s", pipelineName, input.SQL.Name)
+			}
+			if input.SQL.Driver == "" {
+				input.SQL.Driver = "postgres"
+			}
+			if input.SQL.DSNSecretKey == "" {
+				input.SQL.DSNSecretKey = "dsn"
+			}
+			if input.SQL.Format == "" {
+				input.SQL.Format = sql.CSVFormat
+			}
+		}
 		if input.Git != nil {
 			if input.Git.Branch == "" {
 				input.Git.Branch = "master"
@@ -2363,6 +3179,14 @@ func setInputDefaults(pipelineName string, input *pps.Input) {
 				input.Git.Name = tokens[0]
 			}
 		}
+		if input.Window != nil {
+			if input.Window.Branch == "" {
+				input.Window.Branch = "master"
+			}
+			if input.Window.Name == "" {
+				input.Window.Name = input.Window.Repo
+			}
+		}
 	})
 }
 
@@ -2460,6 +3284,12 @@ func (a *apiServer) inspectPipelineInTransaction(txnCtx *txnenv.TransactionConte
 		pipelineInfo.WorkersAvailable = int64(len(workerStatus))
 		pipelineInfo.WorkersRequested = int64(pipelinePtr.Parallelism)
 	}
+	if pipelineInfo.Notifications != nil {
+		pipelineInfo.RecentNotifications, err = a.recentNotifications(txnCtx.ClientContext, pipelineInfo.Pipeline)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return pipelineInfo, nil
 }
 
@@ -2485,6 +3315,57 @@ func (a *apiServer) ListPipeline(ctx context.Context, request *pps.ListPipelineR
 	return pipelineInfos, nil
 }
 
+// ListPipelineHistory implements the protobuf pps.ListPipelineHistory RPC
+func (a *apiServer) ListPipelineHistory(ctx context.Context, request *pps.ListPipelineHistoryRequest) (response *pps.PipelineInfos, retErr error) {
+	func() { a.Log(request, nil, nil, 0) }()
+	defer func(start time.Time) { a.Log(request, response, retErr, time.Since(start)) }(time.Now())
+	if request.Pipeline == nil {
+		return nil, errors.New("must specify a pipeline")
+	}
+	pachClient := a.env.GetPachClient(ctx)
+	pipelineInfos := &pps.PipelineInfos{}
+	// Each version's spec is read from its own spec commit, by walking back
+	// through the parents of the current spec commit
+	if err := a.listPipeline(pachClient, &pps.ListPipelineRequest{
+		Pipeline:        request.Pipeline,
+		History:         -1,
+		AllowIncomplete: true,
+	}, func(pi *pps.PipelineInfo) error {
+		pipelineInfos.PipelineInfo = append(pipelineInfos.PipelineInfo, pi)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if request.Version != 0 {
+		return selectPipelineVersion(request.Pipeline.Name, pipelineInfos.PipelineInfo, request.Version)
+	}
+	// listPipeline resolves versions concurrently, so they may be out of order
+	sort.Slice(pipelineInfos.PipelineInfo, func(i, j int) bool {
+		return pipelineInfos.PipelineInfo[i].Version > pipelineInfos.PipelineInfo[j].Version
+	})
+	return pipelineInfos, nil
+}
+
+// selectPipelineVersion returns the complete spec of 'version' from 'history'.
+// Incomplete PipelineInfos carry no version, so if the version isn't found
+// and some specs couldn't be read, it may be one of them.
+func selectPipelineVersion(pipeline string, history []*pps.PipelineInfo, version uint64) (*pps.PipelineInfos, error) {
+	var incomplete bool
+	for _, pipelineInfo := range history {
+		if pipelineInfo.Transform == nil {
+			incomplete = true
+			continue
+		}
+		if pipelineInfo.Version == version {
+			return &pps.PipelineInfos{PipelineInfo: []*pps.PipelineInfo{pipelineInfo}}, nil
+		}
+	}
+	if incomplete {
+		return nil, errors.Errorf("could not find a complete spec for version %d of pipeline %s, some of its specs could not be read from PFS", version, pipeline)
+	}
+	return nil, errors.Errorf("pipeline %s has no version %d", pipeline, version)
+}
+
 func (a *apiServer) listPipeline(pachClient *client.APIClient, request *pps.ListPipelineRequest, f func(*pps.PipelineInfo) error) error {
 	var jqCode *gojq.Code
 	var enc serde.Encoder
@@ -2782,7 +3663,7 @@ func (a *apiServer) deletePipeline(pachClient *client.APIClient, request *pps.De
 			return grpcutil.ScrubGRPC(superUserClient.DeleteBranch(ppsconsts.SpecRepo, request.Pipeline.Name, request.Force))
 		})
 	})
-	// Delete cron input repos
+	// Delete cron and sql input repos
 	if !request.KeepRepo {
 		pps.VisitInput(pipelineInfo.Input, func(input *pps.Input) {
 			if input.Cron != nil {
@@ -2790,8 +3671,17 @@ func (a *apiServer) deletePipeline(pachClient *client.APIClient, request *pps.De
 					return pachClient.DeleteRepo(input.Cron.Repo, request.Force)
 				})
 			}
+			if input.SQL != nil {
+				eg.Go(func() error {
+					return pachClient.DeleteRepo(input.SQL.Repo, request.Force)
+				})
+			}
 		})
 	}
+	// Delete the pipeline's notifications
+	eg.Go(func() error {
+		return a.deleteNotifications(ctx, request.Pipeline)
+	})
 	// Delete EtcdPipelineInfo
 	eg.Go(func() error {
 		if _, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
@@ -3073,19 +3963,33 @@ func (a *apiServer) RunCron(ctx context.Context, request *pps.RunCronRequest) (r
 	}
 
 	if pipelineInfo.Input == nil {
-		return nil, errors.Errorf("pipeline must have a cron input")
+		return nil, errors.Errorf("pipeline must have a cron or sql input")
 	}
 
-	// find any cron inputs
+	// find any cron and sql inputs
 	var crons []*pps.CronInput
+	var sqlInputs []*pps.SQLInput
 	pps.VisitInput(pipelineInfo.Input, func(in *pps.Input) {
 		if in.Cron != nil {
 			crons = append(crons, in.Cron)
 		}
+		if in.SQL != nil {
+			sqlInputs = append(sqlInputs, in.SQL)
+		}
 	})
 
+	if len(crons) < 1 && len(sqlInputs) < 1 {
+		return nil, errors.Errorf("pipeline must have a cron or sql input")
+	}
+
+	// run each sql input's query, each of which is written as its own commit
+	for _, in := range sqlInputs {
+		if err := writeSQLCommit(pachClient, a.env.GetKubeClient(), a.namespace, in); err != nil {
+			return nil, err
+		}
+	}
 	if len(crons) < 1 {
-		return nil, errors.Errorf("pipeline must have a cron input")
+		return &types.Empty{}, nil
 	}
 
 	txn, err := pachClient.StartTransaction()
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+)
+
+func TestSelectPipelineVersion(t *testing.T) {
+	history := []*pps.PipelineInfo{
+		{Version: 1, Transform: &pps.Transform{Cmd: []string{"v1"}}},
+		{Version: 2, Transform: &pps.Transform{Cmd: []string{"v2"}}},
+	}
+	pipelineInfos, err := selectPipelineVersion("pipeline", history, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(pipelineInfos.PipelineInfo))
+	require.Equal(t, "v1", pipelineInfos.PipelineInfo[0].Transform.Cmd[0])
+	_, err = selectPipelineVersion("pipeline", history, 3)
+	require.YesError(t, err)
+	require.Matches(t, "has no version 3", err.Error())
+
+	// A version whose spec couldn't be read is never returned
+	history = append(history, &pps.PipelineInfo{})
+	_, err = selectPipelineVersion("pipeline", history, 3)
+	require.YesError(t, err)
+	require.Matches(t, "could not be read", err.Error())
+}
+
+func TestValidateTransformSecrets(t *testing.T) {
+	transform := func(external string) *pps.Transform {
+		return &pps.Transform{
+			Image:   "image",
+			Secrets: []*pps.SecretMount{{External: external, EnvVar: "SECRET"}},
+		}
+	}
+	require.NoError(t, validateTransform(transform("vault://secret/data/db#password")))
+	require.NoError(t, validateTransform(transform("file:///var/run/creds/token")))
+	require.NoError(t, validateTransform(transform("file:///var/run/creds.json#token")))
+
+	require.YesError(t, validateTransform(transform("vault://secret/data/db")))
+	require.YesError(t, validateTransform(transform("vault:///secret/data/db#password")))
+	require.YesError(t, validateTransform(transform("file://var/run/creds/token")))
+	require.YesError(t, validateTransform(transform("valut://secret/data/db#password")))
+	require.YesError(t, validateTransform(transform("secret/data/db#password")))
+}
+
+func TestRequiresDatums(t *testing.T) {
+	require.NoError(t, requiresDatums(&pps.PipelineInfo{}, "prefetch"))
+	err := requiresDatums(&pps.PipelineInfo{Spout: &pps.Spout{}}, "prefetch")
+	require.YesError(t, err)
+	require.Equal(t, "prefetch is not supported for spouts or services, as they do not process datums", err.Error())
+	require.YesError(t, requiresDatums(&pps.PipelineInfo{Service: &pps.Service{}}, "state"))
+
+	// the validators of datum-only features reject spouts
+	spout := &pps.PipelineInfo{Spout: &pps.Spout{}}
+	spout.Prefetch = &pps.PrefetchSpec{Datums: 1}
+	require.YesError(t, validatePrefetch(spout))
+	spout.DebugOnFailure = &pps.DebugOnFailure{Enabled: true}
+	require.YesError(t, validateDebugOnFailure(spout))
+}
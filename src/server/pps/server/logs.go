@@ -0,0 +1,219 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+	"github.com/pachyderm/pachyderm/v2/src/server/worker/common"
+)
+
+// logFilter selects the log messages requested by a GetLogsRequest
+type logFilter struct {
+	request  *pps.GetLogsRequest
+	from, to time.Time // 'to' is zero if unset
+	levels   map[pps.LogLevel]bool
+	message  *regexp.Regexp
+	workers  map[string]bool
+}
+
+func newLogFilter(request *pps.GetLogsRequest) (*logFilter, error) {
+	since, err := types.DurationFromProto(request.Since)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid since")
+	}
+	f := &logFilter{
+		request: request,
+		from:    time.Now().Add(-since),
+	}
+	if request.From != nil {
+		if f.from, err = types.TimestampFromProto(request.From); err != nil {
+			return nil, errors.Wrapf(err, "invalid from")
+		}
+	}
+	if request.To != nil {
+		if request.Follow {
+			return nil, errors.Errorf("cannot follow logs with an end time")
+		}
+		if f.to, err = types.TimestampFromProto(request.To); err != nil {
+			return nil, errors.Wrapf(err, "invalid to")
+		}
+	}
+	if len(request.Levels) > 0 {
+		f.levels = make(map[pps.LogLevel]bool)
+		for _, level := range request.Levels {
+			f.levels[level] = true
+		}
+	}
+	if request.MessageRegex != "" {
+		if f.message, err = regexp.Compile(request.MessageRegex); err != nil {
+			return nil, errors.Wrapf(err, "invalid message_regex")
+		}
+	}
+	if len(request.Workers) > 0 {
+		f.workers = make(map[string]bool)
+		for _, worker := range request.Workers {
+			f.workers[worker] = true
+		}
+	}
+	return f, nil
+}
+
+// matchWorker reports whether 'msg', a log message from a worker, is from the
+// requested pipeline, job, datum and process.
+func (f *logFilter) matchWorker(msg *pps.LogMessage) bool {
+	request := f.request
+	if request.Pipeline != nil && request.Pipeline.Name != msg.PipelineName {
+		return false
+	}
+	if request.Job != nil && request.Job.ID != msg.JobID {
+		return false
+	}
+	if request.Datum != nil && request.Datum.ID != msg.DatumID {
+		return false
+	}
+	if request.Master != msg.Master {
+		return false
+	}
+	return common.MatchDatum(request.DataFilters, msg.Data)
+}
+
+// match reports whether 'msg' is in the requested time range, and has a
+// requested level, message and worker.
+func (f *logFilter) match(msg *pps.LogMessage) bool {
+	if msg.Ts != nil {
+		ts, err := types.TimestampFromProto(msg.Ts)
+		if err == nil && (ts.Before(f.from) || f.after(ts)) {
+			return false
+		}
+	}
+	if f.levels != nil && !f.levels[msg.Level] {
+		return false
+	}
+	if f.message != nil && !f.message.MatchString(msg.Message) {
+		return false
+	}
+	return f.workers == nil || f.workers[msg.WorkerID]
+}
+
+// after reports whether 'ts' is after the requested time range.
+func (f *logFilter) after(ts time.Time) bool {
+	return !f.to.IsZero() && ts.After(f.to)
+}
+
+// lokiFilters returns LogQL line filters that select the log lines that may
+// match 'f'. Loki matches the raw JSON of each line, so the filters can have
+// false positives, and messages must still be checked with 'match'.
+func (f *logFilter) lokiFilters() string {
+	var query string
+	if f.levels != nil && !f.levels[pps.LogLevel_LOG_INFO] {
+		// LOG_INFO is the default value, so it's omitted from the JSON
+		var levels []string
+		for level := range f.levels {
+			levels = append(levels, level.String())
+		}
+		sort.Strings(levels)
+		query += matches(fmt.Sprintf(`"level":"(%s)"`, strings.Join(levels, "|")))
+	}
+	if f.workers != nil {
+		var workers []string
+		for worker := range f.workers {
+			workers = append(workers, regexp.QuoteMeta(worker))
+		}
+		sort.Strings(workers)
+		query += matches(fmt.Sprintf(`"workerId":"(%s)"`, strings.Join(workers, "|")))
+	}
+	// Anchors, quotes and backslashes match differently in the JSON line than
+	// in the message, so such regexes are only checked by 'match'.
+	if f.message != nil && !strings.ContainsAny(f.request.MessageRegex, `^$"\`) {
+		query += matches(f.request.MessageRegex)
+	}
+	return query
+}
+
+// mergeLogs sends the messages from 'streams' to 'send' until every stream is
+// closed. If 'ordered' is true, each stream must be in timestamp order, and
+// the messages are sent in timestamp order, which waits for every stream to
+// have a message ready. Otherwise messages are sent as they arrive.
+func mergeLogs(ctx context.Context, streams []chan *pps.LogMessage, ordered bool, send func(*pps.LogMessage) error) error {
+	if !ordered {
+		merged := make(chan *pps.LogMessage)
+		var wg sync.WaitGroup
+		for _, stream := range streams {
+			stream := stream
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range stream {
+					select {
+					case merged <- msg:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(merged)
+		}()
+		for msg := range merged {
+			if err := send(msg); err != nil {
+				return err
+			}
+		}
+		return ctx.Err()
+	}
+	// heads[i] is the next message from streams[i], or nil if it's closed
+	heads := make([]*pps.LogMessage, len(streams))
+	next := func(i int) error {
+		select {
+		case heads[i] = <-streams[i]:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	for i := range streams {
+		if err := next(i); err != nil {
+			return err
+		}
+	}
+	for {
+		first := -1
+		for i, head := range heads {
+			if head != nil && (first < 0 || logBefore(head, heads[first])) {
+				first = i
+			}
+		}
+		if first < 0 {
+			return nil
+		}
+		if err := send(heads[first]); err != nil {
+			return err
+		}
+		if err := next(first); err != nil {
+			return err
+		}
+	}
+}
+
+// logBefore reports whether 'a' was logged before 'b'. Messages without a
+// timestamp are treated as the oldest.
+func logBefore(a, b *pps.LogMessage) bool {
+	if a.Ts == nil || b.Ts == nil {
+		return a.Ts == nil && b.Ts != nil
+	}
+	if a.Ts.Seconds != b.Ts.Seconds {
+		return a.Ts.Seconds < b.Ts.Seconds
+	}
+	return a.Ts.Nanos < b.Ts.Nanos
+}
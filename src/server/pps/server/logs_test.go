@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+)
+
+func logAt(t *testing.T, ts time.Time, message string) *pps.LogMessage {
+	tsProto, err := types.TimestampProto(ts)
+	require.NoError(t, err)
+	return &pps.LogMessage{Ts: tsProto, Message: message, WorkerID: "pod-1"}
+}
+
+func TestLogFilter(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	from, err := types.TimestampProto(start)
+	require.NoError(t, err)
+	to, err := types.TimestampProto(start.Add(time.Minute))
+	require.NoError(t, err)
+	filter, err := newLogFilter(&pps.GetLogsRequest{
+		Since:        types.DurationProto(time.Minute),
+		From:         from,
+		To:           to,
+		Levels:       []pps.LogLevel{pps.LogLevel_LOG_ERROR},
+		MessageRegex: "^fail(ed)?$",
+		Workers:      []string{"pod-1"},
+	})
+	require.NoError(t, err)
+
+	msg := logAt(t, start.Add(time.Second), "failed")
+	msg.Level = pps.LogLevel_LOG_ERROR
+	require.True(t, filter.match(msg))
+	msg.Level = pps.LogLevel_LOG_INFO
+	require.False(t, filter.match(msg))
+
+	for _, msg := range []*pps.LogMessage{
+		logAt(t, start.Add(-time.Second), "failed"),
+		logAt(t, start.Add(2*time.Minute), "failed"),
+		logAt(t, start.Add(time.Second), "it failed"),
+	} {
+		msg.Level = pps.LogLevel_LOG_ERROR
+		require.False(t, filter.match(msg))
+	}
+	msg = logAt(t, start.Add(time.Second), "fail")
+	msg.Level = pps.LogLevel_LOG_ERROR
+	msg.WorkerID = "pod-2"
+	require.False(t, filter.match(msg))
+
+	_, err = newLogFilter(&pps.GetLogsRequest{Since: types.DurationProto(time.Minute), To: to, Follow: true})
+	require.YesError(t, err)
+	_, err = newLogFilter(&pps.GetLogsRequest{Since: types.DurationProto(time.Minute), MessageRegex: "("})
+	require.YesError(t, err)
+}
+
+func TestLokiFilters(t *testing.T) {
+	filter, err := newLogFilter(&pps.GetLogsRequest{
+		Since:        types.DurationProto(time.Minute),
+		Levels:       []pps.LogLevel{pps.LogLevel_LOG_ERROR},
+		MessageRegex: "oom|killed",
+		Workers:      []string{"pod.1", "pod.2"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, ` |~ "\"level\":\"(LOG_ERROR)\""`+
+		` |~ "\"workerId\":\"(pod\\.1|pod\\.2)\""`+
+		` |~ "oom|killed"`, filter.lokiFilters())
+
+	// levels that include the default can't be selected from the JSON, and
+	// anchored regexes don't match the JSON line
+	filter, err = newLogFilter(&pps.GetLogsRequest{
+		Since:        types.DurationProto(time.Minute),
+		Levels:       []pps.LogLevel{pps.LogLevel_LOG_INFO, pps.LogLevel_LOG_ERROR},
+		MessageRegex: "^done$",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "", filter.lokiFilters())
+}
+
+func TestMergeLogs(t *testing.T) {
+	start := time.Now()
+	streams := []chan *pps.LogMessage{make(chan *pps.LogMessage), make(chan *pps.LogMessage)}
+	for i, offsets := range [][]int{{0, 3, 4}, {1, 2, 5}} {
+		stream, offsets := streams[i], offsets
+		go func() {
+			defer close(stream)
+			for _, offset := range offsets {
+				stream <- logAt(t, start.Add(time.Duration(offset)*time.Second), string(rune('0'+offset)))
+			}
+		}()
+	}
+	var messages string
+	require.NoError(t, mergeLogs(context.Background(), streams, true, func(msg *pps.LogMessage) error {
+		messages += msg.Message
+		return nil
+	}))
+	require.Equal(t, "012345", messages)
+}
@@ -93,6 +93,13 @@ type ppsMaster struct {
 	pollCancel      func() // protected by pollPipelinesMu
 	pollPodsCancel  func() // protected by pollPipelinesMu
 	pollEtcdCancel  func() // protected by pollPipelinesMu
+	notifyCancel    func() // protected by pollPipelinesMu
+	schedCancel     func() // protected by pollPipelinesMu
+
+	// allocations maps each pipeline to the number of workers that the
+	// scheduler allows it to run (see allocateWorkers)
+	schedMu     sync.Mutex
+	allocations map[string]int // protected by schedMu
 
 	// channel through which pipeline events are passed
 	eventCh chan *pipelineEvent
@@ -133,8 +140,43 @@ func (a *apiServer) setPipelineFailure(ctx context.Context, pipelineName string,
 	return a.setPipelineState(ctx, pipelineName, pps.PipelineState_PIPELINE_FAILURE, reason)
 }
 
+// setPipelineCrashing moves 'pipelineName' to CRASHING. If the pipeline wasn't
+// already crashing, its webhooks are notified. The PPS master's pod poller
+// calls this for every failing pod event, so the notification is sent here,
+// once per transition, rather than each time the pipeline is processed.
 func (a *apiServer) setPipelineCrashing(ctx context.Context, pipelineName string, reason string) error {
-	return a.setPipelineState(ctx, pipelineName, pps.PipelineState_PIPELINE_CRASHING, reason)
+	pipelinePtr := &pps.EtcdPipelineInfo{}
+	if err := a.pipelines.ReadOnly(ctx).Get(pipelineName, pipelinePtr); err != nil {
+		return err
+	}
+	prevState := pipelinePtr.State
+	if err := a.setPipelineState(ctx, pipelineName, pps.PipelineState_PIPELINE_CRASHING, reason); err != nil {
+		return err
+	}
+	if prevState == pps.PipelineState_PIPELINE_CRASHING || !pipelinePtr.HasNotifications {
+		return nil
+	}
+	// setPipelineState ignores transitions out of FAILURE and STANDBY, so
+	// check that the pipeline is crashing now
+	if err := a.pipelines.ReadOnly(ctx).Get(pipelineName, pipelinePtr); err != nil {
+		return err
+	}
+	if pipelinePtr.State != pps.PipelineState_PIPELINE_CRASHING {
+		return nil
+	}
+	var pipelineInfo *pps.PipelineInfo
+	if err := a.sudo(a.env.GetPachClient(ctx), func(superUserClient *client.APIClient) error {
+		var err error
+		pipelineInfo, err = ppsutil.GetPipelineInfo(superUserClient, pipelineName, pipelinePtr)
+		return err
+	}); err != nil {
+		log.Errorf("PPS master: could not read spec to notify webhooks that pipeline %q is crashing: %v", pipelineName, err)
+		return nil
+	}
+	if err := a.notifyPipeline(ctx, pipelineInfo, pps.NotificationEvent_NOTIFY_PIPELINE_CRASHING, reason); err != nil {
+		log.Errorf("PPS master: could not notify webhooks that pipeline %q is crashing: %v", pipelineName, err)
+	}
+	return nil
 }
 
 func (m *ppsMaster) run() {
@@ -151,6 +193,10 @@ func (m *ppsMaster) run() {
 	defer m.cancelPipelinePodsPoller()
 	m.startPipelineEtcdPoller()
 	defer m.cancelPipelineEtcdPoller()
+	m.startNotifier()
+	defer m.cancelNotifier()
+	m.startScheduler()
+	defer m.cancelScheduler()
 
 	masterCtx := m.masterClient.Ctx()
 eventLoop:
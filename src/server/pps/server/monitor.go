@@ -57,7 +57,7 @@ const crashingBackoff = time.Second * 15
 // startMonitor starts a new goroutine running monitorPipeline for
 // 'pipelineInfo.Pipeline'.
 //
-// Every running pipeline with standby == true or a cron input has a
+// Every running pipeline with standby == true or a cron or sql input has a
 // corresponding goroutine running monitorPipeline() that puts the pipeline in
 // and out of standby in response to new output commits appearing in that
 // pipeline's output repo.
@@ -187,6 +187,14 @@ func (m *ppsMaster) monitorPipeline(pachClient *client.APIClient, pipelineInfo *
 					backoff.NotifyCtx(pachClient.Ctx(), "cron for "+in.Cron.Name))
 			})
 		}
+		if in.SQL != nil {
+			eg.Go(func() error {
+				return backoff.RetryNotify(func() error {
+					return makeSQLCommits(pachClient, m.a.env.GetKubeClient(), m.a.namespace, in.SQL)
+				}, backoff.NewInfiniteBackOff(),
+					backoff.NotifyCtx(pachClient.Ctx(), "sql for "+in.SQL.Name))
+			})
+		}
 	})
 	if pipelineInfo.Standby {
 		// Capacity 1 gives us a bit of buffer so we don't needlessly go into
@@ -357,6 +365,10 @@ func (m *ppsMaster) monitorCrashingPipeline(pachClient *client.APIClient, parall
 				pps.PipelineState_PIPELINE_RUNNING, ""); err != nil {
 				return errors.Wrap(err, "could not transition pipeline to RUNNING")
 			}
+			if err := m.a.notifyPipeline(ctx, pipelineInfo,
+				pps.NotificationEvent_NOTIFY_PIPELINE_RECOVERED, ""); err != nil {
+				log.Errorf("PPS master: could not notify webhooks that pipeline %q recovered: %v", pipeline, err)
+			}
 			cancelInner() // done--pipeline is out of CRASHING
 		}
 		return nil // loop again to check for new workers
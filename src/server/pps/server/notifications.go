@@ -0,0 +1,485 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/backoff"
+	col "github.com/pachyderm/pachyderm/v2/src/internal/collection"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/ppsdb"
+	"github.com/pachyderm/pachyderm/v2/src/internal/uuid"
+	"github.com/pachyderm/pachyderm/v2/src/internal/watch"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+)
+
+const (
+	// defaultNotificationAttempts is the number of times a notification is
+	// sent before it's marked failed, if the webhook doesn't set max_attempts
+	defaultNotificationAttempts = 10
+
+	// maxRecentNotifications is the number of delivered or failed
+	// notifications that are kept for each pipeline (and returned by
+	// InspectPipeline)
+	maxRecentNotifications = 10
+
+	notificationTimeout = 30 * time.Second
+)
+
+// internalNetworks are the networks that webhooks may not be sent to, as they
+// hold the cluster's own services (and the cloud provider's metadata service)
+// rather than external endpoints.
+var internalNetworks = func() []*net.IPNet {
+	var result []*net.IPNet
+	for _, cidr := range []string{
+		"0.0.0.0/8",      // "this" network
+		"10.0.0.0/8",     // private
+		"100.64.0.0/10",  // carrier-grade NAT
+		"127.0.0.0/8",    // loopback
+		"169.254.0.0/16", // link-local
+		"172.16.0.0/12",  // private
+		"192.168.0.0/16", // private
+		"::/128",         // unspecified
+		"::1/128",        // loopback
+		"fc00::/7",       // unique local
+		"fe80::/10",      // link-local
+	} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		result = append(result, network)
+	}
+	return result
+}()
+
+// webhookClient is the HTTP client that notifications are sent with. It
+// refuses to connect to internal addresses (see checkWebhookAddress), which
+// is checked when connecting rather than only when the pipeline is created,
+// as webhook hostnames may resolve to different addresses over time. It
+// doesn't use a proxy, which would hide the address being connected to.
+var webhookClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   notificationTimeout,
+			KeepAlive: notificationTimeout,
+			Control:   checkWebhookAddress,
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: notificationTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	},
+}
+
+// isInternalIP returns true if webhooks may not be sent to 'ip'
+func isInternalIP(ip net.IP) bool {
+	if ip.IsUnspecified() || ip.IsLoopback() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return true
+	}
+	for _, network := range internalNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWebhookAddress is a net.Dialer Control function that rejects
+// connections to internal addresses.
+func checkWebhookAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return errors.Errorf("could not parse webhook address %q", address)
+	}
+	if isInternalIP(ip) {
+		return errors.Errorf("webhook address %s is internal to the cluster's network", ip)
+	}
+	return nil
+}
+
+// validateWebhookHost rejects webhook hosts that are internal to the cluster
+// (e.g. kubernetes service names) up front. Hostnames that resolve to
+// internal addresses are rejected when the notification is sent.
+func validateWebhookHost(host string) error {
+	if host == "" {
+		return errors.New("webhook url must have a host")
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if isInternalIP(ip) {
+			return errors.Errorf("webhook host %s is internal to the cluster's network", host)
+		}
+		return nil
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || !strings.Contains(host, ".") ||
+		strings.HasSuffix(host, ".localhost") || strings.HasSuffix(host, ".local") ||
+		strings.HasSuffix(host, ".svc") || strings.HasSuffix(host, ".internal") {
+		return errors.Errorf("webhook host %q is internal to the cluster's network", host)
+	}
+	return nil
+}
+
+func validateNotifications(notifications *pps.Notifications) error {
+	if notifications == nil {
+		return nil
+	}
+	for _, webhook := range notifications.Webhooks {
+		u, err := url.Parse(webhook.URL)
+		if err != nil {
+			return errors.Wrapf(err, "could not parse webhook url %q", webhook.URL)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return errors.Errorf("webhook url %q must be http or https", webhook.URL)
+		}
+		if err := validateWebhookHost(u.Hostname()); err != nil {
+			return err
+		}
+		if webhook.MaxAttempts < 0 {
+			return errors.Errorf("max_attempts must not be negative")
+		}
+		if _, err := template.New("payload").Parse(webhook.Payload); err != nil {
+			return errors.Wrapf(err, "could not parse payload template for webhook %q", webhook.URL)
+		}
+	}
+	return nil
+}
+
+// hasNotifications returns true if 'pipelineInfo' has webhooks to notify
+func hasNotifications(pipelineInfo *pps.PipelineInfo) bool {
+	return pipelineInfo.Notifications != nil && len(pipelineInfo.Notifications.Webhooks) > 0
+}
+
+// jobNotificationEvent returns the notification event for a job entering
+// 'state', or false if jobs entering 'state' aren't notified.
+func jobNotificationEvent(state pps.JobState) (pps.NotificationEvent, bool) {
+	switch state {
+	case pps.JobState_JOB_FAILURE:
+		return pps.NotificationEvent_NOTIFY_JOB_FAILURE, true
+	case pps.JobState_JOB_SUCCESS:
+		return pps.NotificationEvent_NOTIFY_JOB_SUCCESS, true
+	case pps.JobState_JOB_KILLED:
+		return pps.NotificationEvent_NOTIFY_JOB_KILLED, true
+	default:
+		return 0, false
+	}
+}
+
+func webhookWantsEvent(webhook *pps.Webhook, event pps.NotificationEvent) bool {
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, e := range webhook.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// addNotifications writes a pending notification to 'notifications' for each
+// of 'pipelineInfo's webhooks that's interested in 'event'. The PPS master
+// delivers them (see deliverNotifications).
+func addNotifications(notifications col.ReadWriteCollection, pipelineInfo *pps.PipelineInfo, job *pps.Job, event pps.NotificationEvent, reason string) error {
+	if pipelineInfo.Notifications == nil {
+		return nil
+	}
+	for _, webhook := range pipelineInfo.Notifications.Webhooks {
+		if !webhookWantsEvent(webhook, event) {
+			continue
+		}
+		id := uuid.NewWithoutDashes()
+		if err := notifications.Put(id, &pps.NotificationInfo{
+			ID:       id,
+			Pipeline: pipelineInfo.Pipeline,
+			Job:      job,
+			Event:    event,
+			Reason:   reason,
+			Created:  types.TimestampNow(),
+			Webhook:  webhook,
+			State:    pps.NotificationState_NOTIFICATION_PENDING,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyPipeline sends 'event' to 'pipelineInfo's webhooks. It's used by the
+// PPS master for pipeline state transitions, which (unlike job state
+// transitions) aren't made in a transaction.
+func (a *apiServer) notifyPipeline(ctx context.Context, pipelineInfo *pps.PipelineInfo, event pps.NotificationEvent, reason string) error {
+	if pipelineInfo.Notifications == nil {
+		return nil
+	}
+	_, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		return addNotifications(a.notifications.ReadWrite(stm), pipelineInfo, nil, event, reason)
+	})
+	return err
+}
+
+// recentNotifications returns the notifications for 'pipeline', oldest first
+func (a *apiServer) recentNotifications(ctx context.Context, pipeline *pps.Pipeline) ([]*pps.NotificationInfo, error) {
+	var result []*pps.NotificationInfo
+	notificationInfo := &pps.NotificationInfo{}
+	if err := a.notifications.ReadOnly(ctx).GetByIndex(ppsdb.NotificationsPipelineIndex, pipeline, notificationInfo, col.DefaultOptions, func(string) error {
+		result = append(result, proto.Clone(notificationInfo).(*pps.NotificationInfo))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Created.Compare(result[j].Created) < 0
+	})
+	return result, nil
+}
+
+// deleteNotifications deletes all of 'pipeline's notifications
+func (a *apiServer) deleteNotifications(ctx context.Context, pipeline *pps.Pipeline) error {
+	notificationInfos, err := a.recentNotifications(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	_, err = col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		notifications := a.notifications.ReadWrite(stm)
+		for _, notificationInfo := range notificationInfos {
+			if err := notifications.Delete(notificationInfo.ID); err != nil && !col.IsErrNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// startNotifier starts a new goroutine running deliverNotifications
+func (m *ppsMaster) startNotifier() {
+	m.pollPipelinesMu.Lock()
+	defer m.pollPipelinesMu.Unlock()
+	m.notifyCancel = m.startMonitorThread("deliverNotifications", m.deliverNotifications)
+}
+
+func (m *ppsMaster) cancelNotifier() {
+	m.pollPipelinesMu.Lock()
+	defer m.pollPipelinesMu.Unlock()
+	if m.notifyCancel != nil {
+		m.notifyCancel()
+		m.notifyCancel = nil
+	}
+}
+
+// deliverNotifications watches the notifications collection and delivers each
+// pending notification to its webhook, retrying with backoff until it
+// succeeds or the webhook's max_attempts is reached.
+func (m *ppsMaster) deliverNotifications(pachClient *client.APIClient) {
+	ctx := pachClient.Ctx()
+	var inFlightMu sync.Mutex
+	inFlight := make(map[string]bool)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	if err := backoff.RetryUntilCancel(ctx, backoff.MustLoop(func() error {
+		watcher, err := m.a.notifications.ReadOnly(ctx).Watch()
+		if err != nil {
+			return errors.Wrapf(err, "error creating watch")
+		}
+		defer watcher.Close()
+		for event := range watcher.Watch() {
+			if event.Err != nil {
+				return errors.Wrapf(event.Err, "event err")
+			}
+			if event.Type != watch.EventPut {
+				continue
+			}
+			var key string
+			notificationInfo := &pps.NotificationInfo{}
+			if err := event.Unmarshal(&key, notificationInfo); err != nil {
+				return err
+			}
+			if notificationInfo.State != pps.NotificationState_NOTIFICATION_PENDING {
+				continue
+			}
+			inFlightMu.Lock()
+			if inFlight[key] {
+				inFlightMu.Unlock()
+				continue
+			}
+			inFlight[key] = true
+			inFlightMu.Unlock()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() {
+					inFlightMu.Lock()
+					defer inFlightMu.Unlock()
+					delete(inFlight, key)
+				}()
+				// re-read the notification, in case it was delivered after this
+				// event was generated
+				if err := m.a.notifications.ReadOnly(ctx).Get(key, notificationInfo); err != nil {
+					if !col.IsErrNotFound(err) && ctx.Err() == nil {
+						log.Errorf("PPS master: error reading notification %q: %v", key, err)
+					}
+					return
+				}
+				if notificationInfo.State != pps.NotificationState_NOTIFICATION_PENDING {
+					return
+				}
+				if err := m.a.deliverNotification(ctx, notificationInfo); err != nil && ctx.Err() == nil {
+					log.Errorf("PPS master: error delivering notification %q: %v", key, err)
+				}
+			}()
+		}
+		return nil // reset until ctx is cancelled (RetryUntilCancel)
+	}), &backoff.ZeroBackOff{}, backoff.NotifyContinue("deliverNotifications"),
+	); err != nil && ctx.Err() == nil {
+		log.Fatalf("deliverNotifications is exiting prematurely which should not happen (error: %v); restarting container...", err)
+	}
+}
+
+// deliverNotification sends 'notificationInfo' to its webhook and records the
+// delivery status in etcd.
+func (a *apiServer) deliverNotification(ctx context.Context, notificationInfo *pps.NotificationInfo) error {
+	maxAttempts := notificationInfo.Webhook.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = defaultNotificationAttempts
+	}
+	var sendErr error
+	if err := backoff.RetryUntilCancel(ctx, func() error {
+		sendErr = sendNotification(ctx, notificationInfo)
+		notificationInfo.Attempts++
+		if sendErr == nil {
+			notificationInfo.LastError = ""
+			notificationInfo.State = pps.NotificationState_NOTIFICATION_DELIVERED
+			notificationInfo.Delivered = types.TimestampNow()
+			return nil
+		}
+		notificationInfo.LastError = sendErr.Error()
+		if notificationInfo.Attempts >= maxAttempts {
+			notificationInfo.State = pps.NotificationState_NOTIFICATION_FAILED
+			return nil
+		}
+		if err := a.putNotification(ctx, notificationInfo); err != nil {
+			return err
+		}
+		return sendErr
+	}, backoff.NewInfiniteBackOff(), func(err error, d time.Duration) error {
+		log.Errorf("PPS master: error sending notification %q to %q: %v, retrying in %v",
+			notificationInfo.ID, notificationInfo.Webhook.URL, err, d)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if err := a.putNotification(ctx, notificationInfo); err != nil {
+		return err
+	}
+	return a.pruneNotifications(ctx, notificationInfo.Pipeline)
+}
+
+// putNotification writes 'notificationInfo' back to etcd, unless it has been
+// deleted (e.g. because its pipeline was deleted) in the meantime.
+func (a *apiServer) putNotification(ctx context.Context, notificationInfo *pps.NotificationInfo) error {
+	_, err := col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		notifications := a.notifications.ReadWrite(stm)
+		if err := notifications.Get(notificationInfo.ID, &pps.NotificationInfo{}); err != nil {
+			if col.IsErrNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		return notifications.Put(notificationInfo.ID, notificationInfo)
+	})
+	return err
+}
+
+// pruneNotifications deletes all but the most recent maxRecentNotifications
+// finished notifications for 'pipeline'.
+func (a *apiServer) pruneNotifications(ctx context.Context, pipeline *pps.Pipeline) error {
+	notificationInfos, err := a.recentNotifications(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	var finished []*pps.NotificationInfo
+	for _, notificationInfo := range notificationInfos {
+		if notificationInfo.State != pps.NotificationState_NOTIFICATION_PENDING {
+			finished = append(finished, notificationInfo)
+		}
+	}
+	if len(finished) <= maxRecentNotifications {
+		return nil
+	}
+	_, err = col.NewSTM(ctx, a.env.GetEtcdClient(), func(stm col.STM) error {
+		notifications := a.notifications.ReadWrite(stm)
+		for _, notificationInfo := range finished[:len(finished)-maxRecentNotifications] {
+			if err := notifications.Delete(notificationInfo.ID); err != nil && !col.IsErrNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// sendNotification POSTs 'notificationInfo' to its webhook. The body is the
+// webhook's payload template executed with 'notificationInfo', or
+// 'notificationInfo' as JSON if the webhook has no payload template.
+func sendNotification(ctx context.Context, notificationInfo *pps.NotificationInfo) error {
+	var body bytes.Buffer
+	contentType := "application/json"
+	if notificationInfo.Webhook.Payload == "" {
+		// EmitDefaults, so that "event" is always set (NOTIFY_JOB_FAILURE is 0)
+		if err := (&jsonpb.Marshaler{EmitDefaults: true}).Marshal(&body, notificationInfo); err != nil {
+			return errors.EnsureStack(err)
+		}
+	} else {
+		t, err := template.New("payload").Parse(notificationInfo.Webhook.Payload)
+		if err != nil {
+			return errors.EnsureStack(err)
+		}
+		if err := t.Execute(&body, notificationInfo); err != nil {
+			return errors.EnsureStack(err)
+		}
+		if !json.Valid(body.Bytes()) {
+			contentType = "text/plain"
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, notificationTimeout)
+	defer cancel()
+	req, err := http.NewRequest(http.MethodPost, notificationInfo.Webhook.URL, &body)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := webhookClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("webhook returned %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+)
+
+func TestWebhookWantsEvent(t *testing.T) {
+	all := &pps.Webhook{URL: "http://example.com"}
+	require.True(t, webhookWantsEvent(all, pps.NotificationEvent_NOTIFY_JOB_SUCCESS))
+	require.True(t, webhookWantsEvent(all, pps.NotificationEvent_NOTIFY_PIPELINE_CRASHING))
+
+	failures := &pps.Webhook{
+		URL:    "http://example.com",
+		Events: []pps.NotificationEvent{pps.NotificationEvent_NOTIFY_JOB_FAILURE},
+	}
+	require.True(t, webhookWantsEvent(failures, pps.NotificationEvent_NOTIFY_JOB_FAILURE))
+	require.False(t, webhookWantsEvent(failures, pps.NotificationEvent_NOTIFY_JOB_SUCCESS))
+}
+
+func TestValidateNotifications(t *testing.T) {
+	require.NoError(t, validateNotifications(nil))
+	require.NoError(t, validateNotifications(&pps.Notifications{
+		Webhooks: []*pps.Webhook{{URL: "https://example.com/hook", Payload: "{{.Reason}}"}},
+	}))
+	require.YesError(t, validateNotifications(&pps.Notifications{
+		Webhooks: []*pps.Webhook{{URL: "example.com/hook"}},
+	}))
+	require.YesError(t, validateNotifications(&pps.Notifications{
+		Webhooks: []*pps.Webhook{{URL: "https://example.com/hook", Payload: "{{.Reason"}},
+	}))
+	for _, url := range []string{
+		"file:///etc/passwd",
+		"http://localhost:8080/hook",
+		"http://127.0.0.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.1/hook",
+		"http://[::1]/hook",
+		"http://pachd:1650",
+		"http://pachd.default.svc/hook",
+		"http://metadata.google.internal/",
+	} {
+		require.YesError(t, validateNotifications(&pps.Notifications{
+			Webhooks: []*pps.Webhook{{URL: url}},
+		}), url)
+	}
+	require.NoError(t, validateNotifications(&pps.Notifications{
+		Webhooks: []*pps.Webhook{{URL: "http://93.184.216.34/hook"}},
+	}))
+}
+
+func TestIsInternalIP(t *testing.T) {
+	for _, ip := range []string{"127.0.0.1", "10.1.2.3", "172.20.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "0.0.0.0", "::1", "fe80::1", "fd00::1", "::ffff:127.0.0.1"} {
+		require.True(t, isInternalIP(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{"93.184.216.34", "8.8.8.8", "2606:2800:220:1:248:1893:25c8:1946"} {
+		require.False(t, isInternalIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestWebhookClientRejectsInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	err := sendNotification(context.Background(), &pps.NotificationInfo{
+		Webhook: &pps.Webhook{URL: server.URL},
+	})
+	require.YesError(t, err)
+	require.Matches(t, "internal to the cluster's network", err.Error())
+}
+
+func TestSendNotification(t *testing.T) {
+	var body, contentType string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		body = string(data)
+		contentType = r.Header.Get("Content-Type")
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	// the test server listens on localhost, which webhookClient rejects
+	defer func(c *http.Client) { webhookClient = c }(webhookClient)
+	webhookClient = server.Client()
+
+	notificationInfo := &pps.NotificationInfo{
+		Pipeline: client.NewPipeline("pipeline"),
+		Job:      client.NewJob("job"),
+		Event:    pps.NotificationEvent_NOTIFY_JOB_FAILURE,
+		Reason:   "datum failed",
+		Webhook:  &pps.Webhook{URL: server.URL},
+	}
+	require.NoError(t, sendNotification(context.Background(), notificationInfo))
+	require.Equal(t, "application/json", contentType)
+	require.True(t, len(body) > 0)
+	require.Matches(t, `"event":"NOTIFY_JOB_FAILURE"`, body)
+
+	notificationInfo.Webhook.Payload = "{{.Pipeline.Name}} {{.Event}}: {{.Reason}}"
+	require.NoError(t, sendNotification(context.Background(), notificationInfo))
+	require.Equal(t, "text/plain", contentType)
+	require.Equal(t, "pipeline NOTIFY_JOB_FAILURE: datum failed", body)
+
+	status = http.StatusInternalServerError
+	require.YesError(t, sendNotification(context.Background(), notificationInfo))
+}
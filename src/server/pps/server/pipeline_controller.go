@@ -517,6 +517,13 @@ func (op *pipelineOp) scaleUpPipeline() (retErr error) {
 		log.Errorf("PPS master: error getting number of workers (defaulting to 1 worker)")
 		parallelism = 1
 	}
+	// limit parallelism to the scheduler's allocation for this pipeline, which
+	// may be lower if higher-priority pipelines are busy or the pipeline's team
+	// is over budget
+	if allocation := op.m.workerAllocation(op.name, parallelism); allocation < parallelism {
+		log.Infof("PPS master: limiting %q to %d of %d workers", op.name, allocation, parallelism)
+		parallelism = allocation
+	}
 
 	// update pipeline RC
 	return op.updateRC(func(rc *v1.ReplicationController) {
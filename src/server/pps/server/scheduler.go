@@ -0,0 +1,284 @@
+package server
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/backoff"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/ppsutil"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+	pfsServer "github.com/pachyderm/pachyderm/v2/src/server/pfs"
+)
+
+const schedulerPeriod = 10 * time.Second
+
+// schedCandidate is the subset of a pipeline's state that the scheduler uses
+// to decide how many workers the pipeline may run.
+type schedCandidate struct {
+	pipeline    string
+	priority    int64
+	team        string
+	parallelism int
+	// pending is true if the pipeline has work to do (see hasPendingWork)
+	pending bool
+}
+
+// schedSpec caches a pipeline's scheduling spec, so that the scheduler only
+// has to read a pipeline's spec commit when the pipeline is updated.
+type schedSpec struct {
+	specCommitID string
+	priority     int64
+	team         string
+	outputBranch string
+}
+
+// parseTeamBudgets parses the PPS_TEAM_BUDGETS config value, which has the form
+// "team1=10,team2=4", into a map from team name to the maximum number of
+// workers that the team's pipelines may run at once.
+func parseTeamBudgets(s string) (map[string]int, error) {
+	budgets := make(map[string]int)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("invalid team budget %q (expected <team>=<workers>)", entry)
+		}
+		workers, err := strconv.Atoi(parts[1])
+		if err != nil || workers < 0 {
+			return nil, errors.Errorf("invalid worker count in team budget %q", entry)
+		}
+		budgets[parts[0]] = workers
+	}
+	return budgets, nil
+}
+
+// hasPendingWork returns true if a pipeline, described by 'ptr', has work to
+// do: an unfinished job, or an open output commit at the head of its output
+// branch, 'head' (which may be nil), that it hasn't created a job for yet.
+// Jobs are created by a pipeline's own workers, so without the latter a
+// pipeline that's scaled down to zero workers could never become pending
+// again, even if a higher-priority pipeline is waiting for its output.
+func hasPendingWork(ptr *pps.EtcdPipelineInfo, head *pfs.CommitInfo) bool {
+	if ptr.JobCounts[int32(pps.JobState_JOB_STARTING)] > 0 ||
+		ptr.JobCounts[int32(pps.JobState_JOB_RUNNING)] > 0 ||
+		ptr.JobCounts[int32(pps.JobState_JOB_EGRESSING)] > 0 {
+		return true
+	}
+	return head != nil && head.Finished == nil
+}
+
+// allocateWorkers decides how many workers each candidate pipeline may run.
+// Pipelines with a lower priority than the highest-priority pending pipeline
+// are given no workers. The remaining pipelines are visited in order of
+// priority (pending pipelines first) and each group of pipelines with equal
+// priority shares its team's budget round-robin, so that no pipeline in the
+// group is starved by its peers. Pipelines whose team has no budget always
+// get their full parallelism. Pending pipelines always keep at least one
+// worker, even if they're preempted or their team's budget is spent, so that
+// their work isn't stalled (this may exceed the team's budget).
+func allocateWorkers(candidates []*schedCandidate, budgets map[string]int) map[string]int {
+	sorted := make([]*schedCandidate, len(candidates))
+	copy(sorted, candidates)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].pending != sorted[j].pending {
+			return sorted[i].pending
+		}
+		if sorted[i].priority != sorted[j].priority {
+			return sorted[i].priority > sorted[j].priority
+		}
+		return sorted[i].pipeline < sorted[j].pipeline
+	})
+	remaining := make(map[string]int)
+	for team, budget := range budgets {
+		remaining[team] = budget
+	}
+	result := make(map[string]int)
+	preempted := func(c *schedCandidate) bool {
+		// sorted[0] is the highest-priority pending pipeline, if there is one
+		return sorted[0].pending && c.priority < sorted[0].priority
+	}
+	for start := 0; start < len(sorted); {
+		end := start + 1
+		for end < len(sorted) && sorted[end].pending == sorted[start].pending &&
+			sorted[end].priority == sorted[start].priority {
+			end++
+		}
+		group := sorted[start:end]
+		start = end
+		if preempted(group[0]) {
+			for _, c := range group {
+				result[c.pipeline] = 0
+			}
+			continue
+		}
+		for _, c := range group {
+			result[c.pipeline] = 0
+			if _, ok := remaining[c.team]; !ok {
+				result[c.pipeline] = c.parallelism
+			}
+		}
+		for progress := true; progress; {
+			progress = false
+			for _, c := range group {
+				if result[c.pipeline] >= c.parallelism || remaining[c.team] <= 0 {
+					continue
+				}
+				remaining[c.team]--
+				result[c.pipeline]++
+				progress = true
+			}
+		}
+	}
+	for _, c := range sorted {
+		if c.pending && c.parallelism > 0 && result[c.pipeline] == 0 {
+			remaining[c.team]--
+			result[c.pipeline] = 1
+		}
+	}
+	return result
+}
+
+// workerAllocation returns the number of workers that 'pipeline' may run,
+// given that its spec requests 'parallelism' workers.
+func (m *ppsMaster) workerAllocation(pipeline string, parallelism int) int {
+	m.schedMu.Lock()
+	defer m.schedMu.Unlock()
+	if allocation, ok := m.allocations[pipeline]; ok && allocation < parallelism {
+		return allocation
+	}
+	return parallelism
+}
+
+// startScheduler starts a new goroutine running schedulePipelines
+func (m *ppsMaster) startScheduler() {
+	m.pollPipelinesMu.Lock()
+	defer m.pollPipelinesMu.Unlock()
+	m.schedCancel = m.startMonitorThread("schedulePipelines", m.schedulePipelines)
+}
+
+func (m *ppsMaster) cancelScheduler() {
+	m.pollPipelinesMu.Lock()
+	defer m.pollPipelinesMu.Unlock()
+	if m.schedCancel != nil {
+		m.schedCancel()
+		m.schedCancel = nil
+	}
+}
+
+// schedulePipelines periodically recomputes the number of workers that each
+// pipeline may run (see allocateWorkers) and generates a pipeline event for
+// every pipeline whose allocation changed, so that the pipeline controller
+// scales its RC accordingly.
+func (m *ppsMaster) schedulePipelines(pachClient *client.APIClient) {
+	ctx := pachClient.Ctx()
+	budgets, err := parseTeamBudgets(m.a.env.PPSTeamBudgets)
+	if err != nil {
+		log.Errorf("PPS master: ignoring PPS_TEAM_BUDGETS: %v", err)
+		budgets = nil
+	}
+	specs := make(map[string]*schedSpec)
+	if err := backoff.RetryUntilCancel(ctx, backoff.MustLoop(func() error {
+		var candidates []*schedCandidate
+		seen := make(map[string]bool)
+		if err := m.a.listPipelinePtr(pachClient, nil, 0,
+			func(pipeline string, ptr *pps.EtcdPipelineInfo) error {
+				switch ptr.State {
+				case pps.PipelineState_PIPELINE_RUNNING,
+					pps.PipelineState_PIPELINE_STANDBY,
+					pps.PipelineState_PIPELINE_CRASHING:
+				default:
+					return nil
+				}
+				seen[pipeline] = true
+				spec, ok := specs[pipeline]
+				if !ok || spec.specCommitID != ptr.SpecCommit.ID {
+					var pipelineInfo *pps.PipelineInfo
+					if err := m.a.sudo(pachClient, func(superUserClient *client.APIClient) error {
+						var err error
+						pipelineInfo, err = ppsutil.GetPipelineInfo(superUserClient, pipeline, ptr)
+						return err
+					}); err != nil {
+						return err
+					}
+					spec = &schedSpec{
+						specCommitID: ptr.SpecCommit.ID,
+						outputBranch: pipelineInfo.OutputBranch,
+					}
+					if pipelineInfo.SchedulingSpec != nil {
+						spec.priority = pipelineInfo.SchedulingSpec.Priority
+						spec.team = pipelineInfo.SchedulingSpec.Team
+					}
+					specs[pipeline] = spec
+				}
+				var head *pfs.CommitInfo
+				if !hasPendingWork(ptr, nil) {
+					if err := m.a.sudo(pachClient, func(superUserClient *client.APIClient) error {
+						var err error
+						head, err = superUserClient.InspectCommit(pipeline, spec.outputBranch)
+						if pfsServer.IsNoHeadErr(err) || pfsServer.IsBranchNotFoundErr(err) || pfsServer.IsCommitNotFoundErr(err) {
+							return nil
+						}
+						return err
+					}); err != nil {
+						return err
+					}
+				}
+				candidates = append(candidates, &schedCandidate{
+					pipeline:    pipeline,
+					priority:    spec.priority,
+					team:        spec.team,
+					parallelism: int(ptr.Parallelism),
+					pending:     hasPendingWork(ptr, head),
+				})
+				return nil
+			}); err != nil {
+			return errors.Wrap(err, "error listing pipelines to schedule")
+		}
+		for pipeline := range specs {
+			if !seen[pipeline] {
+				delete(specs, pipeline)
+			}
+		}
+		allocations := allocateWorkers(candidates, budgets)
+
+		// swap in the new allocations and collect the pipelines whose RCs need
+		// to be rescaled
+		var changed []string
+		m.schedMu.Lock()
+		for _, c := range candidates {
+			// pipelines without an allocation run their full parallelism
+			old, ok := m.allocations[c.pipeline]
+			if !ok {
+				old = c.parallelism
+			}
+			if old != allocations[c.pipeline] {
+				changed = append(changed, c.pipeline)
+			}
+		}
+		m.allocations = allocations
+		m.schedMu.Unlock()
+		for _, pipeline := range changed {
+			log.Infof("PPS master: pipeline %q may now run %d workers", pipeline, allocations[pipeline])
+			select {
+			case m.eventCh <- &pipelineEvent{eventType: writeEv, pipeline: pipeline}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		return nil
+	}), backoff.NewConstantBackOff(schedulerPeriod),
+		backoff.NotifyContinue("schedulePipelines"),
+	); err != nil && ctx.Err() == nil {
+		log.Fatalf("schedulePipelines is exiting prematurely which should not happen (error: %v); restarting container...", err)
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+)
+
+func TestParseTeamBudgets(t *testing.T) {
+	budgets, err := parseTeamBudgets("")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(budgets))
+
+	budgets, err = parseTeamBudgets("ml=20, etl=8")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"ml": 20, "etl": 8}, budgets)
+
+	_, err = parseTeamBudgets("ml")
+	require.YesError(t, err)
+	_, err = parseTeamBudgets("ml=-1")
+	require.YesError(t, err)
+}
+
+func TestAllocateWorkersPriority(t *testing.T) {
+	allocations := allocateWorkers([]*schedCandidate{
+		{pipeline: "high", priority: 10, parallelism: 4, pending: true},
+		{pipeline: "low", priority: 1, parallelism: 4, pending: true},
+		{pipeline: "peer", priority: 10, parallelism: 2},
+	}, nil)
+	// "low" keeps one worker, so that its running job isn't stalled
+	require.Equal(t, map[string]int{"high": 4, "low": 1, "peer": 2}, allocations)
+
+	// idle lower-priority pipelines are scaled down to zero
+	allocations = allocateWorkers([]*schedCandidate{
+		{pipeline: "high", priority: 10, parallelism: 4, pending: true},
+		{pipeline: "low", priority: 1, parallelism: 4},
+	}, nil)
+	require.Equal(t, map[string]int{"high": 4, "low": 0}, allocations)
+
+	// with no pending jobs, nothing is scaled down
+	allocations = allocateWorkers([]*schedCandidate{
+		{pipeline: "high", priority: 10, parallelism: 4},
+		{pipeline: "low", priority: 1, parallelism: 4},
+	}, nil)
+	require.Equal(t, map[string]int{"high": 4, "low": 4}, allocations)
+}
+
+func TestAllocateWorkersTeamBudget(t *testing.T) {
+	allocations := allocateWorkers([]*schedCandidate{
+		{pipeline: "a", team: "ml", parallelism: 4, pending: true},
+		{pipeline: "b", team: "ml", parallelism: 4, pending: true},
+		{pipeline: "c", team: "ml", parallelism: 4},
+		{pipeline: "d", team: "etl", parallelism: 4, pending: true},
+	}, map[string]int{"ml": 5})
+	require.Equal(t, map[string]int{"a": 3, "b": 2, "c": 0, "d": 4}, allocations)
+
+	// pipelines with pending work keep one worker even if that exceeds their
+	// team's budget, while idle ones, which have no open output commit to
+	// create a job for, are scaled down to zero
+	allocations = allocateWorkers([]*schedCandidate{
+		{pipeline: "a", team: "ml", parallelism: 4, pending: true},
+		{pipeline: "b", team: "ml", parallelism: 4, pending: true},
+		{pipeline: "c", team: "ml", parallelism: 4},
+	}, map[string]int{"ml": 1})
+	require.Equal(t, map[string]int{"a": 1, "b": 1, "c": 0}, allocations)
+}
+
+func TestHasPendingWork(t *testing.T) {
+	idle := &pps.EtcdPipelineInfo{JobCounts: map[int32]int32{int32(pps.JobState_JOB_SUCCESS): 3}}
+	require.False(t, hasPendingWork(idle, nil))
+	require.False(t, hasPendingWork(idle, &pfs.CommitInfo{Finished: types.TimestampNow()}))
+	// an open output commit is pending work, even if the pipeline hasn't
+	// created a job for it
+	require.True(t, hasPendingWork(idle, &pfs.CommitInfo{}))
+	running := &pps.EtcdPipelineInfo{JobCounts: map[int32]int32{int32(pps.JobState_JOB_RUNNING): 1}}
+	require.True(t, hasPendingWork(running, nil))
+}
+
+func TestAllocateWorkersPreemptedUpstream(t *testing.T) {
+	// "downstream" has a job waiting for the output of the lower-priority
+	// "upstream", which has new input but no workers to create a job for it
+	upstream := &pps.EtcdPipelineInfo{}
+	downstream := &pps.EtcdPipelineInfo{JobCounts: map[int32]int32{int32(pps.JobState_JOB_STARTING): 1}}
+	allocations := allocateWorkers([]*schedCandidate{
+		{pipeline: "downstream", priority: 10, parallelism: 4, pending: hasPendingWork(downstream, nil)},
+		{pipeline: "upstream", priority: 1, parallelism: 4, pending: hasPendingWork(upstream, &pfs.CommitInfo{})},
+	}, nil)
+	// "upstream" keeps a worker, so it can produce the output "downstream"
+	// is waiting for
+	require.Equal(t, map[string]int{"downstream": 4, "upstream": 1}, allocations)
+
+	// the same holds when "upstream" is over its team's budget
+	allocations = allocateWorkers([]*schedCandidate{
+		{pipeline: "downstream", team: "ml", parallelism: 4, pending: hasPendingWork(downstream, nil)},
+		{pipeline: "upstream", team: "ml", parallelism: 4, pending: hasPendingWork(upstream, &pfs.CommitInfo{})},
+	}, map[string]int{"ml": 1})
+	require.Equal(t, map[string]int{"downstream": 1, "upstream": 1}, allocations)
+}
@@ -59,6 +59,7 @@ func NewAPIServer(
 		workerUsesRoot:        workerUsesRoot,
 		pipelines:             ppsdb.Pipelines(env.GetEtcdClient(), etcdPrefix),
 		jobs:                  ppsdb.Jobs(env.GetEtcdClient(), etcdPrefix),
+		notifications:         ppsdb.Notifications(env.GetEtcdClient(), etcdPrefix),
 		workerGrpcPort:        workerGrpcPort,
 		port:                  port,
 		httpPort:              httpPort,
@@ -95,6 +96,7 @@ func NewSidecarAPIServer(
 		workerUsesRoot: true,
 		pipelines:      ppsdb.Pipelines(env.GetEtcdClient(), etcdPrefix),
 		jobs:           ppsdb.Jobs(env.GetEtcdClient(), etcdPrefix),
+		notifications:  ppsdb.Notifications(env.GetEtcdClient(), etcdPrefix),
 		workerGrpcPort: workerGrpcPort,
 		httpPort:       httpPort,
 		peerPort:       peerPort,
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq" // register the "postgres" driver
+	"github.com/robfig/cron"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kube "k8s.io/client-go/kubernetes"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/sql"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+	pfsserver "github.com/pachyderm/pachyderm/v2/src/server/pfs"
+)
+
+// makeSQLCommits runs a single sql input's query on its cron schedule, writing
+// the results to the input's repo each time. It's a helper function called by
+// monitorPipeline.
+func makeSQLCommits(pachClient *client.APIClient, kubeClient kube.Interface, namespace string, in *pps.SQLInput) error {
+	schedule, err := cron.ParseStandard(in.Spec)
+	if err != nil {
+		return err // Shouldn't happen, as the input is validated in CreatePipeline
+	}
+	// Resume the schedule from the last time the query ran, if it has
+	latestTime := time.Now()
+	commitInfo, err := pachClient.InspectCommit(in.Repo, "master")
+	if err != nil && !pfsserver.IsNoHeadErr(err) {
+		return err
+	} else if commitInfo != nil && commitInfo.Finished != nil {
+		if latestTime, err = types.TimestampFromProto(commitInfo.Finished); err != nil {
+			return err
+		}
+	}
+	for {
+		next := schedule.Next(latestTime)
+		select {
+		case <-time.After(time.Until(next)):
+		case <-pachClient.Ctx().Done():
+			return pachClient.Ctx().Err()
+		}
+		if err := writeSQLCommit(pachClient, kubeClient, namespace, in); err != nil {
+			return err
+		}
+		latestTime = next
+	}
+}
+
+// writeSQLCommit runs 'in's query and replaces the contents of 'in's repo with
+// the results, in a single commit.
+func writeSQLCommit(pachClient *client.APIClient, kubeClient kube.Interface, namespace string, in *pps.SQLInput) (retErr error) {
+	secret, err := kubeClient.CoreV1().Secrets(namespace).Get(in.DSNSecret, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "could not get dsn secret %q", in.DSNSecret)
+	}
+	dsn, ok := secret.Data[in.DSNSecretKey]
+	if !ok {
+		return errors.Errorf("dsn secret %q has no key %q", in.DSNSecret, in.DSNSecretKey)
+	}
+	db, err := sqlx.Open(in.Driver, string(dsn))
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	defer func() {
+		if err := db.Close(); retErr == nil {
+			retErr = err
+		}
+	}()
+	rows, err := db.QueryContext(pachClient.Ctx(), in.Query)
+	if err != nil {
+		return errors.Wrapf(err, "error running query for sql input %q", in.Name)
+	}
+	defer rows.Close()
+
+	// make sure there isn't an unfinished commit on the branch
+	commitInfo, err := pachClient.InspectCommit(in.Repo, "master")
+	if err != nil && !pfsserver.IsNoHeadErr(err) {
+		return err
+	} else if commitInfo != nil && commitInfo.Finished == nil {
+		if err := pachClient.SquashCommit(in.Repo, commitInfo.Commit.ID); err != nil {
+			return err
+		}
+	}
+	commit, err := pachClient.StartCommit(in.Repo, "master")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			// Don't leave a partial snapshot behind
+			if err := pachClient.SquashCommit(in.Repo, commit.ID); err != nil {
+				retErr = errors.Wrapf(retErr, "error squashing commit: %v", err)
+			}
+		}
+	}()
+	// Each commit is a full snapshot of the query's results
+	if err := pachClient.DeleteFile(in.Repo, commit.ID, "/"); err != nil && !isNotFoundErr(err) && !pfsserver.IsNoHeadErr(err) {
+		return errors.Wrapf(err, "delete error")
+	}
+	var i int
+	if err := sql.WriteRows(rows, in.Format, in.RowsPerFile, func(r io.Reader) error {
+		name := fmt.Sprintf("/%010d.%s", i, in.Format)
+		i++
+		if err := pachClient.PutFile(in.Repo, commit.ID, name, r); err != nil {
+			return errors.Wrapf(err, "put error")
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return pachClient.FinishCommit(in.Repo, commit.ID)
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"net"
 	"os"
 	"strconv"
 
@@ -41,6 +42,7 @@ type workerOptions struct {
 	rcName        string // Name of the replication controller managing workers
 	specCommit    string // Pipeline spec commit ID (needed for s3 inputs)
 	s3GatewayPort int32  // s3 gateway port (if any s3 pipeline inputs)
+	noSidecar     bool   // If true, workers talk to pachd directly
 
 	userImage             string              // The user's pipeline/job image
 	labels                map[string]string   // k8s labels attached to the RC and workers
@@ -195,6 +197,21 @@ func (a *apiServer) workerPodSpec(options *workerOptions, pipelineInfo *pps.Pipe
 	}...)
 	workerEnv = append(workerEnv, assets.GetSecretEnvVars(a.storageBackend)...)
 
+	// Workers without a sidecar talk to pachd through its peer service
+	if options.noSidecar {
+		if err := validateNoSidecar(pipelineInfo); err != nil {
+			return v1.PodSpec{}, err
+		}
+		pachdAddress, err := peerServiceAddress()
+		if err != nil {
+			return v1.PodSpec{}, err
+		}
+		workerEnv = append(workerEnv, v1.EnvVar{
+			Name:  client.PPSPachdAddressEnv,
+			Value: pachdAddress,
+		})
+	}
+
 	// Set S3GatewayPort in the worker (for user code) and sidecar (for serving)
 	if options.s3GatewayPort != 0 {
 		workerEnv = append(workerEnv, v1.EnvVar{
@@ -344,21 +361,6 @@ func (a *apiServer) workerPodSpec(options *workerOptions, pipelineInfo *pps.Pipe
 				},
 				VolumeMounts: userVolumeMounts,
 			},
-			{
-				Name:            client.PPSWorkerSidecarContainerName,
-				Image:           a.workerSidecarImage,
-				Command:         []string{"/pachd", "--mode", "sidecar"},
-				ImagePullPolicy: v1.PullPolicy(pullPolicy),
-				Env:             sidecarEnv,
-				VolumeMounts:    sidecarVolumeMounts,
-				Resources: v1.ResourceRequirements{
-					Requests: v1.ResourceList{
-						v1.ResourceCPU:    cpuZeroQuantity,
-						v1.ResourceMemory: memSidecarQuantity,
-					},
-				},
-				Ports: sidecarPorts,
-			},
 		},
 		ServiceAccountName:            workerServiceAccountName,
 		RestartPolicy:                 "Always",
@@ -367,6 +369,23 @@ func (a *apiServer) workerPodSpec(options *workerOptions, pipelineInfo *pps.Pipe
 		TerminationGracePeriodSeconds: &zeroVal,
 		SecurityContext:               securityContext,
 	}
+	if !options.noSidecar {
+		podSpec.Containers = append(podSpec.Containers, v1.Container{
+			Name:            client.PPSWorkerSidecarContainerName,
+			Image:           a.workerSidecarImage,
+			Command:         []string{"/pachd", "--mode", "sidecar"},
+			ImagePullPolicy: v1.PullPolicy(pullPolicy),
+			Env:             sidecarEnv,
+			VolumeMounts:    sidecarVolumeMounts,
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceCPU:    cpuZeroQuantity,
+					v1.ResourceMemory: memSidecarQuantity,
+				},
+			},
+			Ports: sidecarPorts,
+		})
+	}
 	if options.schedulingSpec != nil {
 		podSpec.NodeSelector = options.schedulingSpec.NodeSelector
 		podSpec.PriorityClassName = options.schedulingSpec.PriorityClassName
@@ -436,6 +455,41 @@ func (a *apiServer) workerPodSpec(options *workerOptions, pipelineInfo *pps.Pipe
 	return podSpec, nil
 }
 
+// validateNoSidecar returns an error if the pipeline is configured to run
+// without a sidecar, but uses features that the sidecar provides.
+func validateNoSidecar(pipelineInfo *pps.PipelineInfo) error {
+	if !pipelineInfo.NoSidecar {
+		return nil
+	}
+	if ppsutil.ContainsS3Inputs(pipelineInfo.Input) || pipelineInfo.S3Out {
+		return errors.Errorf("s3 inputs and s3_out require a sidecar, as the " +
+			"sidecar serves the s3 gateway")
+	}
+	if pipelineInfo.Spout != nil {
+		return errors.Errorf("spouts require a sidecar, as their pachctl " +
+			"config points at it")
+	}
+	if pipelineInfo.SidecarResourceLimits != nil {
+		return errors.Errorf("sidecar_resource_limits can't be set without a sidecar")
+	}
+	return nil
+}
+
+// peerServiceAddress returns the address of pachd's peer service, which
+// workers without a sidecar connect to. Kubernetes sets the env vars that it's
+// read from in pachd's pods.
+func peerServiceAddress() (string, error) {
+	host, ok := os.LookupEnv("PACHD_PEER_SERVICE_HOST")
+	if !ok {
+		return "", errors.Errorf("PACHD_PEER_SERVICE_HOST not set")
+	}
+	port, ok := os.LookupEnv("PACHD_PEER_SERVICE_PORT")
+	if !ok {
+		return "", errors.Errorf("PACHD_PEER_SERVICE_PORT not set")
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
 func getStorageEnvVars(pipelineInfo *pps.PipelineInfo) ([]v1.EnvVar, error) {
 	uploadConcurrencyLimit, ok := os.LookupEnv(assets.UploadConcurrencyLimitEnvVar)
 	if !ok {
@@ -517,6 +571,10 @@ func (a *apiServer) getWorkerOptions(ptr *pps.EtcdPipelineInfo, pipelineInfo *pp
 	var volumes []v1.Volume
 	var volumeMounts []v1.VolumeMount
 	for _, secret := range transform.Secrets {
+		if secret.External != "" {
+			// External secrets are resolved by the worker
+			continue
+		}
 		if secret.MountPath != "" {
 			volumes = append(volumes, v1.Volume{
 				Name: secret.Name,
@@ -630,6 +688,7 @@ func (a *apiServer) getWorkerOptions(ptr *pps.EtcdPipelineInfo, pipelineInfo *pp
 	return &workerOptions{
 		rcName:                rcName,
 		s3GatewayPort:         s3GatewayPort,
+		noSidecar:             pipelineInfo.NoSidecar,
 		specCommit:            ptr.SpecCommit.ID,
 		labels:                labels,
 		annotations:           annotations,
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+)
+
+func TestValidateNoSidecar(t *testing.T) {
+	pfsInput := &pps.Input{Pfs: &pps.PFSInput{Repo: "in", Glob: "/*"}}
+	s3Input := &pps.Input{Pfs: &pps.PFSInput{Repo: "in", Glob: "/", S3: true}}
+
+	// Pipelines that use the sidecar are fine
+	require.NoError(t, validateNoSidecar(&pps.PipelineInfo{
+		Input: s3Input,
+		S3Out: true,
+	}))
+	require.NoError(t, validateNoSidecar(&pps.PipelineInfo{
+		NoSidecar: true,
+		Input:     pfsInput,
+	}))
+
+	// Features that the sidecar provides can't be used without it
+	require.YesError(t, validateNoSidecar(&pps.PipelineInfo{
+		NoSidecar: true,
+		Input:     s3Input,
+	}))
+	require.YesError(t, validateNoSidecar(&pps.PipelineInfo{
+		NoSidecar: true,
+		Input:     pfsInput,
+		S3Out:     true,
+	}))
+	require.YesError(t, validateNoSidecar(&pps.PipelineInfo{
+		NoSidecar: true,
+		Spout:     &pps.Spout{},
+	}))
+	require.YesError(t, validateNoSidecar(&pps.PipelineInfo{
+		NoSidecar:             true,
+		Input:                 pfsInput,
+		SidecarResourceLimits: &pps.ResourceSpec{Memory: "1G"},
+	}))
+}
@@ -119,6 +119,8 @@ func sprintUpdateJobState(request *pps.UpdateJobStateRequest) string {
 			return "SUCCESS"
 		case pps.JobState_JOB_KILLED:
 			return "KILLED"
+		case pps.JobState_JOB_SKIPPED:
+			return "SKIPPED"
 		default:
 			return "<unknown state>"
 		}
@@ -5,7 +5,10 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"fmt"
+	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/pachyderm/pachyderm/v2/src/client"
 	"github.com/pachyderm/pachyderm/v2/src/pps"
@@ -21,6 +24,36 @@ func IsDone(ctx context.Context) bool {
 	}
 }
 
+type jobStopKey struct{}
+
+// WithJobStop returns a copy of 'ctx' and a function that stops the job being
+// processed with it, which cancels the context the way stopping a job does,
+// as opposed to e.g. a datum timing out (see IsJobStopped).
+func WithJobStop(ctx context.Context) (context.Context, func()) {
+	stopped := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.WithValue(ctx, jobStopKey{}, stopped))
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() { close(stopped) })
+		cancel()
+	}
+}
+
+// IsJobStopped returns true if the job being processed with 'ctx' was stopped
+// with the function returned by WithJobStop, or false otherwise.
+func IsJobStopped(ctx context.Context) bool {
+	stopped, ok := ctx.Value(jobStopKey{}).(chan struct{})
+	if !ok {
+		return false
+	}
+	select {
+	case <-stopped:
+		return true
+	default:
+		return false
+	}
+}
+
 // DatumID computes the ID of a datum.
 func DatumID(inputs []*Input) string {
 	// TODO: This is a stopgap solution that needs to be addressed before 2.0 GA.
@@ -35,6 +68,17 @@ func DatumID(inputs []*Input) string {
 	return strings.Join(files, "-")
 }
 
+// InputEnv returns the environment variables that tell user code where the
+// datum 'inputs' are, given that they have been downloaded to 'inputDir'.
+func InputEnv(inputDir string, inputs []*Input) []string {
+	var env []string
+	for _, input := range inputs {
+		env = append(env, fmt.Sprintf("%s=%s", input.Name, filepath.Join(inputDir, input.Name, input.FileInfo.File.Path)))
+		env = append(env, fmt.Sprintf("%s_COMMIT=%s", input.Name, input.FileInfo.File.Commit.ID))
+	}
+	return env
+}
+
 // HashDatum computes the hash of a datum.
 func HashDatum(pipelineName string, pipelineSalt string, inputs []*Input) string {
 	hash := sha256.New()
@@ -25,20 +25,23 @@ var _ = math.Inf
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
 type Input struct {
-	FileInfo             *pfs.FileInfo `protobuf:"bytes,1,opt,name=file_info,json=fileInfo,proto3" json:"file_info,omitempty"`
-	ParentCommit         *pfs.Commit   `protobuf:"bytes,5,opt,name=parent_commit,json=parentCommit,proto3" json:"parent_commit,omitempty"`
-	Name                 string        `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	JoinOn               string        `protobuf:"bytes,8,opt,name=join_on,json=joinOn,proto3" json:"join_on,omitempty"`
-	OuterJoin            bool          `protobuf:"varint,11,opt,name=outer_join,json=outerJoin,proto3" json:"outer_join,omitempty"`
-	GroupBy              string        `protobuf:"bytes,10,opt,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
-	Lazy                 bool          `protobuf:"varint,3,opt,name=lazy,proto3" json:"lazy,omitempty"`
-	Branch               string        `protobuf:"bytes,4,opt,name=branch,proto3" json:"branch,omitempty"`
-	GitURL               string        `protobuf:"bytes,6,opt,name=git_url,json=gitUrl,proto3" json:"git_url,omitempty"`
-	EmptyFiles           bool          `protobuf:"varint,7,opt,name=empty_files,json=emptyFiles,proto3" json:"empty_files,omitempty"`
-	S3                   bool          `protobuf:"varint,9,opt,name=s3,proto3" json:"s3,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
-	XXX_unrecognized     []byte        `json:"-"`
-	XXX_sizecache        int32         `json:"-"`
+	FileInfo     *pfs.FileInfo `protobuf:"bytes,1,opt,name=file_info,json=fileInfo,proto3" json:"file_info,omitempty"`
+	ParentCommit *pfs.Commit   `protobuf:"bytes,5,opt,name=parent_commit,json=parentCommit,proto3" json:"parent_commit,omitempty"`
+	Name         string        `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	JoinOn       string        `protobuf:"bytes,8,opt,name=join_on,json=joinOn,proto3" json:"join_on,omitempty"`
+	OuterJoin    bool          `protobuf:"varint,11,opt,name=outer_join,json=outerJoin,proto3" json:"outer_join,omitempty"`
+	GroupBy      string        `protobuf:"bytes,10,opt,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
+	Lazy         bool          `protobuf:"varint,3,opt,name=lazy,proto3" json:"lazy,omitempty"`
+	Branch       string        `protobuf:"bytes,4,opt,name=branch,proto3" json:"branch,omitempty"`
+	GitURL       string        `protobuf:"bytes,6,opt,name=git_url,json=gitUrl,proto3" json:"git_url,omitempty"`
+	EmptyFiles   bool          `protobuf:"varint,7,opt,name=empty_files,json=emptyFiles,proto3" json:"empty_files,omitempty"`
+	S3           bool          `protobuf:"varint,9,opt,name=s3,proto3" json:"s3,omitempty"`
+	// Dir, if set, is the directory (relative to the input's mount point) that
+	// the file is downloaded into.
+	Dir                  string   `protobuf:"bytes,12,opt,name=dir,proto3" json:"dir,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *Input) Reset()         { *m = Input{} }
@@ -151,6 +154,13 @@ func (m *Input) GetS3() bool {
 	return false
 }
 
+func (m *Input) GetDir() string {
+	if m != nil {
+		return m.Dir
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*Input)(nil), "common.Input")
 }
@@ -158,31 +168,31 @@ func init() {
 func init() { proto.RegisterFile("server/worker/common/common.proto", fileDescriptor_91fb6c79ddd9db74) }
 
 var fileDescriptor_91fb6c79ddd9db74 = []byte{
-	// 369 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x91, 0xcf, 0x6b, 0xdb, 0x30,
-	0x14, 0xc7, 0xb1, 0x97, 0xf8, 0xc7, 0xf3, 0xb2, 0x83, 0x18, 0x9b, 0x16, 0x58, 0x92, 0x6d, 0x97,
-	0xb0, 0x43, 0x3c, 0x92, 0xdb, 0x8e, 0x19, 0x6c, 0x4b, 0x29, 0x14, 0x0c, 0xb9, 0xf4, 0x62, 0x6c,
-	0x57, 0x76, 0xd4, 0xda, 0x92, 0x90, 0xe4, 0x14, 0xf7, 0x2f, 0xec, 0xa9, 0xf4, 0x2f, 0x28, 0xc5,
-	0x7f, 0x49, 0x91, 0x9c, 0x43, 0x0f, 0x3d, 0xf9, 0xfb, 0xf9, 0xf8, 0x3d, 0x3d, 0xa4, 0x07, 0xdf,
-	0x14, 0x91, 0x47, 0x22, 0xe3, 0x5b, 0x2e, 0x6f, 0x88, 0x8c, 0x0b, 0xde, 0x34, 0x9c, 0x9d, 0x3e,
-	0x2b, 0x21, 0xb9, 0xe6, 0xc8, 0x1b, 0x68, 0x3a, 0x11, 0xa5, 0x8a, 0x45, 0xa9, 0x06, 0x3d, 0xfd,
-	0x58, 0xf1, 0x8a, 0xdb, 0x18, 0x9b, 0x34, 0xd8, 0xef, 0x0f, 0x2e, 0x8c, 0x77, 0x4c, 0xb4, 0x1a,
-	0xfd, 0x84, 0xb0, 0xa4, 0x35, 0x49, 0x29, 0x2b, 0x39, 0x76, 0x16, 0xce, 0x32, 0x5a, 0x4f, 0x56,
-	0xa6, 0xfd, 0x2f, 0xad, 0xc9, 0x8e, 0x95, 0x3c, 0x09, 0xca, 0x53, 0x42, 0xbf, 0x60, 0x22, 0x32,
-	0x49, 0x98, 0x4e, 0xcd, 0x2c, 0xaa, 0xf1, 0xd8, 0xd6, 0x47, 0xb6, 0xfe, 0x8f, 0x55, 0xc9, 0xfb,
-	0xa1, 0x62, 0x20, 0x84, 0x60, 0xc4, 0xb2, 0x86, 0x60, 0x77, 0xe1, 0x2c, 0xc3, 0xc4, 0x66, 0xf4,
-	0x19, 0xfc, 0x6b, 0x4e, 0x59, 0xca, 0x19, 0x0e, 0xac, 0xf6, 0x0c, 0x5e, 0x30, 0xf4, 0x15, 0x80,
-	0xb7, 0x9a, 0xc8, 0xd4, 0x30, 0x8e, 0x16, 0xce, 0x32, 0x48, 0x42, 0x6b, 0xce, 0x38, 0x65, 0xe8,
-	0x0b, 0x04, 0x95, 0xe4, 0xad, 0x48, 0xf3, 0x0e, 0x83, 0x6d, 0xf4, 0x2d, 0x6f, 0x3b, 0x33, 0xa6,
-	0xce, 0xee, 0x3a, 0xfc, 0xce, 0xf6, 0xd8, 0x8c, 0x3e, 0x81, 0x97, 0xcb, 0x8c, 0x15, 0x07, 0x3c,
-	0x1a, 0xa6, 0x0c, 0x84, 0x7e, 0x80, 0x5f, 0x51, 0x9d, 0xb6, 0xb2, 0xc6, 0x9e, 0xf9, 0xb1, 0x85,
-	0xfe, 0x69, 0xee, 0xfd, 0xa3, 0x7a, 0x9f, 0x9c, 0x27, 0x5e, 0x45, 0xf5, 0x5e, 0xd6, 0x68, 0x0e,
-	0x11, 0x69, 0x84, 0xee, 0x52, 0x73, 0x77, 0x85, 0x7d, 0x7b, 0x2e, 0x58, 0x65, 0xde, 0x45, 0xa1,
-	0x0f, 0xe0, 0xaa, 0x0d, 0x0e, 0xad, 0x77, 0xd5, 0x66, 0xfb, 0xff, 0xbe, 0x9f, 0x39, 0x8f, 0xfd,
-	0xcc, 0x79, 0xee, 0x67, 0xce, 0xe5, 0xef, 0x8a, 0xea, 0x43, 0x9b, 0xaf, 0x0a, 0xde, 0xc4, 0x22,
-	0x2b, 0x0e, 0xdd, 0x15, 0x91, 0xaf, 0xd3, 0x71, 0x1d, 0x2b, 0x59, 0xc4, 0x6f, 0x2d, 0x35, 0xf7,
-	0xec, 0x86, 0x36, 0x2f, 0x01, 0x00, 0x00, 0xff, 0xff, 0xbf, 0x3e, 0x25, 0x71, 0xf3, 0x01, 0x00,
-	0x00,
+	// 374 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x91, 0x4f, 0x8b, 0xd3, 0x40,
+	0x18, 0xc6, 0x49, 0x76, 0x37, 0x7f, 0xde, 0x6c, 0x45, 0x06, 0xd1, 0x71, 0xc1, 0x6e, 0xd5, 0x4b,
+	0xf1, 0xd0, 0x48, 0x7b, 0xf3, 0x58, 0x41, 0xad, 0x08, 0x42, 0xa0, 0x17, 0x2f, 0x21, 0x49, 0x27,
+	0xe9, 0x68, 0x32, 0x33, 0xcc, 0x4c, 0x2a, 0xf1, 0x13, 0x7a, 0xf4, 0x13, 0x48, 0xc9, 0x27, 0x91,
+	0x79, 0xd3, 0x83, 0x87, 0x3d, 0xe5, 0xf7, 0xfc, 0xf2, 0xbe, 0x79, 0xc8, 0x0c, 0xbc, 0x34, 0x4c,
+	0x9f, 0x98, 0x4e, 0x7f, 0x4a, 0xfd, 0x83, 0xe9, 0xb4, 0x92, 0x5d, 0x27, 0xc5, 0xe5, 0xb1, 0x52,
+	0x5a, 0x5a, 0x49, 0x82, 0x29, 0xdd, 0xcd, 0x54, 0x6d, 0x52, 0x55, 0x9b, 0x49, 0xdf, 0x3d, 0x69,
+	0x64, 0x23, 0x11, 0x53, 0x47, 0x93, 0x7d, 0x75, 0xf6, 0xe1, 0x66, 0x27, 0x54, 0x6f, 0xc9, 0x1b,
+	0x88, 0x6b, 0xde, 0xb2, 0x9c, 0x8b, 0x5a, 0x52, 0x6f, 0xe1, 0x2d, 0x93, 0xf5, 0x6c, 0xe5, 0xd6,
+	0x3f, 0xf0, 0x96, 0xed, 0x44, 0x2d, 0xb3, 0xa8, 0xbe, 0x10, 0x79, 0x0b, 0x33, 0x55, 0x68, 0x26,
+	0x6c, 0xee, 0xba, 0xb8, 0xa5, 0x37, 0x38, 0x9f, 0xe0, 0xfc, 0x7b, 0x54, 0xd9, 0xed, 0x34, 0x31,
+	0x25, 0x42, 0xe0, 0x5a, 0x14, 0x1d, 0xa3, 0xfe, 0xc2, 0x5b, 0xc6, 0x19, 0x32, 0x79, 0x06, 0xe1,
+	0x77, 0xc9, 0x45, 0x2e, 0x05, 0x8d, 0x50, 0x07, 0x2e, 0x7e, 0x15, 0xe4, 0x05, 0x80, 0xec, 0x2d,
+	0xd3, 0xb9, 0xcb, 0x34, 0x59, 0x78, 0xcb, 0x28, 0x8b, 0xd1, 0x7c, 0x96, 0x5c, 0x90, 0xe7, 0x10,
+	0x35, 0x5a, 0xf6, 0x2a, 0x2f, 0x07, 0x0a, 0xb8, 0x18, 0x62, 0xde, 0x0e, 0xae, 0xa6, 0x2d, 0x7e,
+	0x0d, 0xf4, 0x0a, 0x77, 0x90, 0xc9, 0x53, 0x08, 0x4a, 0x5d, 0x88, 0xea, 0x48, 0xaf, 0xa7, 0x96,
+	0x29, 0x91, 0xd7, 0x10, 0x36, 0xdc, 0xe6, 0xbd, 0x6e, 0x69, 0xe0, 0x5e, 0x6c, 0x61, 0xfc, 0x7b,
+	0x1f, 0x7c, 0xe4, 0x76, 0x9f, 0x7d, 0xc9, 0x82, 0x86, 0xdb, 0xbd, 0x6e, 0xc9, 0x3d, 0x24, 0xac,
+	0x53, 0x76, 0xc8, 0xdd, 0xbf, 0x1b, 0x1a, 0xe2, 0x77, 0x01, 0x95, 0x3b, 0x17, 0x43, 0x1e, 0x81,
+	0x6f, 0x36, 0x34, 0x46, 0xef, 0x9b, 0x0d, 0x79, 0x0c, 0x57, 0x07, 0xae, 0xe9, 0x2d, 0x56, 0x39,
+	0xdc, 0x7e, 0xfa, 0x3d, 0xce, 0xbd, 0x3f, 0xe3, 0xdc, 0x3b, 0x8f, 0x73, 0xef, 0xdb, 0xbb, 0x86,
+	0xdb, 0x63, 0x5f, 0xae, 0x2a, 0xd9, 0xa5, 0xaa, 0xa8, 0x8e, 0xc3, 0x81, 0xe9, 0xff, 0xe9, 0xb4,
+	0x4e, 0x8d, 0xae, 0xd2, 0x87, 0xae, 0xb9, 0x0c, 0xf0, 0xce, 0x36, 0xff, 0x06, 0x00, 0xc3, 0x13,
+	0x74, 0xd0, 0x05, 0x02, 0x00, 0x00,
 }
 
 func (m *Input) Marshal() (dAtA []byte, err error) {
@@ -209,6 +219,13 @@ func (m *Input) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if len(m.Dir) > 0 {
+		i -= len(m.Dir)
+		copy(dAtA[i:], m.Dir)
+		i = encodeVarintCommon(dAtA, i, uint64(len(m.Dir)))
+		i--
+		dAtA[i] = 0x62
+	}
 	if m.OuterJoin {
 		i--
 		if m.OuterJoin {
@@ -368,6 +385,10 @@ func (m *Input) Size() (n int) {
 	if m.OuterJoin {
 		n += 2
 	}
+	l = len(m.Dir)
+	if l > 0 {
+		n += 1 + l + sovCommon(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -721,6 +742,38 @@ func (m *Input) Unmarshal(dAtA []byte) error {
 				}
 			}
 			m.OuterJoin = bool(v != 0)
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Dir", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCommon
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthCommon
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthCommon
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Dir = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipCommon(dAtA[iNdEx:])
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+)
+
+func TestInputEnv(t *testing.T) {
+	inputs := []*Input{
+		{
+			Name:     "images",
+			FileInfo: &pfs.FileInfo{File: client.NewFile("images", "c1", "/a.png")},
+		},
+		{
+			Name:     "labels",
+			FileInfo: &pfs.FileInfo{File: client.NewFile("labels", "c2", "/dir")},
+		},
+	}
+	require.Equal(t, []string{
+		"images=/pfs/images/a.png",
+		"images_COMMIT=c1",
+		"labels=/pfs/labels/dir",
+		"labels_COMMIT=c2",
+	}, InputEnv("/pfs", inputs))
+}
+
+func TestWithJobStop(t *testing.T) {
+	ctx, stop := WithJobStop(context.Background())
+	datumCtx, cancelDatum := context.WithCancel(ctx)
+	// cancelling a datum doesn't stop its job
+	cancelDatum()
+	require.True(t, IsDone(datumCtx))
+	require.False(t, IsJobStopped(datumCtx))
+	require.False(t, IsDone(ctx))
+	stop()
+	require.True(t, IsDone(ctx))
+	require.True(t, IsJobStopped(ctx))
+	require.True(t, IsJobStopped(datumCtx))
+	require.False(t, IsJobStopped(context.Background()))
+}
@@ -0,0 +1,182 @@
+package common
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+)
+
+const (
+	cgroupRoot          = "/sys/fs/cgroup"
+	usageSampleInterval = 100 * time.Millisecond
+)
+
+// UsageSampler samples the CPU and memory usage of the worker's container,
+// as reported by its cgroup, while user code runs. User code only runs on one
+// datum in a worker at a time, so the usage is attributed to that datum. With
+// prefetch, the worker may be downloading or uploading other datums in the
+// same container meanwhile, and the usage includes that transfer work too.
+type UsageSampler struct {
+	cgroup    cgroup
+	startCPU  time.Duration
+	maxMemory uint64
+	mu        sync.Mutex
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewUsageSampler starts sampling resource usage. It returns nil if the
+// cgroup stats aren't available (e.g. when not running in a container).
+func NewUsageSampler() *UsageSampler {
+	return newUsageSampler(cgroupRoot)
+}
+
+func newUsageSampler(root string) *UsageSampler {
+	cg := detectCgroup(root)
+	if cg == nil {
+		return nil
+	}
+	startCPU, err := cg.cpu()
+	if err != nil {
+		return nil
+	}
+	s := &UsageSampler{
+		cgroup:   cg,
+		startCPU: startCPU,
+		done:     make(chan struct{}),
+	}
+	s.sampleMemory()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(usageSampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.sampleMemory()
+			case <-s.done:
+				return
+			}
+		}
+	}()
+	return s
+}
+
+func (s *UsageSampler) sampleMemory() {
+	memory, err := s.cgroup.memory()
+	if err != nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if memory > s.maxMemory {
+		s.maxMemory = memory
+	}
+}
+
+// Stop stops sampling, and returns the CPU time used since the sampler was
+// started and the highest memory usage seen.
+func (s *UsageSampler) Stop() (time.Duration, uint64) {
+	close(s.done)
+	s.wg.Wait()
+	s.sampleMemory()
+	var cpu time.Duration
+	if endCPU, err := s.cgroup.cpu(); err == nil && endCPU > s.startCPU {
+		cpu = endCPU - s.startCPU
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return cpu, s.maxMemory
+}
+
+// MemoryUsage returns the current memory usage of the worker's container, as
+// reported by its cgroup. It returns false if the cgroup stats aren't
+// available.
+func MemoryUsage() (uint64, bool) {
+	cg := detectCgroup(cgroupRoot)
+	if cg == nil {
+		return 0, false
+	}
+	memory, err := cg.memory()
+	return memory, err == nil
+}
+
+// cgroup reads usage from either a cgroup v1 or a cgroup v2 hierarchy.
+type cgroup interface {
+	cpu() (time.Duration, error)
+	memory() (uint64, error)
+}
+
+func detectCgroup(root string) cgroup {
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		return cgroupV2(root)
+	}
+	if _, err := os.Stat(filepath.Join(root, "memory", "memory.usage_in_bytes")); err == nil {
+		return cgroupV1(root)
+	}
+	return nil
+}
+
+type cgroupV1 string
+
+func (cg cgroupV1) cpu() (time.Duration, error) {
+	// cpuacct is mounted on its own or together with cpu, depending on the
+	// distribution
+	for _, dir := range []string{"cpuacct", "cpu,cpuacct"} {
+		usage, err := readUint(filepath.Join(string(cg), dir, "cpuacct.usage"))
+		if err == nil {
+			return time.Duration(usage), nil
+		}
+	}
+	return 0, errors.Errorf("could not read cpuacct.usage")
+}
+
+func (cg cgroupV1) memory() (uint64, error) {
+	return readUint(filepath.Join(string(cg), "memory", "memory.usage_in_bytes"))
+}
+
+type cgroupV2 string
+
+func (cg cgroupV2) cpu() (time.Duration, error) {
+	f, err := os.Open(filepath.Join(string(cg), "cpu.stat"))
+	if err != nil {
+		return 0, errors.EnsureStack(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "usage_usec" {
+			usec, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, errors.EnsureStack(err)
+			}
+			return time.Duration(usec) * time.Microsecond, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, errors.EnsureStack(err)
+	}
+	return 0, errors.Errorf("no usage_usec in cpu.stat")
+}
+
+func (cg cgroupV2) memory() (uint64, error) {
+	return readUint(filepath.Join(string(cg), "memory.current"))
+}
+
+func readUint(file string) (uint64, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return 0, errors.EnsureStack(err)
+	}
+	n, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	return n, errors.EnsureStack(err)
+}
@@ -0,0 +1,48 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+)
+
+func writeCgroupFile(t *testing.T, root, name, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, name), []byte(content), 0644))
+}
+
+func TestUsageSampler(t *testing.T) {
+	t.Run("V2", func(t *testing.T) {
+		root := t.TempDir()
+		writeCgroupFile(t, root, "cgroup.controllers", "cpu memory\n")
+		writeCgroupFile(t, root, "cpu.stat", "usage_usec 1000000\nuser_usec 800000\n")
+		writeCgroupFile(t, root, "memory.current", "1024\n")
+		s := newUsageSampler(root)
+		require.NotNil(t, s)
+		writeCgroupFile(t, root, "memory.current", "4096\n")
+		time.Sleep(3 * usageSampleInterval)
+		writeCgroupFile(t, root, "memory.current", "2048\n")
+		writeCgroupFile(t, root, "cpu.stat", "usage_usec 3500000\nuser_usec 3000000\n")
+		cpu, memory := s.Stop()
+		require.Equal(t, 2500*time.Millisecond, cpu)
+		require.Equal(t, uint64(4096), memory)
+	})
+	t.Run("V1", func(t *testing.T) {
+		root := t.TempDir()
+		writeCgroupFile(t, root, "memory/memory.usage_in_bytes", "1024\n")
+		writeCgroupFile(t, root, "cpu,cpuacct/cpuacct.usage", "1000000000\n")
+		s := newUsageSampler(root)
+		require.NotNil(t, s)
+		writeCgroupFile(t, root, "cpu,cpuacct/cpuacct.usage", "1500000000\n")
+		cpu, memory := s.Stop()
+		require.Equal(t, 500*time.Millisecond, cpu)
+		require.Equal(t, uint64(1024), memory)
+	})
+	t.Run("Unavailable", func(t *testing.T) {
+		require.True(t, newUsageSampler(t.TempDir()) == nil)
+	})
+}
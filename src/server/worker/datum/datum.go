@@ -4,12 +4,15 @@ import (
 	"archive/tar"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -46,6 +49,8 @@ type Client interface {
 	AppendFileTar(overwrite bool, r io.Reader, datum ...string) error
 	// CopyFile copies a file from src to dst.
 	CopyFile(dst string, src *pfs.File, tag string) error
+	// DeleteFile deletes a file.
+	DeleteFile(file string, tag ...string) error
 }
 
 const defaultDatumsPerSet = 10
@@ -97,6 +102,9 @@ type Set struct {
 	storageRoot                       string
 	metaOutputClient, pfsOutputClient Client
 	stats                             *Stats
+	stateClient                       StateClient
+	stateCommit                       *pfs.Commit
+	prefetcher                        *prefetcher
 }
 
 // WithSet provides a scoped environment for a datum set.
@@ -117,6 +125,13 @@ func WithSet(pachClient *client.APIClient, storageRoot string, cb func(*Set) err
 			retErr = err
 		}
 	}()
+	if s.prefetcher != nil {
+		defer func() {
+			if err := s.prefetcher.wait(); retErr == nil {
+				retErr = err
+			}
+		}()
+	}
 	return cb(s)
 }
 
@@ -124,14 +139,18 @@ func WithSet(pachClient *client.APIClient, storageRoot string, cb func(*Set) err
 // TODO: Handle datum concurrency here, and potentially move symlinking here.
 func (s *Set) WithDatum(ctx context.Context, meta *Meta, cb func(*Datum) error, opts ...Option) error {
 	d := newDatum(s, meta, opts...)
+	if s.prefetcher != nil {
+		d.prefetched = s.prefetcher.takePrefetched(d.ID)
+	}
 	cancelCtx, cancel := context.WithCancel(ctx)
-	attemptsLeft := d.numRetries + 1
+	d.attemptsLeft = d.numRetries + 1
 	return backoff.RetryUntilCancel(cancelCtx, func() error {
+		d.timedOut = false
 		return d.withData(func() (retErr error) {
 			defer func() {
-				attemptsLeft--
-				if retErr == nil || attemptsLeft == 0 {
-					retErr = d.finish(retErr)
+				d.attemptsLeft--
+				if retErr == nil || d.attemptsLeft == 0 {
+					retErr = d.finishOrUpload(retErr)
 					cancel()
 				}
 			}()
@@ -151,8 +170,22 @@ type Datum struct {
 	meta             *Meta
 	storageRoot      string
 	numRetries       int
+	attemptsLeft     int
 	recoveryCallback func(context.Context) error
+	failureCallback  func()
 	timeout          time.Duration
+	// timedOut is set if the current attempt at processing the datum failed
+	// because it exceeded the timeout.
+	timedOut bool
+	// stateHashes records the hashes of the state files downloaded for the
+	// current attempt (see downloadState).
+	stateHashes map[string]string
+	// prefetched is set to the download stats of the datum's inputs if they
+	// were downloaded ahead, until the first attempt uses them.
+	prefetched *pps.ProcessStats
+	// background is set if the datum is finished in the background, in which
+	// case its data is kept until then.
+	background bool
 }
 
 func newDatum(set *Set, meta *Meta, opts ...Option) *Datum {
@@ -182,18 +215,62 @@ func (d *Datum) MetaStorageRoot() string {
 	return path.Join(d.storageRoot, MetaPrefix, d.ID)
 }
 
+// finishOrUpload finishes the datum, in the background if the set prefetches
+// datums.
+func (d *Datum) finishOrUpload(err error) error {
+	if d.set.prefetcher == nil {
+		return d.finish(err)
+	}
+	d.background = true
+	return d.set.prefetcher.upload(d, func() error {
+		defer os.RemoveAll(d.PFSStorageRoot())
+		return d.finish(err)
+	})
+}
+
 func (d *Datum) finish(err error) (retErr error) {
 	defer func() {
 		if err := MergeProcessStats(d.set.stats.ProcessStats, d.meta.Stats); retErr == nil {
 			retErr = err
 		}
 	}()
+	// The previous meta isn't part of the datum's output.
+	prev := d.meta.Previous
+	d.meta.Previous = nil
 	if err != nil {
 		d.handleFailed(err)
+		if err := d.deletePrevious(prev, true); err != nil {
+			return err
+		}
 		return d.uploadMetaOutput()
 	}
 	d.set.stats.Processed++
-	return d.uploadOutput()
+	if err := d.uploadState(); err != nil {
+		return err
+	}
+	return d.uploadOutput(prev)
+}
+
+// deletePrevious deletes the previous output of the datum, described by
+// 'prev', which the datum's new output replaces. The files the datum wrote
+// are only deleted if 'output' is true.
+func (d *Datum) deletePrevious(prev *Meta, output bool) error {
+	if prev == nil {
+		return nil
+	}
+	if d.set.metaOutputClient != nil {
+		if err := deleteMeta(d.set.metaOutputClient, d.ID); err != nil {
+			return err
+		}
+	}
+	if output && d.set.pfsOutputClient != nil {
+		for _, file := range prev.Outputs {
+			if err := d.set.pfsOutputClient.DeleteFile(file, d.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
 
 func (d *Datum) handleFailed(err error) {
@@ -204,6 +281,10 @@ func (d *Datum) handleFailed(err error) {
 	d.meta.State = State_FAILED
 	d.meta.Reason = err.Error()
 	d.set.stats.Failed++
+	if d.timedOut {
+		d.meta.Reason = fmt.Sprintf("datum timed out after %v: %v", d.timeout, err)
+		d.set.stats.TimedOut++
+	}
 	if d.set.stats.FailedID == "" {
 		d.set.stats.FailedID = d.ID
 	}
@@ -215,15 +296,26 @@ func (d *Datum) withData(cb func() error) (retErr error) {
 		return err
 	}
 	defer func() {
+		if d.background {
+			return
+		}
 		if err := os.RemoveAll(d.PFSStorageRoot()); retErr == nil {
 			retErr = err
 		}
 	}()
 	return pfssync.WithDownloader(d.set.pachClient, func(downloader pfssync.Downloader) error {
-		// TODO: Move to copy file for inputs to datum file set.
-		if err := d.downloadData(downloader); err != nil {
+		if d.prefetched != nil {
+			d.usePrefetched(d.prefetched)
+			d.prefetched = nil
+		} else if err := d.downloadData(downloader); err != nil {
+			// TODO: Move to copy file for inputs to datum file set.
 			return err
 		}
+		if d.set.stateClient != nil {
+			if err := d.downloadState(downloader); err != nil {
+				return err
+			}
+		}
 		return cb()
 	})
 }
@@ -252,7 +344,7 @@ func (d *Datum) downloadData(downloader pfssync.Downloader) error {
 		if input.EmptyFiles {
 			opts = append(opts, pfssync.WithEmpty())
 		}
-		if err := downloader.Download(path.Join(d.PFSStorageRoot(), input.Name), input.FileInfo.File, opts...); err != nil {
+		if err := downloader.Download(path.Join(d.PFSStorageRoot(), input.Name, input.Dir), input.FileInfo.File, opts...); err != nil {
 			return err
 		}
 	}
@@ -262,13 +354,23 @@ func (d *Datum) downloadData(downloader pfssync.Downloader) error {
 // Run provides a scoped environment for the processing of a datum.
 func (d *Datum) Run(ctx context.Context, cb func(ctx context.Context) error) error {
 	start := time.Now()
+	sampler := common.NewUsageSampler()
 	defer func() {
 		d.meta.Stats.ProcessTime = types.DurationProto(time.Since(start))
+		if sampler != nil {
+			cpu, memory := sampler.Stop()
+			d.meta.Stats.CPUSeconds = cpu.Seconds()
+			d.meta.Stats.MaxMemoryBytes = memory
+		}
 	}()
 	if d.timeout > 0 {
 		timeoutCtx, cancel := context.WithTimeout(ctx, d.timeout)
 		defer cancel()
-		return d.run(timeoutCtx, cb)
+		err := d.run(timeoutCtx, cb)
+		if err != nil && ctx.Err() == nil && timeoutCtx.Err() == context.DeadlineExceeded {
+			d.timedOut = true
+		}
+		return err
 	}
 	return d.run(ctx, cb)
 }
@@ -283,6 +385,9 @@ func (d *Datum) run(ctx context.Context, cb func(ctx context.Context) error) (re
 					d.meta.State = State_RECOVERED
 				}
 			}
+			if d.failureCallback != nil && d.attemptsLeft == 1 && d.meta.State != State_RECOVERED {
+				d.failureCallback()
+			}
 		}
 	}()
 	return cb(ctx)
@@ -313,10 +418,24 @@ func (d *Datum) uploadMetaOutput() (retErr error) {
 	return nil
 }
 
-func (d *Datum) uploadOutput() error {
+// uploadOutput uploads the datum's output, unless it's identical to the
+// datum's previous output, described by 'prev', which is kept instead.
+func (d *Datum) uploadOutput(prev *Meta) error {
 	if d.set.pfsOutputClient != nil {
 		start := time.Now()
 		d.meta.Stats.UploadBytes = 0
+		if err := d.recordOutputs(); err != nil {
+			return err
+		}
+		if prev != nil && d.meta.OutputHash != "" && d.meta.OutputHash == prev.OutputHash {
+			if err := d.deletePrevious(prev, false); err != nil {
+				return err
+			}
+			return d.uploadMetaOutput()
+		}
+		if err := d.deletePrevious(prev, true); err != nil {
+			return err
+		}
 		if err := d.upload(d.set.pfsOutputClient, path.Join(d.PFSStorageRoot(), OutputPrefix), func(hdr *tar.Header) error {
 			d.meta.Stats.UploadBytes += uint64(hdr.Size)
 			return nil
@@ -324,10 +443,70 @@ func (d *Datum) uploadOutput() error {
 			return err
 		}
 		d.meta.Stats.UploadTime = types.DurationProto(time.Since(start))
+		if d.background {
+			addOverlappedTime(d.meta.Stats, time.Since(start))
+		}
+	} else if err := d.deletePrevious(prev, false); err != nil {
+		return err
 	}
 	return d.uploadMetaOutput()
 }
 
+// recordOutputs records the files in the datum's output directory, and a hash
+// of them, in its meta, so the datum that produced an output file can be
+// looked up later, and identical output isn't uploaded again.
+func (d *Datum) recordOutputs() error {
+	d.meta.Outputs = nil
+	d.meta.OutputHash = ""
+	outputDir := path.Join(d.PFSStorageRoot(), OutputPrefix)
+	symlinks := false
+	if err := filepath.Walk(outputDir, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			symlinks = true
+		}
+		relPath, err := filepath.Rel(outputDir, file)
+		if err != nil {
+			return err
+		}
+		d.meta.Outputs = append(d.meta.Outputs, "/"+filepath.ToSlash(relPath))
+		return nil
+	}); err != nil {
+		return err
+	}
+	// Symlinked output is copied from the inputs, and the files it links to
+	// may have changed, so it's always uploaded.
+	if symlinks {
+		return nil
+	}
+	hashes, err := hashFiles(outputDir)
+	if err != nil {
+		return err
+	}
+	d.meta.OutputHash = outputHash(hashes)
+	return nil
+}
+
+// outputHash combines the hashes of a datum's output files, keyed by path, into
+// a single hash.
+func outputHash(hashes map[string]string) string {
+	var files []string
+	for file := range hashes {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	h := sha256.New()
+	for _, file := range files {
+		fmt.Fprintf(h, "%s\x00%s\n", file, hashes[file])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 func (d *Datum) upload(c Client, storageRoot string, cb ...func(*tar.Header) error) error {
 	// TODO: Might make more sense to convert to tar on the fly.
 	f, err := os.Create(path.Join(d.set.storageRoot, TmpFileName))
@@ -383,15 +562,19 @@ type DeleteClient interface {
 // Deleter deletes a datum.
 type Deleter func(*Meta) error
 
+// deleteMeta deletes the datum directory of the datum 'ID' in the meta output.
+func deleteMeta(metaOutputClient DeleteClient, ID string) error {
+	if err := metaOutputClient.DeleteFile(path.Join(MetaPrefix, ID) + "/"); err != nil {
+		return err
+	}
+	return metaOutputClient.DeleteFile(path.Join(PFSPrefix, ID) + "/")
+}
+
 // NewDeleter creates a new deleter.
 func NewDeleter(metaFileWalker fileWalkerFunc, metaOutputClient, pfsOutputClient DeleteClient) Deleter {
 	return func(meta *Meta) error {
 		ID := common.DatumID(meta.Inputs)
-		// Delete the datum directory in the meta output.
-		if err := metaOutputClient.DeleteFile(path.Join(MetaPrefix, ID) + "/"); err != nil {
-			return err
-		}
-		if err := metaOutputClient.DeleteFile(path.Join(PFSPrefix, ID) + "/"); err != nil {
+		if err := deleteMeta(metaOutputClient, ID); err != nil {
 			return err
 		}
 		// Delete the content output by the datum.
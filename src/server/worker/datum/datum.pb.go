@@ -54,15 +54,24 @@ func (State) EnumDescriptor() ([]byte, []int) {
 }
 
 type Meta struct {
-	JobID                string            `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
-	Inputs               []*common.Input   `protobuf:"bytes,2,rep,name=inputs,proto3" json:"inputs,omitempty"`
-	Hash                 string            `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
-	State                State             `protobuf:"varint,4,opt,name=state,proto3,enum=datum.State" json:"state,omitempty"`
-	Reason               string            `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
-	Stats                *pps.ProcessStats `protobuf:"bytes,6,opt,name=stats,proto3" json:"stats,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
-	XXX_unrecognized     []byte            `json:"-"`
-	XXX_sizecache        int32             `json:"-"`
+	JobID  string            `protobuf:"bytes,1,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	Inputs []*common.Input   `protobuf:"bytes,2,rep,name=inputs,proto3" json:"inputs,omitempty"`
+	Hash   string            `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	State  State             `protobuf:"varint,4,opt,name=state,proto3,enum=datum.State" json:"state,omitempty"`
+	Reason string            `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	Stats  *pps.ProcessStats `protobuf:"bytes,6,opt,name=stats,proto3" json:"stats,omitempty"`
+	// outputs are the files the datum wrote, relative to its output directory
+	Outputs []string `protobuf:"bytes,7,rep,name=outputs,proto3" json:"outputs,omitempty"`
+	// output_hash is a hash of the files the datum wrote, which is compared with
+	// the datum's previous output to skip uploading identical output. It's empty
+	// if the output can't be compared, e.g. because it contains symlinks.
+	OutputHash string `protobuf:"bytes,8,opt,name=output_hash,json=outputHash,proto3" json:"output_hash,omitempty"`
+	// previous is the datum's meta in the previous job's output, which its new
+	// output replaces. It's only set while the datum is being processed.
+	Previous             *Meta    `protobuf:"bytes,9,opt,name=previous,proto3" json:"previous,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *Meta) Reset()         { *m = Meta{} }
@@ -140,16 +149,40 @@ func (m *Meta) GetStats() *pps.ProcessStats {
 	return nil
 }
 
+func (m *Meta) GetOutputs() []string {
+	if m != nil {
+		return m.Outputs
+	}
+	return nil
+}
+
+func (m *Meta) GetOutputHash() string {
+	if m != nil {
+		return m.OutputHash
+	}
+	return ""
+}
+
+func (m *Meta) GetPrevious() *Meta {
+	if m != nil {
+		return m.Previous
+	}
+	return nil
+}
+
 type Stats struct {
-	ProcessStats         *pps.ProcessStats `protobuf:"bytes,1,opt,name=process_stats,json=processStats,proto3" json:"process_stats,omitempty"`
-	Processed            int64             `protobuf:"varint,2,opt,name=processed,proto3" json:"processed,omitempty"`
-	Skipped              int64             `protobuf:"varint,3,opt,name=skipped,proto3" json:"skipped,omitempty"`
-	Failed               int64             `protobuf:"varint,4,opt,name=failed,proto3" json:"failed,omitempty"`
-	Recovered            int64             `protobuf:"varint,5,opt,name=recovered,proto3" json:"recovered,omitempty"`
-	FailedID             string            `protobuf:"bytes,6,opt,name=failed_id,json=failedId,proto3" json:"failed_id,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
-	XXX_unrecognized     []byte            `json:"-"`
-	XXX_sizecache        int32             `json:"-"`
+	ProcessStats *pps.ProcessStats `protobuf:"bytes,1,opt,name=process_stats,json=processStats,proto3" json:"process_stats,omitempty"`
+	Processed    int64             `protobuf:"varint,2,opt,name=processed,proto3" json:"processed,omitempty"`
+	Skipped      int64             `protobuf:"varint,3,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	Failed       int64             `protobuf:"varint,4,opt,name=failed,proto3" json:"failed,omitempty"`
+	Recovered    int64             `protobuf:"varint,5,opt,name=recovered,proto3" json:"recovered,omitempty"`
+	FailedID     string            `protobuf:"bytes,6,opt,name=failed_id,json=failedId,proto3" json:"failed_id,omitempty"`
+	// timed_out is the number of failed datums that failed because they timed
+	// out.
+	TimedOut             int64    `protobuf:"varint,7,opt,name=timed_out,json=timedOut,proto3" json:"timed_out,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *Stats) Reset()         { *m = Stats{} }
@@ -227,6 +260,13 @@ func (m *Stats) GetFailedID() string {
 	return ""
 }
 
+func (m *Stats) GetTimedOut() int64 {
+	if m != nil {
+		return m.TimedOut
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterEnum("datum.State", State_name, State_value)
 	proto.RegisterType((*Meta)(nil), "datum.Meta")
@@ -236,35 +276,39 @@ func init() {
 func init() { proto.RegisterFile("server/worker/datum/datum.proto", fileDescriptor_96ec7427544ac634) }
 
 var fileDescriptor_96ec7427544ac634 = []byte{
-	// 434 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x52, 0x41, 0x8b, 0xd4, 0x30,
-	0x18, 0x35, 0xd3, 0x69, 0xdd, 0x66, 0xa7, 0x32, 0x06, 0x91, 0xb0, 0xc8, 0x4c, 0x1d, 0x10, 0xab,
-	0x87, 0x16, 0x2b, 0x08, 0x1e, 0xdd, 0x6d, 0x57, 0x2a, 0xca, 0x2e, 0x19, 0xf0, 0xe0, 0x65, 0x69,
-	0x9b, 0x38, 0xed, 0xae, 0x33, 0x09, 0x49, 0x67, 0xc4, 0x7f, 0xb8, 0x47, 0x8f, 0x9e, 0x16, 0xe9,
-	0x2f, 0x91, 0x24, 0x95, 0x1d, 0xc1, 0xbd, 0xb4, 0xdf, 0x7b, 0xef, 0x7b, 0x8f, 0xbe, 0x34, 0x70,
-	0xae, 0x98, 0xdc, 0x31, 0x99, 0x7c, 0xe7, 0xf2, 0x8a, 0xc9, 0x84, 0x96, 0xdd, 0x76, 0x6d, 0x9f,
-	0xb1, 0x90, 0xbc, 0xe3, 0xc8, 0x35, 0xe0, 0xe8, 0xd1, 0x8a, 0xaf, 0xb8, 0x61, 0x12, 0x3d, 0x59,
-	0xf1, 0x28, 0x10, 0x42, 0x25, 0x42, 0xa8, 0x01, 0x3e, 0xfd, 0x37, 0xac, 0xe6, 0xeb, 0x35, 0xdf,
-	0x0c, 0x2f, 0xbb, 0xb2, 0xb8, 0x06, 0x70, 0xfc, 0x89, 0x75, 0x25, 0x0a, 0xa1, 0x77, 0xc9, 0xab,
-	0x8b, 0x96, 0x62, 0x10, 0x82, 0xc8, 0x3f, 0xf6, 0xfb, 0x9b, 0xb9, 0xfb, 0x81, 0x57, 0x45, 0x46,
-	0xdc, 0x4b, 0x5e, 0x15, 0x14, 0x3d, 0x83, 0x5e, 0xbb, 0x11, 0xdb, 0x4e, 0xe1, 0x51, 0xe8, 0x44,
-	0x87, 0x69, 0x10, 0x0f, 0x49, 0x85, 0x66, 0xc9, 0x20, 0x22, 0x04, 0xc7, 0x4d, 0xa9, 0x1a, 0xec,
-	0xe8, 0x18, 0x62, 0x66, 0xb4, 0x80, 0xae, 0xea, 0xca, 0x8e, 0xe1, 0x71, 0x08, 0xa2, 0x07, 0xe9,
-	0x24, 0xb6, 0x8d, 0x96, 0x9a, 0x23, 0x56, 0x42, 0x8f, 0xa1, 0x27, 0x59, 0xa9, 0xf8, 0x06, 0xbb,
-	0xc6, 0x39, 0x20, 0xf4, 0xdc, 0x7a, 0x15, 0xf6, 0x42, 0x10, 0x1d, 0xa6, 0x0f, 0x63, 0xdd, 0xef,
-	0x5c, 0xf2, 0x9a, 0x29, 0xa5, 0x03, 0x94, 0x0d, 0x50, 0x8b, 0x5f, 0x00, 0xba, 0x86, 0x40, 0x6f,
-	0x60, 0x20, 0xec, 0xc2, 0x85, 0xb5, 0x82, 0xbb, 0xac, 0x13, 0xb1, 0x87, 0xd0, 0x13, 0xe8, 0x0f,
-	0x98, 0x51, 0x3c, 0x0a, 0x41, 0xe4, 0x90, 0x5b, 0x02, 0x61, 0x78, 0x5f, 0x5d, 0xb5, 0x42, 0x30,
-	0x6a, 0xba, 0x39, 0xe4, 0x2f, 0xd4, 0x9f, 0xfe, 0xb5, 0x6c, 0xbf, 0x31, 0x6a, 0xfa, 0x39, 0x64,
-	0x40, 0x3a, 0x4f, 0xb2, 0x9a, 0xef, 0x98, 0x64, 0xd4, 0xb4, 0x72, 0xc8, 0x2d, 0x81, 0x5e, 0x40,
-	0xdf, 0xee, 0xe9, 0x43, 0xf7, 0xcc, 0xa1, 0x4f, 0xfa, 0x9b, 0xf9, 0xc1, 0xa9, 0x21, 0x8b, 0x8c,
-	0x1c, 0x58, 0xb9, 0xa0, 0x2f, 0x5f, 0xd9, 0x66, 0x0c, 0x05, 0xd0, 0x3f, 0x27, 0x67, 0x27, 0xf9,
-	0x72, 0x99, 0x67, 0xd3, 0x7b, 0x08, 0x42, 0xef, 0xf4, 0x5d, 0xf1, 0x31, 0xcf, 0xa6, 0x40, 0x4b,
-	0x24, 0x3f, 0x39, 0xfb, 0x9c, 0x93, 0x3c, 0x9b, 0x8e, 0x8e, 0xdf, 0x5f, 0xf7, 0x33, 0xf0, 0xb3,
-	0x9f, 0x81, 0xdf, 0xfd, 0x0c, 0x7c, 0x79, 0xbb, 0x6a, 0xbb, 0x66, 0x5b, 0xe9, 0x3f, 0x96, 0x88,
-	0xb2, 0x6e, 0x7e, 0x50, 0x26, 0xf7, 0xa7, 0x5d, 0x9a, 0x28, 0x59, 0x27, 0xff, 0xb9, 0x7c, 0x95,
-	0x67, 0x2e, 0xca, 0xeb, 0x3f, 0x01, 0x00, 0x00, 0xff, 0xff, 0x0e, 0xdb, 0xa3, 0x1b, 0x9a, 0x02,
-	0x00, 0x00,
+	// 501 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x52, 0xd1, 0x6e, 0xd3, 0x3c,
+	0x18, 0xfd, 0xdd, 0x34, 0x69, 0xe3, 0xb6, 0xbf, 0x8a, 0x85, 0x90, 0x35, 0x50, 0x1b, 0x2a, 0xa1,
+	0x05, 0x2e, 0x1a, 0x51, 0x24, 0x24, 0x2e, 0xd9, 0x9a, 0x41, 0x10, 0xa8, 0x93, 0x2b, 0x71, 0xc1,
+	0x4d, 0x95, 0xc6, 0xa6, 0xcd, 0x46, 0x6b, 0xcb, 0x76, 0x8a, 0x78, 0x27, 0x1e, 0x84, 0x4b, 0x9e,
+	0x60, 0x42, 0x79, 0x07, 0xee, 0x91, 0xed, 0x8c, 0x0d, 0x09, 0x6e, 0x92, 0xef, 0x9c, 0x63, 0x1f,
+	0x9f, 0xef, 0xb3, 0xe1, 0x58, 0x31, 0x79, 0x60, 0x32, 0xf9, 0xcc, 0xe5, 0x25, 0x93, 0x09, 0xcd,
+	0x75, 0xb5, 0x73, 0xdf, 0xa9, 0x90, 0x5c, 0x73, 0xe4, 0x5b, 0x70, 0x74, 0x77, 0xc3, 0x37, 0xdc,
+	0x32, 0x89, 0xa9, 0x9c, 0x78, 0x34, 0x10, 0x42, 0x25, 0x42, 0xa8, 0x06, 0x3e, 0xfc, 0xd3, 0xac,
+	0xe0, 0xbb, 0x1d, 0xdf, 0x37, 0x3f, 0xb7, 0x64, 0xf2, 0xb5, 0x05, 0xdb, 0xef, 0x98, 0xce, 0x51,
+	0x04, 0x83, 0x0b, 0xbe, 0x5e, 0x95, 0x14, 0x83, 0x08, 0xc4, 0xe1, 0x49, 0x58, 0x5f, 0x8d, 0xfd,
+	0x37, 0x7c, 0x9d, 0xcd, 0x89, 0x7f, 0xc1, 0xd7, 0x19, 0x45, 0x8f, 0x60, 0x50, 0xee, 0x45, 0xa5,
+	0x15, 0x6e, 0x45, 0x5e, 0xdc, 0x9b, 0x0d, 0xa6, 0x8d, 0x53, 0x66, 0x58, 0xd2, 0x88, 0x08, 0xc1,
+	0xf6, 0x36, 0x57, 0x5b, 0xec, 0x19, 0x1b, 0x62, 0x6b, 0x34, 0x81, 0xbe, 0xd2, 0xb9, 0x66, 0xb8,
+	0x1d, 0x81, 0xf8, 0xff, 0x59, 0x7f, 0xea, 0x3a, 0x5a, 0x1a, 0x8e, 0x38, 0x09, 0xdd, 0x83, 0x81,
+	0x64, 0xb9, 0xe2, 0x7b, 0xec, 0xdb, 0x9d, 0x0d, 0x42, 0xc7, 0x6e, 0xaf, 0xc2, 0x41, 0x04, 0xe2,
+	0xde, 0xec, 0xce, 0xd4, 0xf4, 0x77, 0x2e, 0x79, 0xc1, 0x94, 0x32, 0x06, 0xca, 0x19, 0x28, 0x84,
+	0x61, 0x87, 0x57, 0xda, 0x06, 0xec, 0x44, 0x5e, 0x1c, 0x92, 0x6b, 0x88, 0xc6, 0xb0, 0xe7, 0xca,
+	0x95, 0x4d, 0xd6, 0xb5, 0xfe, 0xd0, 0x51, 0xaf, 0x4d, 0xbe, 0x63, 0xd8, 0x15, 0x92, 0x1d, 0x4a,
+	0x5e, 0x29, 0x1c, 0xda, 0x63, 0x7a, 0x4d, 0x44, 0x33, 0x1b, 0xf2, 0x5b, 0x9c, 0xfc, 0x04, 0xd0,
+	0xb7, 0x87, 0xa2, 0xe7, 0x70, 0x20, 0x5c, 0x88, 0x95, 0x8b, 0x07, 0xfe, 0x15, 0xaf, 0x2f, 0x6e,
+	0x21, 0xf4, 0x00, 0x86, 0x0d, 0x66, 0x14, 0xb7, 0x22, 0x10, 0x7b, 0xe4, 0x86, 0x30, 0x3d, 0xa8,
+	0xcb, 0x52, 0x08, 0x46, 0xed, 0xfc, 0x3c, 0x72, 0x0d, 0xcd, 0x78, 0x3e, 0xe6, 0xe5, 0x27, 0x46,
+	0xed, 0x0c, 0x3d, 0xd2, 0x20, 0xe3, 0x27, 0x59, 0xc1, 0x0f, 0x4c, 0x32, 0x6a, 0x27, 0xe7, 0x91,
+	0x1b, 0x02, 0x3d, 0x86, 0xa1, 0x5b, 0x67, 0x2e, 0x36, 0xb0, 0x17, 0xdb, 0xaf, 0xaf, 0xc6, 0xdd,
+	0x33, 0x4b, 0x66, 0x73, 0xd2, 0x75, 0x72, 0x46, 0xd1, 0x7d, 0x18, 0xea, 0x72, 0xc7, 0xe8, 0x8a,
+	0x57, 0x1a, 0x77, 0xac, 0x51, 0xd7, 0x12, 0x8b, 0x4a, 0x3f, 0x79, 0xea, 0xda, 0x66, 0x68, 0x00,
+	0xc3, 0x73, 0xb2, 0x38, 0x4d, 0x97, 0xcb, 0x74, 0x3e, 0xfc, 0x0f, 0x41, 0x18, 0x9c, 0xbd, 0xcc,
+	0xde, 0xa6, 0xf3, 0x21, 0x30, 0x12, 0x49, 0x4f, 0x17, 0xef, 0x53, 0x92, 0xce, 0x87, 0xad, 0x93,
+	0x57, 0xdf, 0xea, 0x11, 0xf8, 0x5e, 0x8f, 0xc0, 0x8f, 0x7a, 0x04, 0x3e, 0xbc, 0xd8, 0x94, 0x7a,
+	0x5b, 0xad, 0xcd, 0x93, 0x49, 0x44, 0x5e, 0x6c, 0xbf, 0x50, 0x26, 0x6f, 0x57, 0x87, 0x59, 0xa2,
+	0x64, 0x91, 0xfc, 0xe5, 0xf5, 0xaf, 0x03, 0xfb, 0x52, 0x9f, 0xfd, 0x1a, 0x00, 0x4d, 0x0f, 0x7e,
+	0x5b, 0x1b, 0x03, 0x00, 0x00,
 }
 
 func (m *Meta) Marshal() (dAtA []byte, err error) {
@@ -291,6 +335,34 @@ func (m *Meta) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.Previous != nil {
+		{
+			size, err := m.Previous.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintDatum(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x4a
+	}
+	if len(m.OutputHash) > 0 {
+		i -= len(m.OutputHash)
+		copy(dAtA[i:], m.OutputHash)
+		i = encodeVarintDatum(dAtA, i, uint64(len(m.OutputHash)))
+		i--
+		dAtA[i] = 0x42
+	}
+	if len(m.Outputs) > 0 {
+		for iNdEx := len(m.Outputs) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Outputs[iNdEx])
+			copy(dAtA[i:], m.Outputs[iNdEx])
+			i = encodeVarintDatum(dAtA, i, uint64(len(m.Outputs[iNdEx])))
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
 	if m.Stats != nil {
 		{
 			size, err := m.Stats.MarshalToSizedBuffer(dAtA[:i])
@@ -370,6 +442,11 @@ func (m *Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.TimedOut != 0 {
+		i = encodeVarintDatum(dAtA, i, uint64(m.TimedOut))
+		i--
+		dAtA[i] = 0x38
+	}
 	if len(m.FailedID) > 0 {
 		i -= len(m.FailedID)
 		copy(dAtA[i:], m.FailedID)
@@ -454,6 +531,20 @@ func (m *Meta) Size() (n int) {
 		l = m.Stats.Size()
 		n += 1 + l + sovDatum(uint64(l))
 	}
+	if len(m.Outputs) > 0 {
+		for _, s := range m.Outputs {
+			l = len(s)
+			n += 1 + l + sovDatum(uint64(l))
+		}
+	}
+	l = len(m.OutputHash)
+	if l > 0 {
+		n += 1 + l + sovDatum(uint64(l))
+	}
+	if m.Previous != nil {
+		l = m.Previous.Size()
+		n += 1 + l + sovDatum(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -486,6 +577,9 @@ func (m *Stats) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovDatum(uint64(l))
 	}
+	if m.TimedOut != 0 {
+		n += 1 + sovDatum(uint64(m.TimedOut))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -712,6 +806,106 @@ func (m *Meta) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Outputs", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDatum
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDatum
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthDatum
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Outputs = append(m.Outputs, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OutputHash", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDatum
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthDatum
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthDatum
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.OutputHash = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Previous", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDatum
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthDatum
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthDatum
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Previous == nil {
+				m.Previous = &Meta{}
+			}
+			if err := m.Previous.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipDatum(dAtA[iNdEx:])
@@ -907,6 +1101,25 @@ func (m *Stats) Unmarshal(dAtA []byte) error {
 			}
 			m.FailedID = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TimedOut", wireType)
+			}
+			m.TimedOut = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowDatum
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TimedOut |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipDatum(dAtA[iNdEx:])
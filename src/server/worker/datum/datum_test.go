@@ -1,5 +1,79 @@
 package datum
 
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	"github.com/pachyderm/pachyderm/v2/src/server/worker/common"
+)
+
+type testOutputClient struct {
+	appended int
+	deleted  []string
+}
+
+func (c *testOutputClient) AppendFileTar(_ bool, r io.Reader, _ ...string) error {
+	c.appended++
+	_, err := io.Copy(ioutil.Discard, r)
+	return err
+}
+
+func (c *testOutputClient) CopyFile(_ string, _ *pfs.File, _ string) error {
+	return nil
+}
+
+func (c *testOutputClient) DeleteFile(file string, _ ...string) error {
+	c.deleted = append(c.deleted, file)
+	return nil
+}
+
+func TestUploadOutputDedup(t *testing.T) {
+	metaClient, pfsClient := &testOutputClient{}, &testOutputClient{}
+	s := &Set{
+		storageRoot:      t.TempDir(),
+		metaOutputClient: metaClient,
+		pfsOutputClient:  pfsClient,
+	}
+	newOutputDatum := func(output string) *Datum {
+		d := newDatum(s, &Meta{Inputs: []*common.Input{{GroupBy: "datum"}}})
+		outputDir := filepath.Join(d.PFSStorageRoot(), OutputPrefix)
+		require.NoError(t, os.MkdirAll(outputDir, 0700))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(outputDir, "file"), []byte(output), 0600))
+		return d
+	}
+	d := newOutputDatum("output")
+	require.NoError(t, d.uploadOutput(nil))
+	require.Equal(t, 1, pfsClient.appended)
+	require.NotEqual(t, "", d.meta.OutputHash)
+	prev := d.meta
+
+	// identical output keeps the previous output, and only replaces the meta
+	d = newOutputDatum("output")
+	require.NoError(t, d.uploadOutput(prev))
+	require.Equal(t, 1, pfsClient.appended)
+	require.Equal(t, 0, len(pfsClient.deleted))
+	require.Equal(t, 2, metaClient.appended)
+	require.Equal(t, []string{"meta/datum/", "pfs/datum/"}, metaClient.deleted)
+
+	// changed output replaces the previous output
+	d = newOutputDatum("changed")
+	require.NoError(t, d.uploadOutput(prev))
+	require.Equal(t, 2, pfsClient.appended)
+	require.Equal(t, []string{"/file"}, pfsClient.deleted)
+
+	// symlinked output is always uploaded
+	d = newOutputDatum("changed")
+	outputDir := filepath.Join(d.PFSStorageRoot(), OutputPrefix)
+	require.NoError(t, os.Symlink(filepath.Join(outputDir, "file"), filepath.Join(outputDir, "link")))
+	require.NoError(t, d.recordOutputs())
+	require.Equal(t, "", d.meta.OutputHash)
+}
+
 // TODO: This test needs to be reworked.
 //func TestSet(t *testing.T) {
 //	t.Parallel()
@@ -5,13 +5,17 @@ import (
 	"io"
 	"path"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/cevaris/ordered_map"
 	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/types"
 	glob "github.com/pachyderm/ohmyglob"
 
 	"github.com/pachyderm/pachyderm/v2/src/client"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errutil"
 	"github.com/pachyderm/pachyderm/v2/src/internal/stream"
 	"github.com/pachyderm/pachyderm/v2/src/pfs"
 	"github.com/pachyderm/pachyderm/v2/src/pps"
@@ -136,6 +140,170 @@ func newCronIterator(pachClient *client.APIClient, input *pps.CronInput) Iterato
 	})
 }
 
+func newSQLIterator(pachClient *client.APIClient, input *pps.SQLInput) Iterator {
+	return newPFSIterator(pachClient, &pps.PFSInput{
+		Name:   input.Name,
+		Repo:   input.Repo,
+		Branch: "master",
+		Commit: input.Commit,
+		Glob:   "/*",
+	})
+}
+
+type windowIterator struct {
+	pachClient *client.APIClient
+	input      *pps.WindowInput
+}
+
+func newWindowIterator(pachClient *client.APIClient, input *pps.WindowInput) Iterator {
+	if input.Commit == "" {
+		// this can happen if a pipeline with multiple inputs has been triggered
+		// before all commits have inputs
+		return &windowIterator{}
+	}
+	return &windowIterator{
+		pachClient: pachClient,
+		input:      input,
+	}
+}
+
+func (wi *windowIterator) Iterate(cb func(*Meta) error) error {
+	if wi.input == nil {
+		return nil
+	}
+	commitInfos, err := wi.windowCommits()
+	if err != nil {
+		return err
+	}
+	// Replay the changes in the window from oldest to newest, so that a file
+	// which is deleted within the window is not exposed.
+	changed := make(map[string]*pfs.FileInfo)
+	for i := len(commitInfos) - 1; i >= 0; i-- {
+		if err := wi.pachClient.DiffFile(wi.input.Repo, commitInfos[i].Commit.ID, "/", "", "", "", false, func(newFile, oldFile *pfs.FileInfo) error {
+			if newFile != nil {
+				if newFile.FileType == pfs.FileType_FILE {
+					changed[newFile.File.Path] = newFile
+				}
+				return nil
+			}
+			if oldFile != nil {
+				delete(changed, oldFile.File.Path)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	g, err := glob.Compile(wi.input.Glob, '/')
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	var paths []string
+	for p := range changed {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	datums := make(map[string][]*common.Input)
+	for _, p := range paths {
+		match, ok := windowMatch(g, p)
+		if !ok {
+			continue
+		}
+		fi := changed[p]
+		// Every file is read from the input commit, which holds the latest
+		// version of each file changed within the window.
+		fi.File = client.NewFile(wi.input.Repo, wi.input.Commit, p)
+		input := &common.Input{
+			FileInfo:   fi,
+			Name:       wi.input.Name,
+			Lazy:       wi.input.Lazy,
+			Branch:     wi.input.Branch,
+			EmptyFiles: wi.input.EmptyFiles,
+		}
+		if input.Lazy || input.EmptyFiles {
+			// Lazy and empty downloads place a file by its base name, so
+			// keep the file at its full path, as a regular download does.
+			input.Dir = strings.TrimPrefix(path.Dir(p), "/")
+		}
+		datums[match] = append(datums[match], input)
+	}
+	var metas []*Meta
+	for _, inputs := range datums {
+		metas = append(metas, &Meta{Inputs: inputs})
+	}
+	sort.Slice(metas, func(i, j int) bool {
+		return common.DatumID(metas[i].Inputs) < common.DatumID(metas[j].Inputs)
+	})
+	for _, meta := range metas {
+		if err := cb(meta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// windowCommits returns the commits in the window, newest first.
+func (wi *windowIterator) windowCommits() ([]*pfs.CommitInfo, error) {
+	var number uint64
+	if wi.input.Commits > 0 {
+		number = uint64(wi.input.Commits)
+	}
+	var cutoff time.Time
+	if wi.input.Duration != nil {
+		duration, err := types.DurationFromProto(wi.input.Duration)
+		if err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+		headInfo, err := wi.pachClient.InspectCommit(wi.input.Repo, wi.input.Commit)
+		if err != nil {
+			return nil, err
+		}
+		head := headInfo.Started
+		if headInfo.Finished != nil {
+			head = headInfo.Finished
+		}
+		headTime, err := types.TimestampFromProto(head)
+		if err != nil {
+			return nil, errors.EnsureStack(err)
+		}
+		cutoff = headTime.Add(-duration)
+	}
+	var commitInfos []*pfs.CommitInfo
+	if err := wi.pachClient.ListCommitF(wi.input.Repo, wi.input.Commit, "", number, false, func(ci *pfs.CommitInfo) error {
+		if !cutoff.IsZero() && ci.Finished != nil {
+			finished, err := types.TimestampFromProto(ci.Finished)
+			if err != nil {
+				return errors.EnsureStack(err)
+			}
+			if finished.Before(cutoff) {
+				return errutil.ErrBreak
+			}
+		}
+		commitInfos = append(commitInfos, ci)
+		return nil
+	}); err != nil && !errors.Is(err, errutil.ErrBreak) {
+		return nil, err
+	}
+	return commitInfos, nil
+}
+
+// windowMatch returns the shortest prefix of 'p' (including 'p' itself) that
+// matches 'g', which is the root of the datum that 'p' belongs to.
+func windowMatch(g *glob.Glob, p string) (string, bool) {
+	if g.Match("/") {
+		return "/", true
+	}
+	for i := 1; i < len(p); i++ {
+		if p[i] == '/' && g.Match(p[:i]) {
+			return p[:i], true
+		}
+	}
+	if g.Match(p) {
+		return p, true
+	}
+	return "", false
+}
+
 // Hasher is the standard interface for a datum hasher.
 type Hasher interface {
 	// Hash computes the datum hash based on the inputs.
@@ -467,6 +635,10 @@ func NewIterator(pachClient *client.APIClient, input *pps.Input) (Iterator, erro
 		return newGroupIterator(pachClient, input.Group)
 	case input.Cron != nil:
 		return newCronIterator(pachClient, input.Cron), nil
+	case input.Window != nil:
+		return newWindowIterator(pachClient, input.Window), nil
+	case input.SQL != nil:
+		return newSQLIterator(pachClient, input.SQL), nil
 	}
 	return nil, errors.Errorf("unrecognized input type: %v", input)
 }
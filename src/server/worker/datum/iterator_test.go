@@ -357,6 +357,47 @@ func TestIterators(t *testing.T) {
 //	}))
 //}
 
+func TestWindowIterator(t *testing.T) {
+	t.Parallel()
+	db := dbutil.NewTestDB(t)
+	require.NoError(t, testpachd.WithRealEnv(db, func(env *testpachd.RealEnv) error {
+		c := env.PachClient
+		dataRepo := tu.UniqueString(t.Name() + "_data")
+		require.NoError(t, c.CreateRepo(dataRepo))
+		commit1, err := c.StartCommit(dataRepo, "master")
+		require.NoError(t, err)
+		require.NoError(t, c.PutFile(dataRepo, commit1.ID, "/a/1", strings.NewReader("input")))
+		require.NoError(t, c.PutFile(dataRepo, commit1.ID, "/b/1", strings.NewReader("input")))
+		require.NoError(t, c.FinishCommit(dataRepo, commit1.ID))
+		commit2, err := c.StartCommit(dataRepo, "master")
+		require.NoError(t, err)
+		require.NoError(t, c.PutFile(dataRepo, commit2.ID, "/a/2", strings.NewReader("input")))
+		require.NoError(t, c.FinishCommit(dataRepo, commit2.ID))
+		commit3, err := c.StartCommit(dataRepo, "master")
+		require.NoError(t, err)
+		require.NoError(t, c.PutFile(dataRepo, commit3.ID, "/b/2", strings.NewReader("input")))
+		require.NoError(t, c.DeleteFile(dataRepo, commit3.ID, "/a/1"))
+		require.NoError(t, c.FinishCommit(dataRepo, commit3.ID))
+		t.Run("Commits", func(t *testing.T) {
+			in := client.NewWindowInput(dataRepo, "/*", 2)
+			in.Window.Name = dataRepo
+			in.Window.Commit = commit3.ID
+			window, err := NewIterator(c, in)
+			require.NoError(t, err)
+			validateDI(t, window, "/a/2", "/b/2")
+		})
+		t.Run("AllCommits", func(t *testing.T) {
+			in := client.NewWindowInput(dataRepo, "/*", 3)
+			in.Window.Name = dataRepo
+			in.Window.Commit = commit3.ID
+			window, err := NewIterator(c, in)
+			require.NoError(t, err)
+			validateDI(t, window, "/a/2", "/b/1/b/2")
+		})
+		return nil
+	}))
+}
+
 func validateDI(t testing.TB, di Iterator, datums ...string) {
 	t.Helper()
 	require.NoError(t, di.Iterate(func(meta *Meta) error {
@@ -3,6 +3,8 @@ package datum
 import (
 	"context"
 	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
 )
 
 // SetOption configures a set.
@@ -22,6 +24,15 @@ func WithPFSOutput(c Client) SetOption {
 	}
 }
 
+// WithState sets the StateClient that changes to the pipeline state are
+// written to, and the commit that datums read the pipeline state from.
+func WithState(c StateClient, commit *pfs.Commit) SetOption {
+	return func(s *Set) {
+		s.stateClient = c
+		s.stateCommit = commit
+	}
+}
+
 // WithStats sets the stats to fill in.
 func WithStats(stats *Stats) SetOption {
 	return func(s *Set) {
@@ -29,6 +40,18 @@ func WithStats(stats *Stats) SetOption {
 	}
 }
 
+// WithPrefetch configures the set to download the inputs of up to 'datums'
+// datums ahead of the one being processed (see PrefetchIterator), and to
+// upload each datum's output in the background. Prefetching pauses while the
+// datums downloaded ahead or waiting to be uploaded use more than 'maxDisk'
+// bytes of scratch space, or the worker uses more than 'maxMemory' bytes of
+// memory. Zero limits are unbounded.
+func WithPrefetch(datums int, maxDisk, maxMemory uint64) SetOption {
+	return func(s *Set) {
+		s.prefetcher = newPrefetcher(datums, maxDisk, maxMemory)
+	}
+}
+
 // Option configures a datum.
 type Option func(*Datum)
 
@@ -46,6 +69,14 @@ func WithRecoveryCallback(cb func(context.Context) error) Option {
 	}
 }
 
+// WithFailureCallback sets a callback that is called when the datum's final
+// attempt fails (and isn't recovered), while the datum's data is still active.
+func WithFailureCallback(cb func()) Option {
+	return func(d *Datum) {
+		d.failureCallback = cb
+	}
+}
+
 // WithTimeout sets the timeout.
 func WithTimeout(timeout time.Duration) Option {
 	return func(d *Datum) {
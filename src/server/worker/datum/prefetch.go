@@ -0,0 +1,287 @@
+package datum
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfssync"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+	"github.com/pachyderm/pachyderm/v2/src/server/worker/common"
+)
+
+// prefetchPollPeriod is how often a prefetcher that's over its budget checks
+// whether it can continue.
+const prefetchPollPeriod = 100 * time.Millisecond
+
+// prefetcher downloads the inputs of upcoming datums in a set, and uploads the
+// output of processed datums, in the background (see WithPrefetch).
+type prefetcher struct {
+	datums             int
+	maxDisk, maxMemory uint64 // zero if unbounded
+	memoryUsage        func() (uint64, bool)
+
+	mu sync.Mutex
+	// prefetched are the download stats of the datums that have been
+	// downloaded ahead, by ID
+	prefetched map[string]*pps.ProcessStats
+	// diskUsed is the scratch space used by each datum that has been
+	// downloaded ahead or is waiting to be uploaded, by ID
+	diskUsed       map[string]uint64
+	totalDiskUsed  uint64
+	pendingUploads int
+	uploadErr      error
+
+	// uploadMu serializes uploads, as the set's output clients aren't thread
+	// safe
+	uploadMu sync.Mutex
+	uploads  sync.WaitGroup
+}
+
+func newPrefetcher(datums int, maxDisk, maxMemory uint64) *prefetcher {
+	if datums < 1 {
+		datums = 1
+	}
+	return &prefetcher{
+		datums:      datums,
+		maxDisk:     maxDisk,
+		maxMemory:   maxMemory,
+		memoryUsage: common.MemoryUsage,
+		prefetched:  make(map[string]*pps.ProcessStats),
+		diskUsed:    make(map[string]uint64),
+	}
+}
+
+func (p *prefetcher) overDisk() bool {
+	return p.maxDisk > 0 && p.totalDiskUsed >= p.maxDisk
+}
+
+func (p *prefetcher) overMemory() bool {
+	if p.maxMemory == 0 {
+		return false
+	}
+	memory, ok := p.memoryUsage()
+	return ok && memory >= p.maxMemory
+}
+
+// waitForBudget blocks until another datum may be downloaded ahead.
+func (p *prefetcher) waitForBudget(ctx context.Context) error {
+	for {
+		p.mu.Lock()
+		overDisk := p.overDisk()
+		p.mu.Unlock()
+		if !overDisk && !p.overMemory() {
+			return nil
+		}
+		select {
+		case <-time.After(prefetchPollPeriod):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (p *prefetcher) setDiskUsed(ID string, bytes uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.totalDiskUsed += bytes - p.diskUsed[ID]
+	p.diskUsed[ID] = bytes
+}
+
+func (p *prefetcher) releaseDisk(ID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.totalDiskUsed -= p.diskUsed[ID]
+	delete(p.diskUsed, ID)
+}
+
+func (p *prefetcher) addPrefetched(ID string, stats *pps.ProcessStats) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.prefetched[ID] = stats
+}
+
+// takePrefetched returns the download stats of the datum 'ID' if it was
+// downloaded ahead, or nil otherwise.
+func (p *prefetcher) takePrefetched(ID string) *pps.ProcessStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.prefetched[ID]
+	delete(p.prefetched, ID)
+	return stats
+}
+
+// upload runs 'cb', which uploads the output of the datum 'd', in the
+// background. If the scratch space budget is exceeded, it first waits for
+// earlier uploads to finish. It returns the error of any earlier upload that
+// failed.
+func (p *prefetcher) upload(d *Datum, cb func() error) error {
+	size, err := dirSize(d.PFSStorageRoot())
+	if err != nil {
+		return err
+	}
+	p.setDiskUsed(d.ID, size)
+	for {
+		p.mu.Lock()
+		wait := p.overDisk() && p.pendingUploads > 0
+		err := p.uploadErr
+		if !wait && err == nil {
+			p.pendingUploads++
+		}
+		p.mu.Unlock()
+		if err != nil {
+			p.releaseDisk(d.ID)
+			return err
+		}
+		if !wait {
+			break
+		}
+		time.Sleep(prefetchPollPeriod)
+	}
+	p.uploads.Add(1)
+	go func() {
+		defer p.uploads.Done()
+		p.uploadMu.Lock()
+		defer p.uploadMu.Unlock()
+		err := cb()
+		p.releaseDisk(d.ID)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.pendingUploads--
+		if err != nil && p.uploadErr == nil {
+			p.uploadErr = err
+		}
+	}()
+	return nil
+}
+
+// wait waits for all background uploads to finish, and returns the error of
+// the first one that failed.
+func (p *prefetcher) wait() error {
+	p.uploads.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.uploadErr
+}
+
+func dirSize(dir string) (uint64, error) {
+	var size uint64
+	if err := filepath.Walk(dir, func(_ string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.Mode().IsRegular() {
+			size += uint64(fi.Size())
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// PrefetchIterator returns an iterator over the datums of 'dit' that
+// downloads the inputs of upcoming datums while the current one is processed
+// with the set. It returns 'dit' if the set isn't configured to prefetch.
+func (s *Set) PrefetchIterator(dit Iterator) Iterator {
+	if s.prefetcher == nil {
+		return dit
+	}
+	return &prefetchIterator{
+		set: s,
+		dit: dit,
+	}
+}
+
+type prefetchIterator struct {
+	set *Set
+	dit Iterator
+}
+
+func (pi *prefetchIterator) Iterate(cb func(*Meta) error) error {
+	p := pi.set.prefetcher
+	ctx, cancel := context.WithCancel(pi.set.pachClient.Ctx())
+	defer cancel()
+	pachClient := pi.set.pachClient.WithCtx(ctx)
+	// One datum is downloaded while 'datums' - 1 wait to be processed.
+	metas := make(chan *Meta, p.datums-1)
+	var eg errgroup.Group
+	eg.Go(func() error {
+		defer close(metas)
+		return pi.dit.Iterate(func(meta *Meta) error {
+			if err := p.waitForBudget(ctx); err != nil {
+				return err
+			}
+			if err := pi.set.prefetch(pachClient, meta); err != nil {
+				return err
+			}
+			select {
+			case metas <- meta:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	})
+	for meta := range metas {
+		if err := cb(meta); err != nil {
+			cancel()
+			eg.Wait()
+			return err
+		}
+	}
+	return eg.Wait()
+}
+
+// prefetch downloads the inputs of the datum 'meta' into the set. Datums with
+// lazy inputs aren't downloaded ahead, as lazy inputs are only readable while
+// their downloader is open.
+func (s *Set) prefetch(pachClient *client.APIClient, meta *Meta) error {
+	for _, input := range meta.Inputs {
+		if input.Lazy {
+			return nil
+		}
+	}
+	d := newDatum(s, meta)
+	if err := os.MkdirAll(filepath.Join(d.PFSStorageRoot(), OutputPrefix), 0700); err != nil {
+		return err
+	}
+	if err := pfssync.WithDownloader(pachClient, func(downloader pfssync.Downloader) error {
+		return d.downloadData(downloader)
+	}); err != nil {
+		return err
+	}
+	size, err := dirSize(d.PFSStorageRoot())
+	if err != nil {
+		return err
+	}
+	s.prefetcher.setDiskUsed(d.ID, size)
+	s.prefetcher.addPrefetched(d.ID, &pps.ProcessStats{
+		DownloadTime:   d.meta.Stats.DownloadTime,
+		DownloadBytes:  d.meta.Stats.DownloadBytes,
+		OverlappedTime: d.meta.Stats.DownloadTime,
+	})
+	return nil
+}
+
+// usePrefetched records the download stats of the datum's inputs, which were
+// downloaded ahead.
+func (d *Datum) usePrefetched(stats *pps.ProcessStats) {
+	d.meta.Stats.DownloadTime = stats.DownloadTime
+	d.meta.Stats.DownloadBytes = stats.DownloadBytes
+	d.meta.Stats.OverlappedTime = stats.OverlappedTime
+}
+
+func addOverlappedTime(stats *pps.ProcessStats, duration time.Duration) {
+	var overlapped time.Duration
+	if stats.OverlappedTime != nil {
+		overlapped, _ = types.DurationFromProto(stats.OverlappedTime)
+	}
+	stats.OverlappedTime = types.DurationProto(overlapped + duration)
+}
@@ -0,0 +1,72 @@
+package datum
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/server/worker/common"
+)
+
+func testPrefetchDatum(t *testing.T, s *Set, ID string, size int) *Datum {
+	d := newDatum(s, &Meta{Inputs: []*common.Input{{GroupBy: ID}}})
+	require.NoError(t, os.MkdirAll(d.PFSStorageRoot(), 0700))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(d.PFSStorageRoot(), "data"), make([]byte, size), 0600))
+	return d
+}
+
+func TestPrefetcherUpload(t *testing.T) {
+	s := &Set{storageRoot: t.TempDir()}
+	p := newPrefetcher(2, 10, 0)
+
+	// The first upload fits in the budget, but leaves no room for the second,
+	// which waits for it.
+	release := make(chan struct{})
+	require.NoError(t, p.upload(testPrefetchDatum(t, s, "a", 8), func() error {
+		<-release
+		return nil
+	}))
+	queued := make(chan error)
+	go func() {
+		queued <- p.upload(testPrefetchDatum(t, s, "b", 8), func() error {
+			return errors.New("upload failed")
+		})
+	}()
+	select {
+	case <-queued:
+		t.Fatal("upload was queued while over the scratch space budget")
+	case <-time.After(2 * prefetchPollPeriod):
+	}
+	close(release)
+	require.NoError(t, <-queued)
+
+	// The failed upload is returned by later uploads and by wait.
+	require.YesError(t, p.wait())
+	require.YesError(t, p.upload(testPrefetchDatum(t, s, "c", 1), func() error { return nil }))
+	require.Equal(t, uint64(0), p.totalDiskUsed)
+}
+
+func TestPrefetcherBudget(t *testing.T) {
+	p := newPrefetcher(1, 10, 100)
+	var memory uint64
+	p.memoryUsage = func() (uint64, bool) { return memory, true }
+	require.NoError(t, p.waitForBudget(context.Background()))
+
+	memory = 100
+	ctx, cancel := context.WithTimeout(context.Background(), 2*prefetchPollPeriod)
+	defer cancel()
+	require.YesError(t, p.waitForBudget(ctx))
+
+	memory = 0
+	p.setDiskUsed("a", 10)
+	ctx, cancel = context.WithTimeout(context.Background(), 2*prefetchPollPeriod)
+	defer cancel()
+	require.YesError(t, p.waitForBudget(ctx))
+	p.releaseDisk("a")
+	require.NoError(t, p.waitForBudget(context.Background()))
+}
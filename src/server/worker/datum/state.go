@@ -0,0 +1,117 @@
+package datum
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/pfssync"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	pfsserver "github.com/pachyderm/pachyderm/v2/src/server/pfs"
+)
+
+// StatePrefix is the prefix for the pipeline state, both in a datum's pfs
+// directory (mounted at /pfs/state) and in the meta commit.
+const StatePrefix = "state"
+
+// StateClient is the interface for the client that changes to the pipeline
+// state are written to.
+type StateClient interface {
+	// AppendFile puts a file.
+	AppendFile(path string, overwrite bool, r io.Reader, tag ...string) error
+	// DeleteFile deletes a file.
+	DeleteFile(file string, tag ...string) error
+}
+
+// StateStorageRoot returns the state storage root.
+func (d *Datum) StateStorageRoot() string {
+	return path.Join(d.PFSStorageRoot(), StatePrefix)
+}
+
+// downloadState downloads the pipeline state from the set's state commit and
+// records a hash of each state file, so that uploadState only writes the
+// files that the datum changed.
+func (d *Datum) downloadState(downloader pfssync.Downloader) error {
+	if err := os.MkdirAll(d.StateStorageRoot(), 0700); err != nil {
+		return err
+	}
+	if d.set.stateCommit != nil {
+		file := &pfs.File{Commit: d.set.stateCommit, Path: "/" + StatePrefix + "/"}
+		if err := downloader.Download(d.PFSStorageRoot(), file); err != nil && !pfsserver.IsFileNotFoundErr(err) {
+			return err
+		}
+	}
+	var err error
+	d.stateHashes, err = hashFiles(d.StateStorageRoot())
+	return err
+}
+
+// uploadState writes the state files that the datum created or modified, and
+// deletes the state files that the datum removed.
+func (d *Datum) uploadState() error {
+	if d.set.stateClient == nil {
+		return nil
+	}
+	hashes, err := hashFiles(d.StateStorageRoot())
+	if err != nil {
+		return err
+	}
+	for file, hash := range hashes {
+		if d.stateHashes[file] == hash {
+			continue
+		}
+		if err := func() error {
+			f, err := os.Open(filepath.Join(d.StateStorageRoot(), filepath.FromSlash(file)))
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return d.set.stateClient.AppendFile(path.Join("/", StatePrefix, file), true, f)
+		}(); err != nil {
+			return err
+		}
+	}
+	for file := range d.stateHashes {
+		if _, ok := hashes[file]; !ok {
+			if err := d.set.stateClient.DeleteFile(path.Join("/", StatePrefix, file)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hashFiles returns the hash of every regular file under 'root', keyed by its
+// slash-separated path relative to 'root'.
+func hashFiles(root string) (map[string]string, error) {
+	hashes := make(map[string]string)
+	if err := filepath.Walk(root, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		hashes[filepath.ToSlash(relPath)] = hex.EncodeToString(h.Sum(nil))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
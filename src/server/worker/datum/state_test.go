@@ -0,0 +1,61 @@
+package datum
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+)
+
+type testStateClient struct {
+	appended map[string]string
+	deleted  []string
+}
+
+func (c *testStateClient) AppendFile(path string, overwrite bool, r io.Reader, _ ...string) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	c.appended[path] = string(data)
+	return nil
+}
+
+func (c *testStateClient) DeleteFile(file string, _ ...string) error {
+	c.deleted = append(c.deleted, file)
+	return nil
+}
+
+func TestUploadState(t *testing.T) {
+	storageRoot, err := ioutil.TempDir("", "datum_state_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(storageRoot)
+	c := &testStateClient{appended: make(map[string]string)}
+	d := &Datum{
+		set:         &Set{stateClient: c},
+		ID:          "datum",
+		storageRoot: storageRoot,
+	}
+	stateRoot := d.StateStorageRoot()
+	require.NoError(t, os.MkdirAll(filepath.Join(stateRoot, "dir"), 0700))
+	for _, file := range []string{"unchanged", "changed", "dir/removed"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(stateRoot, file), []byte(file), 0600))
+	}
+	d.stateHashes, err = hashFiles(stateRoot)
+	require.NoError(t, err)
+
+	// simulate the user code updating the state
+	require.NoError(t, ioutil.WriteFile(filepath.Join(stateRoot, "changed"), []byte("new"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(stateRoot, "dir", "added"), []byte("added"), 0600))
+	require.NoError(t, os.Remove(filepath.Join(stateRoot, "dir", "removed")))
+
+	require.NoError(t, d.uploadState())
+	require.Equal(t, map[string]string{
+		"/state/changed":   "new",
+		"/state/dir/added": "added",
+	}, c.appended)
+	require.Equal(t, []string{"/state/dir/removed"}, c.deleted)
+}
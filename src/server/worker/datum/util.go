@@ -16,6 +16,7 @@ func MergeStats(x, y *Stats) error {
 	x.Skipped += y.Skipped
 	x.Failed += y.Failed
 	x.Recovered += y.Recovered
+	x.TimedOut += y.TimedOut
 	if x.FailedID == "" {
 		x.FailedID = y.FailedID
 	}
@@ -34,8 +35,15 @@ func MergeProcessStats(x, y *pps.ProcessStats) error {
 	if x.UploadTime, err = plusDuration(x.UploadTime, y.UploadTime); err != nil {
 		return err
 	}
+	if x.OverlappedTime, err = plusDuration(x.OverlappedTime, y.OverlappedTime); err != nil {
+		return err
+	}
 	x.DownloadBytes += y.DownloadBytes
 	x.UploadBytes += y.UploadBytes
+	x.CPUSeconds += y.CPUSeconds
+	if y.MaxMemoryBytes > x.MaxMemoryBytes {
+		x.MaxMemoryBytes = y.MaxMemoryBytes
+	}
 	return nil
 }
 
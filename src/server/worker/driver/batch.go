@@ -0,0 +1,224 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/uuid"
+	"github.com/pachyderm/pachyderm/v2/src/server/worker/logs"
+)
+
+// DatumBatch hands datums, one at a time, to a single long-lived user process
+// (see pps.Transform.DatumBatching). The user process reads each datum's ID
+// from the fifo named by $PACH_DATUM_BATCH_NEXT, processes the datum (which is
+// mounted at /pfs as usual), and writes its exit status to the fifo named by
+// $PACH_DATUM_BATCH_DONE. When there are no more datums, reading from
+// $PACH_DATUM_BATCH_NEXT returns EOF and the user process should exit.
+type DatumBatch interface {
+	// Process hands the datum with the given ID to the user process and blocks
+	// until the user process reports the datum's status. If the user process
+	// exits or 'ctx' is cancelled while the datum is being processed, the user
+	// process is killed and a new one is started for the next datum.
+	Process(ctx context.Context, datumID string) error
+	// Close tells the user process that there are no more datums and waits for
+	// it to exit.
+	Close(ctx context.Context) error
+}
+
+type datumBatch struct {
+	d       *driver
+	ctx     context.Context
+	logger  logs.TaggedLogger
+	environ []string
+	proc    *batchProcess
+}
+
+// NewDatumBatch returns a DatumBatch that runs the configured user process
+// with the given environment. The user process is started lazily, when the
+// first datum is processed.
+func (d *driver) NewDatumBatch(ctx context.Context, logger logs.TaggedLogger, environ []string) DatumBatch {
+	return &datumBatch{
+		d:       d,
+		ctx:     ctx,
+		logger:  logger,
+		environ: environ,
+	}
+}
+
+func (b *datumBatch) Process(ctx context.Context, datumID string) error {
+	if b.proc == nil {
+		proc, err := b.d.startBatchProcess(b.ctx, b.logger, b.environ)
+		if err != nil {
+			return err
+		}
+		b.proc = proc
+	}
+	proc := b.proc
+	result := make(chan error, 1)
+	go func() {
+		result <- proc.process(datumID)
+	}()
+	select {
+	case err := <-result:
+		if err == nil {
+			return nil
+		}
+		if statusErr := (&batchStatusError{}); errors.As(err, &statusErr) {
+			// the user process reported a failure, but is still running
+			return err
+		}
+		b.stop()
+		return err
+	case <-proc.exited:
+		b.stop()
+		if proc.err != nil {
+			return errors.Wrapf(proc.err, "user code exited while processing datum %v", datumID)
+		}
+		return errors.Errorf("user code exited while processing datum %v", datumID)
+	case <-ctx.Done():
+		b.stop()
+		return errors.EnsureStack(ctx.Err())
+	}
+}
+
+func (b *datumBatch) Close(ctx context.Context) error {
+	if b.proc == nil {
+		return nil
+	}
+	proc := b.proc
+	defer b.stop()
+	// Opening and closing the fifo without writing to it makes the user
+	// process's next read return EOF.
+	go func() {
+		if f, err := os.OpenFile(proc.next, os.O_WRONLY, 0); err == nil {
+			f.Close()
+		}
+	}()
+	select {
+	case <-proc.exited:
+		return proc.err
+	case <-ctx.Done():
+		return errors.EnsureStack(ctx.Err())
+	}
+}
+
+// stop kills the current user process (if it's still running) and removes
+// its fifos, so that the next datum starts a new process.
+func (b *datumBatch) stop() {
+	if b.proc != nil {
+		b.proc.stop()
+		b.proc = nil
+	}
+}
+
+// batchStatusError is returned by batchProcess.process when the user process
+// reports a non-zero status for a datum.
+type batchStatusError struct {
+	status string
+}
+
+func (e *batchStatusError) Error() string {
+	return fmt.Sprintf("user code reported status %q", e.status)
+}
+
+type batchProcess struct {
+	dir    string
+	next   string
+	done   string
+	cancel context.CancelFunc
+	// acceptReturnCode is the set of non-zero statuses that indicate success
+	acceptReturnCode []int64
+	// exited is closed once the user process exits, after err is set
+	exited chan struct{}
+	err    error
+}
+
+func (d *driver) startBatchProcess(ctx context.Context, logger logs.TaggedLogger, environ []string) (*batchProcess, error) {
+	dir := filepath.Join(d.InputDir(), client.PPSScratchSpace, "batch-"+uuid.NewWithoutDashes())
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	p := &batchProcess{
+		dir:              dir,
+		next:             filepath.Join(dir, "next"),
+		done:             filepath.Join(dir, "done"),
+		acceptReturnCode: d.pipelineInfo.Transform.AcceptReturnCode,
+		exited:           make(chan struct{}),
+	}
+	for _, fifo := range []string{p.next, p.done} {
+		if err := createFifo(fifo); err != nil {
+			os.RemoveAll(dir)
+			return nil, errors.Wrapf(err, "error creating fifo %s", fifo)
+		}
+	}
+	env := append(environ[:len(environ):len(environ)],
+		fmt.Sprintf("%s=%s", client.DatumBatchNextEnv, p.next),
+		fmt.Sprintf("%s=%s", client.DatumBatchDoneEnv, p.done),
+	)
+	ctx, p.cancel = context.WithCancel(ctx)
+	go func() {
+		defer close(p.exited)
+		p.err = d.RunUserCode(ctx, logger, env)
+	}()
+	return p, nil
+}
+
+// process writes 'datumID' to the user process's 'next' fifo, then reads the
+// datum's status from its 'done' fifo.
+func (p *batchProcess) process(datumID string) error {
+	f, err := os.OpenFile(p.next, os.O_WRONLY, 0)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	if _, err := f.Write([]byte(datumID + "\n")); err != nil {
+		f.Close()
+		return errors.EnsureStack(err)
+	}
+	if err := f.Close(); err != nil {
+		return errors.EnsureStack(err)
+	}
+	f, err = os.OpenFile(p.done, os.O_RDONLY, 0)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	status := strings.TrimSpace(string(data))
+	if code, err := strconv.ParseInt(status, 10, 64); err == nil {
+		if code == 0 {
+			return nil
+		}
+		for _, returnCode := range p.acceptReturnCode {
+			if returnCode == code {
+				return nil
+			}
+		}
+	}
+	return &batchStatusError{status: status}
+}
+
+func (p *batchProcess) stop() {
+	p.cancel()
+	<-p.exited
+	// Open the other end of each fifo to release a process() call that is
+	// still blocked opening it. Any process() call that gets to its open after
+	// this sees that the fifos have been removed.
+	if f, err := os.OpenFile(p.next, os.O_RDONLY|syscall.O_NONBLOCK, 0); err == nil {
+		f.Close()
+	}
+	if f, err := os.OpenFile(p.done, os.O_WRONLY|syscall.O_NONBLOCK, 0); err == nil {
+		f.Close()
+	}
+	os.RemoveAll(p.dir)
+}
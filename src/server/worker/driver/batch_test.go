@@ -0,0 +1,83 @@
+// +build !windows
+
+package driver
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+	"github.com/pachyderm/pachyderm/v2/src/server/worker/logs"
+)
+
+func newBatchTestDriver(t *testing.T, script string) (*driver, string) {
+	inputDir, err := ioutil.TempDir("", "datum_batch_test")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(inputDir) })
+	return &driver{
+		pipelineInfo: &pps.PipelineInfo{
+			Transform: &pps.Transform{
+				Cmd:              []string{"bash", "-c", script},
+				AcceptReturnCode: []int64{3},
+				DatumBatching:    true,
+			},
+		},
+		rootDir:  "/",
+		inputDir: inputDir,
+	}, inputDir
+}
+
+func TestDatumBatch(t *testing.T) {
+	// Each datum whose ID starts with "fail" exits with status 1 (or 3, which
+	// is accepted), and the datum "exit" kills the user process.
+	d, inputDir := newBatchTestDriver(t, `
+echo $$ >> "$LOG"
+while read -r datum < "$PACH_DATUM_BATCH_NEXT"; do
+    case "$datum" in
+        fail*) echo 1 > "$PACH_DATUM_BATCH_DONE" ;;
+        accept*) echo 3 > "$PACH_DATUM_BATCH_DONE" ;;
+        exit*) exit 1 ;;
+        *) echo 0 > "$PACH_DATUM_BATCH_DONE" ;;
+    esac
+done
+`)
+	log := filepath.Join(inputDir, "pids")
+	ctx := context.Background()
+	b := d.NewDatumBatch(ctx, logs.NewMockLogger(), []string{"LOG=" + log})
+	require.NoError(t, b.Process(ctx, "a"))
+	require.YesError(t, b.Process(ctx, "fail"))
+	require.NoError(t, b.Process(ctx, "accept"))
+	require.NoError(t, b.Process(ctx, "b"))
+	require.YesError(t, b.Process(ctx, "exit"))
+	// a new user process is started after the previous one exited
+	require.NoError(t, b.Process(ctx, "c"))
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	require.NoError(t, b.Close(timeoutCtx))
+
+	pids, err := ioutil.ReadFile(log)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(strings.Fields(string(pids))))
+}
+
+func TestDatumBatchTimeout(t *testing.T) {
+	d, _ := newBatchTestDriver(t, `
+while read -r datum < "$PACH_DATUM_BATCH_NEXT"; do
+    if [ "$datum" = "slow" ]; then sleep 60; fi
+    echo 0 > "$PACH_DATUM_BATCH_DONE"
+done
+`)
+	ctx := context.Background()
+	b := d.NewDatumBatch(ctx, logs.NewMockLogger(), nil)
+	timeoutCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	require.YesError(t, b.Process(timeoutCtx, "slow"))
+	require.NoError(t, b.Process(ctx, "fast"))
+	require.NoError(t, b.Close(ctx))
+}
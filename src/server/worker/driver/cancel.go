@@ -0,0 +1,177 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/exec"
+	"github.com/pachyderm/pachyderm/v2/src/internal/ppsutil"
+	"github.com/pachyderm/pachyderm/v2/src/internal/uuid"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+	"github.com/pachyderm/pachyderm/v2/src/server/worker/common"
+	"github.com/pachyderm/pachyderm/v2/src/server/worker/logs"
+)
+
+// gracefulCancel stops cancelled user code gracefully, for pipelines with a
+// cancel grace period (see pps.Transform.CancelGracePeriod). When the user
+// code is cancelled, it's sent SIGTERM, and is only killed once it has exited
+// or the grace period has elapsed. If it's cancelled because its job is
+// stopped (see common.WithJobStop), the pipeline's on_cancel command is also
+// run, and the partial output that the user code wrote to
+// $PACH_PARTIAL_OUTPUT is uploaded to the job's output commit before it's
+// killed.
+type gracefulCancel struct {
+	partialDir string
+	// exited is closed once the user code has exited
+	exited chan struct{}
+	// stopped is closed once cancelled user code has been stopped, and its
+	// partial output uploaded
+	stopped chan struct{}
+}
+
+// newGracefulCancel configures 'cmd' to be stopped gracefully when 'ctx', its
+// context, is done, or returns nil if the pipeline has no cancel grace period.
+// 'cmd's environment must already be set.
+func (d *driver) newGracefulCancel(ctx context.Context, logger logs.TaggedLogger, cmd *exec.Cmd) (*gracefulCancel, error) {
+	transform := d.pipelineInfo.Transform
+	gracePeriod, err := ppsutil.CancelGracePeriod(transform)
+	if err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	if gracePeriod <= 0 {
+		return nil, nil
+	}
+	gc := &gracefulCancel{
+		partialDir: filepath.Join(d.InputDir(), client.PPSScratchSpace, "partial-"+uuid.NewWithoutDashes()),
+		exited:     make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	if err := os.MkdirAll(gc.partialDir, 0777); err != nil {
+		return nil, errors.EnsureStack(err)
+	}
+	if d.uid != nil && d.gid != nil {
+		if err := os.Chown(gc.partialDir, int(*d.uid), int(*d.gid)); err != nil {
+			os.RemoveAll(gc.partialDir)
+			return nil, errors.EnsureStack(err)
+		}
+	}
+	cmd.Env = append(cmd.Env[:len(cmd.Env):len(cmd.Env)], fmt.Sprintf("%s=%s", client.PartialOutputEnv, gc.partialDir))
+	environ := cmd.Env
+	cmd.GracePeriod = gracePeriod
+	cmd.OnCancel = func(graceCtx context.Context) {
+		defer close(gc.stopped)
+		logger.Logf("user code cancelled, waiting up to %v for it to shut down", gracePeriod)
+		// The user code may also be cancelled because its datum timed out or
+		// was restarted, in which case it's only given time to exit.
+		stopped := common.IsJobStopped(ctx)
+		if stopped && len(transform.OnCancel) > 0 {
+			if err := d.runOnCancel(graceCtx, logger, environ); err != nil {
+				logger.WithLevel(pps.LogLevel_LOG_ERROR).Logf("error running on_cancel: %v", err)
+			}
+		}
+		select {
+		case <-gc.exited:
+		case <-graceCtx.Done():
+		}
+		if !stopped {
+			return
+		}
+		if err := d.uploadPartialOutput(environ, gc.partialDir); err != nil {
+			logger.WithLevel(pps.LogLevel_LOG_ERROR).Logf("error uploading partial output: %v", err)
+		}
+	}
+	return gc, nil
+}
+
+// finish must be called once the user code has exited. If it was cancelled,
+// finish waits for it to be stopped.
+func (gc *gracefulCancel) finish(cancelled bool) {
+	close(gc.exited)
+	if cancelled {
+		<-gc.stopped
+	}
+}
+
+// cleanup removes the partial output.
+func (gc *gracefulCancel) cleanup() error {
+	return errors.EnsureStack(os.RemoveAll(gc.partialDir))
+}
+
+func (d *driver) runOnCancel(ctx context.Context, logger logs.TaggedLogger, environ []string) error {
+	onCancel := d.pipelineInfo.Transform.OnCancel
+	cmd := exec.CommandContext(ctx, onCancel[0], onCancel[1:]...)
+	cmd.Stdout = logger.WithUserCode()
+	cmd.Stderr = logger.WithUserCode().WithLevel(pps.LogLevel_LOG_ERROR)
+	cmd.Env = environ
+	if d.uid != nil && d.gid != nil {
+		cmd.SysProcAttr = makeCmdCredentials(*d.uid, *d.gid)
+	}
+	cmd.Dir = filepath.Join(d.rootDir, d.pipelineInfo.Transform.WorkingDir)
+	return errors.EnsureStack(cmd.Run())
+}
+
+// uploadPartialOutput uploads the files in 'dir' to the job's output commit,
+// which is read from 'environ'. Spouts and services have no output commit, so
+// nothing is uploaded for them.
+func (d *driver) uploadPartialOutput(environ []string, dir string) error {
+	commitID := lookupEnv(environ, client.OutputCommitIDEnv)
+	if commitID == "" {
+		return nil
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	var files []string
+	if err := filepath.Walk(dir, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.Mode().IsRegular() {
+			files = append(files, file)
+		}
+		return nil
+	}); err != nil {
+		return errors.EnsureStack(err)
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	// The job's context is cancelled by now, so the upload gets its own.
+	pachClient := d.pachClient.WithCtx(context.Background())
+	return pachClient.WithModifyFileClient(d.pipelineInfo.Pipeline.Name, commitID, func(mfc *client.ModifyFileClient) error {
+		for _, file := range files {
+			rel, err := filepath.Rel(dir, file)
+			if err != nil {
+				return errors.EnsureStack(err)
+			}
+			if err := func() error {
+				f, err := os.Open(file)
+				if err != nil {
+					return errors.EnsureStack(err)
+				}
+				defer f.Close()
+				return mfc.AppendFile(path.Join("/", filepath.ToSlash(rel)), true, f)
+			}(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// lookupEnv returns the value of the env var 'name' in 'environ', or "" if
+// it's unset.
+func lookupEnv(environ []string, name string) string {
+	for i := len(environ) - 1; i >= 0; i-- {
+		if strings.HasPrefix(environ[i], name+"=") {
+			return strings.TrimPrefix(environ[i], name+"=")
+		}
+	}
+	return ""
+}
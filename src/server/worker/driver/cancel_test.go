@@ -0,0 +1,110 @@
+// +build !windows
+
+package driver
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+	"github.com/pachyderm/pachyderm/v2/src/server/worker/common"
+	"github.com/pachyderm/pachyderm/v2/src/server/worker/logs"
+)
+
+func newCancelTestDriver(t *testing.T, script string, onCancel []string, gracePeriod time.Duration) *driver {
+	return &driver{
+		pipelineInfo: &pps.PipelineInfo{
+			Pipeline: &pps.Pipeline{Name: "pipeline"},
+			Transform: &pps.Transform{
+				Cmd:               []string{"bash", "-c", script},
+				OnCancel:          onCancel,
+				CancelGracePeriod: types.DurationProto(gracePeriod),
+			},
+		},
+		rootDir:  "/",
+		inputDir: t.TempDir(),
+	}
+}
+
+// runCancelled runs the user code until it has logged "started" to 'log', then
+// cancels it, by stopping its job if 'stopJob' is set, and returns how long it
+// took to stop.
+func runCancelled(t *testing.T, d *driver, log string, stopJob bool) time.Duration {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx, stop := common.WithJobStop(ctx)
+	if !stopJob {
+		stop = cancel
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- d.RunUserCode(ctx, logs.NewMockLogger(), []string{"LOG=" + log, "PATH=" + os.Getenv("PATH")})
+	}()
+	require.NoErrorWithinTRetry(t, 10*time.Second, func() error {
+		data, err := ioutil.ReadFile(log)
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(string(data), "started") {
+			return os.ErrNotExist
+		}
+		return nil
+	})
+	start := time.Now()
+	stop()
+	require.YesError(t, <-errCh)
+	return time.Since(start)
+}
+
+func TestRunUserCodeGracefulCancel(t *testing.T) {
+	// The user code flushes its partial output when it's sent SIGTERM
+	d := newCancelTestDriver(t, `
+trap 'echo stopped >> "$LOG"; echo partial > "$PACH_PARTIAL_OUTPUT/out"; exit 0' TERM
+echo started >> "$LOG"
+while true; do sleep 0.1; done
+`, []string{"bash", "-c", `echo on_cancel >> "$LOG"`}, time.Minute)
+	log := filepath.Join(t.TempDir(), "log")
+	require.True(t, runCancelled(t, d, log, true) < 30*time.Second)
+
+	data, err := ioutil.ReadFile(log)
+	require.NoError(t, err)
+	require.True(t, strings.Contains(string(data), "stopped"))
+	require.True(t, strings.Contains(string(data), "on_cancel"))
+	// the partial output is removed once it's been uploaded
+	partial, err := filepath.Glob(filepath.Join(d.inputDir, client.PPSScratchSpace, "partial-*"))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(partial))
+
+	// user code that's cancelled without its job being stopped, e.g. because
+	// its datum timed out, is sent SIGTERM, but on_cancel isn't run
+	log = filepath.Join(t.TempDir(), "log")
+	require.True(t, runCancelled(t, d, log, false) < 30*time.Second)
+	data, err = ioutil.ReadFile(log)
+	require.NoError(t, err)
+	require.True(t, strings.Contains(string(data), "stopped"))
+	require.False(t, strings.Contains(string(data), "on_cancel"))
+}
+
+func TestRunUserCodeGracePeriodElapsed(t *testing.T) {
+	// The user code ignores SIGTERM, so it's killed once the grace period
+	// elapses
+	gracePeriod := 500 * time.Millisecond
+	d := newCancelTestDriver(t, `
+trap '' TERM
+echo started >> "$LOG"
+while true; do sleep 0.1; done
+`, nil, gracePeriod)
+	log := filepath.Join(t.TempDir(), "log")
+	elapsed := runCancelled(t, d, log, false)
+	require.True(t, elapsed >= gracePeriod)
+	require.True(t, elapsed < 30*time.Second)
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -80,13 +81,24 @@ type Driver interface {
 	WithActiveData([]*common.Input, string, func() error) error
 
 	// UserCodeEnv returns the set of environment variables to construct when
-	// launching the configured user process.
-	UserCodeEnv(string, *pfs.Commit, []*common.Input) []string
+	// launching the configured user process. It also resolves the pipeline's
+	// external secrets, writing any that are mounted as files.
+	UserCodeEnv(string, *pfs.Commit, []*common.Input) ([]string, error)
 
 	RunUserCode(context.Context, logs.TaggedLogger, []string) error
 
 	RunUserErrorHandlingCode(context.Context, logs.TaggedLogger, []string) error
 
+	// RunDebugShell runs an interactive shell with the given environment, as
+	// the user code's user and in its working directory, until the shell exits
+	// or the context is cancelled.
+	RunDebugShell(context.Context, []string, io.Reader, io.Writer) error
+
+	// NewDatumBatch returns a DatumBatch, which runs the configured user
+	// process once and hands it datums one at a time (for pipelines with
+	// datum batching).
+	NewDatumBatch(context.Context, logs.TaggedLogger, []string) DatumBatch
+
 	// TODO: provide a more generic interface for modifying jobs, and
 	// some quality-of-life functions for common operations.
 	DeleteJob(col.STM, *pps.EtcdJobInfo) error
@@ -121,6 +133,9 @@ type driver struct {
 	// The directory to store input data - this is typically static but can be
 	// overridden by tests.
 	inputDir string
+
+	// Resolves the pipeline's secrets from external secret stores
+	secrets *secretResolver
 }
 
 // NewDriver constructs a Driver object using the given clients and pipeline
@@ -150,6 +165,7 @@ func NewDriver(
 		rootDir:         rootPath,
 		inputDir:        pfsPath,
 		namespace:       namespace,
+		secrets:         newSecretResolver(),
 	}
 	if pipelineInfo.Transform.User != "" {
 		user, err := lookupDockerUser(pipelineInfo.Transform.User)
@@ -315,7 +331,7 @@ func (d *driver) RunUserCode(
 	logger.Logf("beginning to run user code")
 	defer func(start time.Time) {
 		if retErr != nil {
-			logger.Logf("errored running user code after %v: %v", time.Since(start), retErr)
+			logger.WithLevel(pps.LogLevel_LOG_ERROR).Logf("errored running user code after %v: %v", time.Since(start), retErr)
 		} else {
 			logger.Logf("finished running user code after %v", time.Since(start))
 		}
@@ -330,19 +346,33 @@ func (d *driver) RunUserCode(
 		cmd.Stdin = strings.NewReader(strings.Join(d.pipelineInfo.Transform.Stdin, "\n") + "\n")
 	}
 	cmd.Stdout = logger.WithUserCode()
-	cmd.Stderr = logger.WithUserCode()
+	cmd.Stderr = logger.WithUserCode().WithLevel(pps.LogLevel_LOG_ERROR)
 	cmd.Env = environ
 	if d.uid != nil && d.gid != nil {
 		cmd.SysProcAttr = makeCmdCredentials(*d.uid, *d.gid)
 	}
 	cmd.Dir = filepath.Join(d.rootDir, d.pipelineInfo.Transform.WorkingDir)
-	err := cmd.Start()
+	gc, err := d.newGracefulCancel(ctx, logger, cmd)
 	if err != nil {
+		return err
+	}
+	if gc != nil {
+		defer func() {
+			if err := gc.cleanup(); retErr == nil {
+				retErr = err
+			}
+		}()
+	}
+	if err := cmd.Start(); err != nil {
 		return errors.EnsureStack(err)
 	}
 	// A context with a deadline will successfully cancel/kill
-	// the running process (minus zombies)
+	// the running process (minus zombies), gracefully if the pipeline has a
+	// cancel grace period
 	state, err := cmd.Process.Wait()
+	if gc != nil {
+		gc.finish(common.IsDone(ctx))
+	}
 	if err != nil {
 		return errors.EnsureStack(err)
 	}
@@ -379,6 +409,31 @@ func (d *driver) RunUserCode(
 	return nil
 }
 
+func (d *driver) RunDebugShell(ctx context.Context, environ []string, stdin io.Reader, out io.Writer) error {
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-i")
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.Env = environ
+	if d.uid != nil && d.gid != nil {
+		cmd.SysProcAttr = makeCmdCredentials(*d.uid, *d.gid)
+	}
+	cmd.Dir = filepath.Join(d.rootDir, d.pipelineInfo.Transform.WorkingDir)
+	// Stdin is copied by hand rather than set on cmd, so that Wait doesn't
+	// block on a client that never closes its input.
+	stdinPipe, err := cmd.StdinPipe()
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	if err := cmd.Start(); err != nil {
+		return errors.EnsureStack(err)
+	}
+	go func() {
+		io.Copy(stdinPipe, stdin)
+		stdinPipe.Close()
+	}()
+	return errors.EnsureStack(cmd.Wait())
+}
+
 func (d *driver) RunUserErrorHandlingCode(
 	ctx context.Context,
 	logger logs.TaggedLogger,
@@ -387,7 +442,7 @@ func (d *driver) RunUserErrorHandlingCode(
 	logger.Logf("beginning to run user error handling code")
 	defer func(start time.Time) {
 		if retErr != nil {
-			logger.Logf("errored running user error handling code after %v: %v", time.Since(start), retErr)
+			logger.WithLevel(pps.LogLevel_LOG_ERROR).Logf("errored running user error handling code after %v: %v", time.Since(start), retErr)
 		} else {
 			logger.Logf("finished running user error handling code after %v", time.Since(start))
 		}
@@ -398,7 +453,7 @@ func (d *driver) RunUserErrorHandlingCode(
 		cmd.Stdin = strings.NewReader(strings.Join(d.pipelineInfo.Transform.ErrStdin, "\n") + "\n")
 	}
 	cmd.Stdout = logger.WithUserCode()
-	cmd.Stderr = logger.WithUserCode()
+	cmd.Stderr = logger.WithUserCode().WithLevel(pps.LogLevel_LOG_ERROR)
 	cmd.Env = environ
 	if d.uid != nil && d.gid != nil {
 		cmd.SysProcAttr = makeCmdCredentials(*d.uid, *d.gid)
@@ -496,13 +551,8 @@ func (d *driver) UserCodeEnv(
 	jobID string,
 	outputCommit *pfs.Commit,
 	inputs []*common.Input,
-) []string {
-	result := os.Environ()
-
-	for _, input := range inputs {
-		result = append(result, fmt.Sprintf("%s=%s", input.Name, filepath.Join(d.InputDir(), input.Name, input.FileInfo.File.Path)))
-		result = append(result, fmt.Sprintf("%s_COMMIT=%s", input.Name, input.FileInfo.File.Commit.ID))
-	}
+) ([]string, error) {
+	result := append(os.Environ(), common.InputEnv(d.InputDir(), inputs)...)
 
 	if jobID != "" {
 		result = append(result, fmt.Sprintf("%s=%s", client.JobIDEnv, jobID))
@@ -530,5 +580,11 @@ func (d *driver) UserCodeEnv(
 		result = append(result, fmt.Sprintf("%s=%s", client.OutputCommitIDEnv, outputCommit.ID))
 	}
 
-	return result
+	secretEnv, err := d.secrets.inject(d.pachClient.Ctx(), d.rootDir, d.uid, d.gid, d.PipelineInfo().Transform.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	result = append(result, secretEnv...)
+
+	return result, nil
 }
@@ -10,6 +10,7 @@ import (
 
 	"github.com/pachyderm/pachyderm/v2/src/client"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/ppsutil"
 	"github.com/pachyderm/pachyderm/v2/src/server/worker/common"
 )
 
@@ -22,6 +23,16 @@ func makeCmdCredentials(uid uint32, gid uint32) *syscall.SysProcAttr {
 	}
 }
 
+// createFifo creates a fifo at 'path' that the user code can open regardless
+// of the user it runs as.
+func createFifo(path string) error {
+	if err := syscall.Mkfifo(path, 0666); err != nil {
+		return errors.EnsureStack(err)
+	}
+	// Mkfifo is subject to the umask
+	return errors.EnsureStack(os.Chmod(path, 0666))
+}
+
 // WithActiveData is implemented differently in unix vs windows because of how
 // symlinks work on windows. Here, we create symlinks to the scratch space
 // directory, then clean up before returning.
@@ -127,5 +138,11 @@ func (d *driver) linkData(inputs []*common.Input, dir string) error {
 		}
 	}
 
+	if ppsutil.IsStateful(d.PipelineInfo()) {
+		if err := os.Symlink(filepath.Join(dir, "state"), filepath.Join(d.InputDir(), "state")); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -10,6 +10,7 @@ import (
 
 	"github.com/pachyderm/pachyderm/v2/src/client"
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/ppsutil"
 	"github.com/pachyderm/pachyderm/v2/src/server/worker/common"
 )
 
@@ -29,6 +30,16 @@ func createSpoutFifo(path string) error {
 	return file.Close()
 }
 
+// Note: this function only exists for tests, the real system uses fifos for
+// datum batching (which do not exist in the normal filesystem on Windows)
+func createFifo(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
 // WithActiveData is implemented differently in unix vs windows because of how
 // symlinks work on windows. Here, we move inputs into place before the
 // callback, then move them back to the scratch space before returning.
@@ -79,6 +90,12 @@ func (d *driver) moveData(inputs []*common.Input, dir string) error {
 		}
 	}
 
+	if ppsutil.IsStateful(d.PipelineInfo()) {
+		if err := os.Rename(filepath.Join(dir, "state"), filepath.Join(d.InputDir(), "state")); err != nil {
+			return err
+		}
+	}
+
 	return os.Rename(filepath.Join(dir, "out"), filepath.Join(d.InputDir(), "out"))
 }
 
@@ -0,0 +1,259 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/ppsutil"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+)
+
+const (
+	// VaultAddrEnv and VaultTokenEnv are the environment variables that
+	// configure the worker's connection to vault for "vault://" secrets.
+	VaultAddrEnv  = "VAULT_ADDR"
+	VaultTokenEnv = "VAULT_TOKEN"
+
+	// defaultSecretRefresh is how long a secret without a lease duration is
+	// cached before it's fetched again, so that rotated secrets are picked up.
+	defaultSecretRefresh = time.Minute
+)
+
+// secretProvider resolves references to secrets in an external secret store.
+type secretProvider interface {
+	// resolve returns the value of the secret at 'ref', and how long it may
+	// be cached for (zero means it shouldn't be cached).
+	resolve(ctx context.Context, ref *url.URL) (string, time.Duration, error)
+}
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+// secretResolver resolves external secret references using the provider
+// registered for their scheme, and caches them until they need refreshing.
+type secretResolver struct {
+	providers map[string]secretProvider
+	mu        sync.Mutex
+	cache     map[string]cachedSecret
+}
+
+func newSecretResolver() *secretResolver {
+	return &secretResolver{
+		providers: map[string]secretProvider{
+			"file": fileProvider{},
+			"vault": &vaultProvider{
+				addr:   os.Getenv(VaultAddrEnv),
+				token:  os.Getenv(VaultTokenEnv),
+				client: &http.Client{Timeout: 30 * time.Second},
+			},
+		},
+		cache: make(map[string]cachedSecret),
+	}
+}
+
+func (r *secretResolver) resolve(ctx context.Context, ref string) (string, error) {
+	r.mu.Lock()
+	cached, ok := r.cache[ref]
+	r.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.value, nil
+	}
+	u, err := ppsutil.ParseSecretRef(ref)
+	if err != nil {
+		return "", err
+	}
+	provider, ok := r.providers[u.Scheme]
+	if !ok {
+		return "", errors.Errorf("no secret provider for %q", ref)
+	}
+	value, ttl, err := provider.resolve(ctx, u)
+	if err != nil {
+		return "", errors.Wrapf(err, "could not resolve secret %q", ref)
+	}
+	if ttl > 0 {
+		r.mu.Lock()
+		r.cache[ref] = cachedSecret{value: value, expires: time.Now().Add(ttl)}
+		r.mu.Unlock()
+	}
+	return value, nil
+}
+
+// inject resolves the external secrets in 'secrets', writes the ones with a
+// mount path to files under 'rootDir', and returns the ones with an env var
+// as environment variables. If 'uid' and 'gid' are set, the files are owned
+// by them, so that only the user code can read them.
+func (r *secretResolver) inject(ctx context.Context, rootDir string, uid, gid *uint32, secrets []*pps.SecretMount) ([]string, error) {
+	var env []string
+	for _, secret := range secrets {
+		if secret.External == "" {
+			continue
+		}
+		value, err := r.resolve(ctx, secret.External)
+		if err != nil {
+			return nil, err
+		}
+		if secret.EnvVar != "" {
+			env = append(env, fmt.Sprintf("%s=%s", secret.EnvVar, value))
+		}
+		if secret.MountPath != "" {
+			if err := writeSecretFile(filepath.Join(rootDir, secret.MountPath, secretFileName(secret)), value, uid, gid); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return env, nil
+}
+
+// secretFileName returns the name of the file that a mounted external secret
+// is written to: its key if set, otherwise the last element of the reference.
+func secretFileName(secret *pps.SecretMount) string {
+	if secret.Key != "" {
+		return secret.Key
+	}
+	u, err := url.Parse(secret.External)
+	if err != nil {
+		return path.Base(secret.External)
+	}
+	if u.Fragment != "" {
+		return u.Fragment
+	}
+	return path.Base(u.Host + u.Path)
+}
+
+// writeSecretFile replaces the file at 'p' atomically, so user code never
+// sees a partially written secret. The file, and its directory if it's
+// created here, are only accessible to their owner.
+func writeSecretFile(p, value string, uid, gid *uint32) error {
+	dir := filepath.Dir(p)
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return errors.EnsureStack(err)
+	}
+	if err := os.Mkdir(dir, 0700); err == nil {
+		if err := chownSecret(dir, uid, gid); err != nil {
+			return err
+		}
+	} else if !os.IsExist(err) {
+		return errors.EnsureStack(err)
+	}
+	tmp := p + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(value), 0600); err != nil {
+		return errors.EnsureStack(err)
+	}
+	if err := chownSecret(tmp, uid, gid); err != nil {
+		return err
+	}
+	return errors.EnsureStack(os.Rename(tmp, p))
+}
+
+func chownSecret(p string, uid, gid *uint32) error {
+	if uid == nil || gid == nil {
+		return nil
+	}
+	return errors.EnsureStack(os.Chown(p, int(*uid), int(*gid)))
+}
+
+// fileProvider resolves "file:///path[#key]" references. Files are read for
+// every datum, so rotating the file rotates the secret. If a key is given,
+// the file must hold a JSON object and the value of that key is used.
+type fileProvider struct{}
+
+func (fileProvider) resolve(_ context.Context, ref *url.URL) (string, time.Duration, error) {
+	data, err := ioutil.ReadFile(ref.Path)
+	if err != nil {
+		return "", 0, errors.EnsureStack(err)
+	}
+	if ref.Fragment == "" {
+		return string(data), 0, nil
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return "", 0, errors.Wrapf(err, "could not parse %s", ref.Path)
+	}
+	value, err := secretValue(values, ref.Fragment)
+	return value, 0, err
+}
+
+// vaultProvider resolves "vault://path#key" references by reading 'path'
+// from vault's HTTP API. Both the v1 and v2 KV secret engines are supported.
+// Secrets are cached for their lease duration, or defaultSecretRefresh if
+// they don't have one.
+type vaultProvider struct {
+	addr   string
+	token  string
+	client *http.Client
+}
+
+type vaultResponse struct {
+	LeaseDuration int64                      `json:"lease_duration"`
+	Data          map[string]json.RawMessage `json:"data"`
+}
+
+func (p *vaultProvider) resolve(ctx context.Context, ref *url.URL) (string, time.Duration, error) {
+	if p.addr == "" {
+		return "", 0, errors.Errorf("%s must be set to use vault secrets", VaultAddrEnv)
+	}
+	req, err := http.NewRequest("GET", strings.TrimSuffix(p.addr, "/")+"/v1/"+ref.Host+ref.Path, nil)
+	if err != nil {
+		return "", 0, errors.EnsureStack(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Vault-Token", p.token)
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", 0, errors.EnsureStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, errors.Errorf("vault returned %s", resp.Status)
+	}
+	var vaultResp vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&vaultResp); err != nil {
+		return "", 0, errors.EnsureStack(err)
+	}
+	values := vaultResp.Data
+	// The KV v2 engine nests the secret under "data", next to its "metadata"
+	if nested, ok := values["data"]; ok {
+		if _, ok := values["metadata"]; ok {
+			values = nil
+			if err := json.Unmarshal(nested, &values); err != nil {
+				return "", 0, errors.EnsureStack(err)
+			}
+		}
+	}
+	value, err := secretValue(values, ref.Fragment)
+	if err != nil {
+		return "", 0, err
+	}
+	ttl := time.Duration(vaultResp.LeaseDuration) * time.Second
+	if ttl <= 0 {
+		ttl = defaultSecretRefresh
+	}
+	return value, ttl, nil
+}
+
+// secretValue returns the value of 'key' in 'values'. Strings are returned
+// as is, other values as JSON.
+func secretValue(values map[string]json.RawMessage, key string) (string, error) {
+	raw, ok := values[key]
+	if !ok {
+		return "", errors.Errorf("key %q not found", key)
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+	return string(raw), nil
+}
@@ -0,0 +1,126 @@
+package driver
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+)
+
+// newTestVault returns a stand-in for vault's HTTP API that serves a KV v2
+// secret at "secret/data/db" whose password changes on every read.
+func newTestVault(t *testing.T, leaseDuration int) (*httptest.Server, *int64) {
+	var reads int64
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/db" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		n := atomic.AddInt64(&reads, 1)
+		fmt.Fprintf(w, `{"lease_duration": %d, "data": {"data": {"password": "pw%d", "port": 5432}, "metadata": {"version": %d}}}`, leaseDuration, n, n)
+	}))
+	t.Cleanup(s.Close)
+	return s, &reads
+}
+
+func newTestResolver(addr string) *secretResolver {
+	r := newSecretResolver()
+	r.providers["vault"] = &vaultProvider{addr: addr, token: "token", client: http.DefaultClient}
+	return r
+}
+
+func TestVaultSecrets(t *testing.T) {
+	vault, _ := newTestVault(t, 0)
+	r := newTestResolver(vault.URL)
+	ctx := context.Background()
+	value, err := r.resolve(ctx, "vault://secret/data/db#password")
+	require.NoError(t, err)
+	require.Equal(t, "pw1", value)
+	value, err = r.resolve(ctx, "vault://secret/data/db#port")
+	require.NoError(t, err)
+	require.Equal(t, "5432", value)
+	_, err = r.resolve(ctx, "vault://secret/data/db#user")
+	require.YesError(t, err)
+	_, err = r.resolve(ctx, "vault://secret/data/other#password")
+	require.YesError(t, err)
+	_, err = r.resolve(ctx, "unknown://secret#password")
+	require.YesError(t, err)
+}
+
+func TestVaultSecretRotation(t *testing.T) {
+	// Without a lease duration, the secret is cached for defaultSecretRefresh
+	vault, reads := newTestVault(t, 0)
+	r := newTestResolver(vault.URL)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		value, err := r.resolve(ctx, "vault://secret/data/db#password")
+		require.NoError(t, err)
+		require.Equal(t, "pw1", value)
+	}
+	require.Equal(t, int64(1), atomic.LoadInt64(reads))
+	// Once the cached secret expires, the rotated secret is fetched
+	r.cache["vault://secret/data/db#password"] = cachedSecret{value: "pw1"}
+	value, err := r.resolve(ctx, "vault://secret/data/db#password")
+	require.NoError(t, err)
+	require.Equal(t, "pw2", value)
+}
+
+func TestFileSecrets(t *testing.T) {
+	dir := t.TempDir()
+	token := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(token, []byte("abc"), 0644))
+	creds := filepath.Join(dir, "creds.json")
+	require.NoError(t, ioutil.WriteFile(creds, []byte(`{"user": "admin"}`), 0644))
+	r := newSecretResolver()
+	ctx := context.Background()
+	value, err := r.resolve(ctx, "file://"+token)
+	require.NoError(t, err)
+	require.Equal(t, "abc", value)
+	value, err = r.resolve(ctx, "file://"+creds+"#user")
+	require.NoError(t, err)
+	require.Equal(t, "admin", value)
+	// Files aren't cached, so rotating the file rotates the secret
+	require.NoError(t, ioutil.WriteFile(token, []byte("def"), 0644))
+	value, err = r.resolve(ctx, "file://"+token)
+	require.NoError(t, err)
+	require.Equal(t, "def", value)
+}
+
+func TestInjectSecrets(t *testing.T) {
+	vault, _ := newTestVault(t, 0)
+	r := newTestResolver(vault.URL)
+	root := t.TempDir()
+	env, err := r.inject(context.Background(), root, nil, nil, []*pps.SecretMount{
+		{Name: "k8s-secret", EnvVar: "IGNORED", Key: "key"},
+		{External: "vault://secret/data/db#password", EnvVar: "DB_PASSWORD"},
+		{External: "vault://secret/data/db#password", MountPath: "/var/secrets"},
+		{External: "vault://secret/data/db#port", MountPath: "/var/secrets", Key: "db_port"},
+	})
+	require.NoError(t, err)
+	require.ElementsEqual(t, []string{"DB_PASSWORD=pw1"}, env)
+	data, err := ioutil.ReadFile(filepath.Join(root, "var", "secrets", "password"))
+	require.NoError(t, err)
+	require.Equal(t, "pw1", string(data))
+	data, err = ioutil.ReadFile(filepath.Join(root, "var", "secrets", "db_port"))
+	require.NoError(t, err)
+	require.Equal(t, "5432", string(data))
+	// Secrets are only readable by the user code
+	fi, err := os.Stat(filepath.Join(root, "var", "secrets"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0700), fi.Mode().Perm())
+	fi, err = os.Stat(filepath.Join(root, "var", "secrets", "password"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+}
@@ -41,6 +41,7 @@ type TaggedLogger interface {
 	WithJob(jobID string) TaggedLogger
 	WithData(data []*common.Input) TaggedLogger
 	WithUserCode() TaggedLogger
+	WithLevel(level pps.LogLevel) TaggedLogger
 
 	JobID() string
 }
@@ -143,6 +144,14 @@ func (logger *taggedLogger) WithUserCode() TaggedLogger {
 	return result
 }
 
+// WithLevel clones the current logger and returns a new one that will log
+// with the given level.
+func (logger *taggedLogger) WithLevel(level pps.LogLevel) TaggedLogger {
+	result := logger.clone()
+	result.template.Level = level
+	return result
+}
+
 // JobID returns the current job that the logger is configured with.
 func (logger *taggedLogger) JobID() string {
 	return logger.template.JobID
@@ -182,7 +191,7 @@ func (logger *taggedLogger) LogStep(name string, cb func() error) (retErr error)
 	logger.Logf("started %v", name)
 	defer func() {
 		if retErr != nil {
-			logger.Logf("errored %v: %v", name, retErr)
+			logger.WithLevel(pps.LogLevel_LOG_ERROR).Logf("errored %v: %v", name, retErr)
 		} else {
 			logger.Logf("finished %v", name)
 		}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
 	"github.com/pachyderm/pachyderm/v2/src/server/worker/common"
 )
 
@@ -20,6 +21,7 @@ type MockLogger struct {
 	Job      string
 	Data     []*common.Input
 	UserCode bool
+	Level    pps.LogLevel
 }
 
 // Not used - forces a compile-time error in this file if MockLogger does not
@@ -106,6 +108,14 @@ func (ml *MockLogger) WithUserCode() TaggedLogger {
 	return result
 }
 
+// WithLevel duplicates the MockLogger and returns a new one tagged with the
+// given log level.
+func (ml *MockLogger) WithLevel(level pps.LogLevel) TaggedLogger {
+	result := ml.clone()
+	result.Level = level
+	return result
+}
+
 // JobID returns the currently tagged job ID for the logger.  This is redundant
 // for MockLogger, as you can access ml.Job directly, but it is needed for the
 // TaggedLogger interface.
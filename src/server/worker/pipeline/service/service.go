@@ -61,7 +61,10 @@ func Run(driver driver.Driver, logger logs.TaggedLogger) error {
 		return datum.WithSet(pachClient, storageRoot, func(s *datum.Set) error {
 			inputs := meta.Inputs
 			logger = logger.WithData(inputs)
-			env := driver.UserCodeEnv(logger.JobID(), commitInfo.Commit, inputs)
+			env, err := driver.UserCodeEnv(logger.JobID(), commitInfo.Commit, inputs)
+			if err != nil {
+				return err
+			}
 			return s.WithDatum(ctx, meta, func(d *datum.Datum) error {
 				return driver.WithActiveData(inputs, d.PFSStorageRoot(), func() error {
 					return d.Run(ctx, func(runCtx context.Context) error {
@@ -1,12 +1,253 @@
 package spout
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/backoff"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/tarutil"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	pfsserver "github.com/pachyderm/pachyderm/v2/src/server/pfs"
 	"github.com/pachyderm/pachyderm/v2/src/server/worker/driver"
 	"github.com/pachyderm/pachyderm/v2/src/server/worker/logs"
 )
 
+const (
+	// CheckpointBranch is the branch in a checkpointed spout's output repo
+	// that holds its checkpoints.
+	CheckpointBranch = "spout_checkpoint"
+	// checkpointFile is the name of the checkpoint, both in CheckpointBranch
+	// and in the worker's input dir.
+	checkpointFile = "checkpoint"
+	outputDir      = "out"
+)
+
 // Run will run a spout pipeline until the driver is canceled.
 func Run(driver driver.Driver, logger logs.TaggedLogger) error {
 	logger = logger.WithJob("spout")
+	if driver.PipelineInfo().Spout.Checkpoint {
+		return runCheckpointed(driver, logger)
+	}
 	return driver.RunUserCode(driver.PachClient().Ctx(), logger, nil)
 }
+
+// runCheckpointed runs the user code repeatedly. Each run is handed the last
+// committed checkpoint at /pfs/checkpoint and writes its output to /pfs/out,
+// along with a new checkpoint. The output and the checkpoint are then
+// committed in a single transaction, so a restarted spout always resumes from
+// the checkpoint that matches the output in its repo. Runs that write no
+// output and leave the checkpoint unchanged don't commit anything.
+func runCheckpointed(driver driver.Driver, logger logs.TaggedLogger) error {
+	pachClient := driver.PachClient()
+	repo := driver.PipelineInfo().Pipeline.Name
+	branch := driver.PipelineInfo().OutputBranch
+	checkpointPath := filepath.Join(driver.InputDir(), checkpointFile)
+	outputPath := filepath.Join(driver.InputDir(), outputDir)
+	for {
+		if err := backoff.RetryUntilCancel(pachClient.Ctx(), func() error {
+			if err := squashOpenCommits(pachClient, repo, branch, CheckpointBranch); err != nil {
+				return err
+			}
+			if err := getCheckpoint(pachClient, repo, checkpointPath); err != nil {
+				return err
+			}
+			prevCheckpoint, err := fileDigest(checkpointPath)
+			if err != nil {
+				return err
+			}
+			if err := os.RemoveAll(outputPath); err != nil {
+				return errors.EnsureStack(err)
+			}
+			if err := os.MkdirAll(outputPath, 0777); err != nil {
+				return errors.EnsureStack(err)
+			}
+			if err := driver.RunUserCode(pachClient.Ctx(), logger, nil); err != nil {
+				return err
+			}
+			return commitOutput(pachClient, repo, branch, outputPath, checkpointPath, prevCheckpoint)
+		}, backoff.NewInfiniteBackOff(), func(err error, d time.Duration) error {
+			logger.Logf("error in checkpointed spout: %v, retrying in %v", err, d)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+// squashOpenCommits removes any commit left open on 'branches' by a previous
+// run that failed before committing.
+func squashOpenCommits(pachClient *client.APIClient, repo string, branches ...string) error {
+	for _, branch := range branches {
+		commitInfo, err := pachClient.InspectCommit(repo, branch)
+		if err != nil {
+			if pfsserver.IsNoHeadErr(err) || pfsserver.IsBranchNotFoundErr(err) {
+				continue
+			}
+			return err
+		}
+		if commitInfo.Finished == nil {
+			if err := pachClient.SquashCommit(repo, commitInfo.Commit.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getCheckpoint writes the last committed checkpoint to 'checkpointPath'. If
+// the spout hasn't committed a checkpoint yet, 'checkpointPath' is removed.
+func getCheckpoint(pachClient *client.APIClient, repo, checkpointPath string) (retErr error) {
+	if err := os.RemoveAll(checkpointPath); err != nil {
+		return errors.EnsureStack(err)
+	}
+	if _, err := pachClient.InspectFile(repo, CheckpointBranch, checkpointFile); err != nil {
+		if pfsserver.IsNoHeadErr(err) || pfsserver.IsBranchNotFoundErr(err) || pfsserver.IsFileNotFoundErr(err) {
+			return nil
+		}
+		return err
+	}
+	f, err := os.Create(checkpointPath)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	defer func() {
+		if err := f.Close(); retErr == nil {
+			retErr = errors.EnsureStack(err)
+		}
+	}()
+	return pachClient.GetFile(repo, CheckpointBranch, checkpointFile, f)
+}
+
+// commitOutput uploads the contents of 'outputPath' to 'branch', and the file
+// at 'checkpointPath' to CheckpointBranch, then finishes both commits in a
+// single transaction. Only the branches that have changed are committed to:
+// 'outputPath' must contain files, and the checkpoint's digest must differ
+// from 'prevCheckpoint'. If the user code didn't write a checkpoint, the
+// previous one is kept.
+func commitOutput(pachClient *client.APIClient, repo, branch, outputPath, checkpointPath, prevCheckpoint string) (retErr error) {
+	hasOutput, err := hasFiles(outputPath)
+	if err != nil {
+		return err
+	}
+	checkpoint, err := fileDigest(checkpointPath)
+	if err != nil {
+		return err
+	}
+	hasCheckpoint := checkpoint != "" && checkpoint != prevCheckpoint
+	if !hasOutput && !hasCheckpoint {
+		return nil
+	}
+	var commits []*pfs.Commit
+	defer func() {
+		if retErr != nil {
+			// Don't leave output behind without its checkpoint (or vice versa)
+			for _, commit := range commits {
+				if err := pachClient.SquashCommit(repo, commit.ID); err != nil {
+					retErr = errors.Wrapf(retErr, "error squashing commit: %v", err)
+				}
+			}
+		}
+	}()
+	if hasOutput {
+		outputCommit, err := pachClient.StartCommit(repo, branch)
+		if err != nil {
+			return err
+		}
+		commits = append(commits, outputCommit)
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(tarutil.Export(outputPath, pw))
+		}()
+		if err := pachClient.AppendFileTar(repo, outputCommit.ID, false, pr); err != nil {
+			pr.CloseWithError(err)
+			return err
+		}
+	}
+	if hasCheckpoint {
+		checkpointCommit, err := pachClient.StartCommit(repo, CheckpointBranch)
+		if err != nil {
+			return err
+		}
+		commits = append(commits, checkpointCommit)
+		if err := putCheckpoint(pachClient, repo, checkpointCommit.ID, checkpointPath); err != nil {
+			return err
+		}
+	}
+	_, err = pachClient.RunBatchInTransaction(func(builder *client.TransactionBuilder) error {
+		for _, commit := range commits {
+			if _, err := builder.PfsAPIClient.FinishCommit(pachClient.Ctx(), &pfs.FinishCommitRequest{
+				Commit: commit,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// putCheckpoint replaces the checkpoint in 'commitID' with the file at
+// 'checkpointPath'.
+func putCheckpoint(pachClient *client.APIClient, repo, commitID, checkpointPath string) (retErr error) {
+	f, err := os.Open(checkpointPath)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	defer func() {
+		if err := f.Close(); retErr == nil {
+			retErr = errors.EnsureStack(err)
+		}
+	}()
+	return pachClient.PutFileOverwrite(repo, commitID, checkpointFile, f)
+}
+
+// errFound stops hasFiles' walk at the first file
+var errFound = errors.New("found")
+
+// hasFiles returns true if there are any files under 'dir'.
+func hasFiles(dir string) (bool, error) {
+	err := filepath.Walk(dir, func(_ string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			return errFound
+		}
+		return nil
+	})
+	if errors.Is(err, errFound) {
+		return true, nil
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return false, errors.EnsureStack(err)
+}
+
+// fileDigest returns a digest of the contents of the file at 'path', or "" if
+// it doesn't exist.
+func fileDigest(path string) (_ string, retErr error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", errors.EnsureStack(err)
+	}
+	defer func() {
+		if err := f.Close(); retErr == nil {
+			retErr = errors.EnsureStack(err)
+		}
+	}()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.EnsureStack(err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
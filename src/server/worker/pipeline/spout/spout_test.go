@@ -0,0 +1,133 @@
+package spout
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/dbutil"
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/internal/testpachd"
+	tu "github.com/pachyderm/pachyderm/v2/src/internal/testutil"
+)
+
+func TestHasFiles(t *testing.T) {
+	dir := t.TempDir()
+	hasOutput, err := hasFiles(filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	require.False(t, hasOutput)
+
+	// empty directories don't count as output
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "a", "b"), 0777))
+	hasOutput, err = hasFiles(dir)
+	require.NoError(t, err)
+	require.False(t, hasOutput)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a", "b", "file"), []byte("foo"), 0666))
+	hasOutput, err = hasFiles(dir)
+	require.NoError(t, err)
+	require.True(t, hasOutput)
+}
+
+func TestFileDigest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	digest, err := fileDigest(path)
+	require.NoError(t, err)
+	require.Equal(t, "", digest)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("1"), 0666))
+	digest1, err := fileDigest(path)
+	require.NoError(t, err)
+	require.NotEqual(t, "", digest1)
+	require.NoError(t, ioutil.WriteFile(path, []byte("2"), 0666))
+	digest2, err := fileDigest(path)
+	require.NoError(t, err)
+	require.NotEqual(t, digest1, digest2)
+}
+
+func TestCommitOutput(t *testing.T) {
+	t.Parallel()
+	db := dbutil.NewTestDB(t)
+	require.NoError(t, testpachd.WithRealEnv(db, func(env *testpachd.RealEnv) error {
+		c := env.PachClient
+		repo := tu.UniqueString(t.Name())
+		require.NoError(t, c.CreateRepo(repo))
+		dir := t.TempDir()
+		outputPath := filepath.Join(dir, outputDir)
+		checkpointPath := filepath.Join(dir, checkpointFile)
+		require.NoError(t, os.MkdirAll(outputPath, 0777))
+		numCommits := func(branch string) int {
+			commitInfos, err := c.ListCommit(repo, branch, "", 0)
+			require.NoError(t, err)
+			return len(commitInfos)
+		}
+
+		// A run without output or a checkpoint commits nothing
+		require.NoError(t, commitOutput(c, repo, "master", outputPath, checkpointPath, ""))
+		commitInfos, err := c.ListCommit(repo, "", "", 0)
+		require.NoError(t, err)
+		require.Equal(t, 0, len(commitInfos))
+
+		// The output and the checkpoint are committed together
+		require.NoError(t, ioutil.WriteFile(filepath.Join(outputPath, "file"), []byte("foo"), 0666))
+		require.NoError(t, ioutil.WriteFile(checkpointPath, []byte("1"), 0666))
+		require.NoError(t, commitOutput(c, repo, "master", outputPath, checkpointPath, ""))
+		buf := &bytes.Buffer{}
+		require.NoError(t, c.GetFile(repo, "master", "file", buf))
+		require.Equal(t, "foo", buf.String())
+		require.NoError(t, getCheckpoint(c, repo, checkpointPath))
+		checkpoint, err := ioutil.ReadFile(checkpointPath)
+		require.NoError(t, err)
+		require.Equal(t, "1", string(checkpoint))
+		require.Equal(t, 1, numCommits("master"))
+		require.Equal(t, 1, numCommits(CheckpointBranch))
+
+		// A run without output that leaves the checkpoint unchanged commits
+		// nothing
+		require.NoError(t, os.RemoveAll(outputPath))
+		require.NoError(t, os.MkdirAll(outputPath, 0777))
+		prevCheckpoint, err := fileDigest(checkpointPath)
+		require.NoError(t, err)
+		require.NoError(t, commitOutput(c, repo, "master", outputPath, checkpointPath, prevCheckpoint))
+		require.Equal(t, 1, numCommits("master"))
+		require.Equal(t, 1, numCommits(CheckpointBranch))
+
+		// A new checkpoint without output is committed on its own, so it
+		// doesn't trigger downstream jobs
+		require.NoError(t, ioutil.WriteFile(checkpointPath, []byte("2"), 0666))
+		require.NoError(t, commitOutput(c, repo, "master", outputPath, checkpointPath, prevCheckpoint))
+		require.Equal(t, 1, numCommits("master"))
+		require.Equal(t, 2, numCommits(CheckpointBranch))
+		require.NoError(t, getCheckpoint(c, repo, checkpointPath))
+		checkpoint, err = ioutil.ReadFile(checkpointPath)
+		require.NoError(t, err)
+		require.Equal(t, "2", string(checkpoint))
+		return nil
+	}))
+}
+
+func TestSquashOpenCommits(t *testing.T) {
+	t.Parallel()
+	db := dbutil.NewTestDB(t)
+	require.NoError(t, testpachd.WithRealEnv(db, func(env *testpachd.RealEnv) error {
+		c := env.PachClient
+		repo := tu.UniqueString(t.Name())
+		require.NoError(t, c.CreateRepo(repo))
+		// Branches without commits are skipped
+		require.NoError(t, squashOpenCommits(c, repo, "master", CheckpointBranch))
+
+		require.NoError(t, c.PutFile(repo, "master", "file", bytes.NewReader([]byte("foo"))))
+		_, err := c.StartCommit(repo, "master")
+		require.NoError(t, err)
+		require.NoError(t, squashOpenCommits(c, repo, "master", CheckpointBranch))
+		commitInfo, err := c.InspectCommit(repo, "master")
+		require.NoError(t, err)
+		require.NotNil(t, commitInfo.Finished)
+		buf := &bytes.Buffer{}
+		require.NoError(t, c.GetFile(repo, "master", "file", buf))
+		require.Equal(t, "foo", buf.String())
+		return nil
+	}))
+}
@@ -91,9 +91,10 @@ func (jdi *JobDatumIterator) Iterate(cb func(*datum.Meta) error) error {
 		}
 		if jdi.skippableDatum(metas[0], metas[1]) {
 			jdi.stats.Skipped++
-			return nil
 		}
-		return cb(metas[0])
+		// Datums that exist in the parent job are processed once the parent
+		// job is done, so their previous output is known.
+		return nil
 	}); err != nil {
 		return err
 	}
@@ -114,12 +115,11 @@ func (jdi *JobDatumIterator) Iterate(cb func(*datum.Meta) error) error {
 			if metas[0].JobID != jdi.jobID {
 				return jdi.deleteDatum(metas[1])
 			}
-			// Datum exists in both jobs, but was not processed by the parent.
+			// Datum exists in both jobs, but has no output in the parent job.
 			if jdi.skippableDatum(metas[0], metas[1]) {
 				jdi.stats.Skipped--
-				return cb(metas[0])
 			}
-			return nil
+			return cb(metas[0])
 		}
 		// Check if a skipped datum was not successfully processed by the parent.
 		if jdi.skippableDatum(metas[0], metas[1]) {
@@ -127,14 +127,27 @@ func (jdi *JobDatumIterator) Iterate(cb func(*datum.Meta) error) error {
 				return nil
 			}
 			jdi.stats.Skipped--
-			if err := cb(metas[0]); err != nil {
-				return err
-			}
 		}
-		return jdi.deleteDatum(metas[2])
+		return jdi.reprocessDatum(metas[0], metas[2], cb)
 	})
 }
 
+// reprocessDatum processes a datum that has output in the parent job, which
+// its new output replaces. The datum replaces the output itself (see
+// datum.Meta.Previous), so identical output isn't uploaded again, unless the
+// parent's meta doesn't record the files the datum wrote, in which case the
+// output is deleted here.
+func (jdi *JobDatumIterator) reprocessDatum(meta, prev *datum.Meta, cb func(*datum.Meta) error) error {
+	if len(prev.Outputs) == 0 {
+		if err := jdi.deleteDatum(prev); err != nil {
+			return err
+		}
+		return cb(meta)
+	}
+	meta.Previous = prev
+	return cb(meta)
+}
+
 func (jdi *JobDatumIterator) deleteDatum(meta *datum.Meta) error {
 	if jdi.deleter == nil {
 		return nil
@@ -84,6 +84,49 @@ func TestEmptyBase(t *testing.T) {
 	requireIteratorContents(t, jdi, jobMetas)
 }
 
+func TestDeleteRemovedDatums(t *testing.T) {
+	hasher := &testHasher{}
+	baseMetas := newTestMetas(uuid.NewWithoutDashes())
+	baseMetas[0].Outputs = []string{"/a"}
+	baseMetas[1].Hash = hasher.Hash(baseMetas[1].Inputs)
+	chain := newTestChain(baseMetas...)
+	jobID := uuid.NewWithoutDashes()
+	jobMetas := newTestMetas(jobID)[:2]
+	ti := newTestIterator(jobMetas)
+	jdi := chain.CreateJob(context.Background(), jobID, ti, ti)
+	var deleted []string
+	jdi.SetDeleter(func(meta *datum.Meta) error {
+		deleted = append(deleted, meta.Inputs[0].FileInfo.File.Path)
+		return nil
+	})
+	// "a" is processed again, and replaces its previous output itself, "b" is
+	// skipped, and "c" was removed from the job's input.
+	requireIteratorContents(t, jdi, jobMetas[:1])
+	require.Equal(t, []string{"c"}, deleted)
+	require.Equal(t, baseMetas[0], jobMetas[0].Previous)
+}
+
+func TestDeleteReprocessedDatumsWithoutOutputs(t *testing.T) {
+	baseMetas := newTestMetas(uuid.NewWithoutDashes())
+	chain := newTestChain(baseMetas...)
+	jobID := uuid.NewWithoutDashes()
+	jobMetas := newTestMetas(jobID)
+	ti := newTestIterator(jobMetas)
+	jdi := chain.CreateJob(context.Background(), jobID, ti, ti)
+	var deleted []string
+	jdi.SetDeleter(func(meta *datum.Meta) error {
+		deleted = append(deleted, meta.Inputs[0].FileInfo.File.Path)
+		return nil
+	})
+	// The base metas don't record the files the datums wrote, so their
+	// output is deleted before they're processed again.
+	requireIteratorContents(t, jdi, jobMetas)
+	require.Equal(t, []string{"a", "b", "c"}, deleted)
+	for _, meta := range jobMetas {
+		require.Nil(t, meta.Previous)
+	}
+}
+
 // TODO: Make work with V2?
 //func TestAdditiveOnBase(t *testing.T) {
 //	chain := newTestChain(newTestMetas(uuid.NewWithoutDashes())...)
@@ -2,6 +2,7 @@ package transform
 
 import (
 	"context"
+	"io"
 	"path/filepath"
 
 	etcd "github.com/coreos/etcd/clientv3"
@@ -94,7 +95,7 @@ func (td *testDriver) WithContext(ctx context.Context) driver.Driver {
 func (td *testDriver) WithActiveData(inputs []*common.Input, dir string, cb func() error) error {
 	return td.inner.WithActiveData(inputs, dir, cb)
 }
-func (td *testDriver) UserCodeEnv(job string, commit *pfs.Commit, inputs []*common.Input) []string {
+func (td *testDriver) UserCodeEnv(job string, commit *pfs.Commit, inputs []*common.Input) ([]string, error) {
 	return td.inner.UserCodeEnv(job, commit, inputs)
 }
 func (td *testDriver) RunUserCode(ctx context.Context, logger logs.TaggedLogger, env []string) error {
@@ -103,6 +104,12 @@ func (td *testDriver) RunUserCode(ctx context.Context, logger logs.TaggedLogger,
 func (td *testDriver) RunUserErrorHandlingCode(ctx context.Context, logger logs.TaggedLogger, env []string) error {
 	return td.inner.RunUserErrorHandlingCode(ctx, logger, env)
 }
+func (td *testDriver) RunDebugShell(ctx context.Context, env []string, stdin io.Reader, out io.Writer) error {
+	return td.inner.RunDebugShell(ctx, env, stdin, out)
+}
+func (td *testDriver) NewDatumBatch(ctx context.Context, logger logs.TaggedLogger, env []string) driver.DatumBatch {
+	return td.inner.NewDatumBatch(ctx, logger, env)
+}
 func (td *testDriver) DeleteJob(stm col.STM, ji *pps.EtcdJobInfo) error {
 	return td.inner.DeleteJob(stm, ji)
 }
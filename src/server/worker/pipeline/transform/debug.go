@@ -0,0 +1,76 @@
+package transform
+
+import (
+	"context"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+)
+
+// defaultDebugTimeout is how long a failed datum is held for debugging if the
+// pipeline's debug_on_failure doesn't set a timeout
+const defaultDebugTimeout = 30 * time.Minute
+
+// debugHold is a failed datum that the worker is holding for debugging
+type debugHold struct {
+	jobID   string
+	datumID string
+	env     []string
+	// ctx is cancelled when the hold ends
+	ctx      context.Context
+	attached bool
+	// detached is closed when the debug session ends
+	detached chan struct{}
+}
+
+func debugTimeout(spec *pps.DebugOnFailure) (time.Duration, error) {
+	if spec.Timeout == nil {
+		return defaultDebugTimeout, nil
+	}
+	return types.DurationFromProto(spec.Timeout)
+}
+
+// holdForDebug blocks (while the failed datum's data is still active) until a
+// debug session for the datum ends, 'timeout' elapses, or 'ctx' is cancelled.
+func (s *Status) holdForDebug(ctx context.Context, jobID, datumID string, env []string, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	hold := &debugHold{
+		jobID:    jobID,
+		datumID:  datumID,
+		env:      env,
+		ctx:      ctx,
+		detached: make(chan struct{}),
+	}
+	s.withLock(func() {
+		s.debugHold = hold
+	})
+	defer s.withLock(func() {
+		s.debugHold = nil
+	})
+	select {
+	case <-hold.detached:
+	case <-ctx.Done():
+	}
+}
+
+// DebugDatum attaches a debug session to the held datum 'datumID' of job
+// 'jobID'. It returns the datum's user code environment and a context that is
+// cancelled when the hold ends. The caller must call 'detach' when the session
+// ends, which lets the datum be marked failed.
+func (s *Status) DebugDatum(jobID, datumID string) (_ context.Context, env []string, detach func(), retErr error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	hold := s.debugHold
+	if hold == nil || hold.jobID != jobID || hold.datumID != datumID {
+		return nil, nil, nil, errors.Errorf("datum %v of job %v is not being held for debugging", datumID, jobID)
+	}
+	if hold.attached {
+		return nil, nil, nil, errors.Errorf("datum %v of job %v is already being debugged", datumID, jobID)
+	}
+	hold.attached = true
+	return hold.ctx, hold.env, func() { close(hold.detached) }, nil
+}
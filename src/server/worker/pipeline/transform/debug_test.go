@@ -0,0 +1,56 @@
+package transform
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+)
+
+func TestDebugHold(t *testing.T) {
+	status := &Status{}
+	_, _, _, err := status.DebugDatum("job", "datum")
+	require.YesError(t, err)
+
+	released := make(chan struct{})
+	go func() {
+		defer close(released)
+		status.holdForDebug(context.Background(), "job", "datum", []string{"FOO=bar"}, time.Minute)
+	}()
+	var detach func()
+	require.NoErrorWithinTRetry(t, 5*time.Second, func() error {
+		var env []string
+		var err error
+		_, env, detach, err = status.DebugDatum("job", "datum")
+		if err != nil {
+			return err
+		}
+		require.Equal(t, []string{"FOO=bar"}, env)
+		return nil
+	})
+	// only one session may attach, and only to the held datum
+	_, _, _, err = status.DebugDatum("job", "datum")
+	require.YesError(t, err)
+	_, _, _, err = status.DebugDatum("job", "other")
+	require.YesError(t, err)
+
+	select {
+	case <-released:
+		t.Fatal("datum was released before the debug session ended")
+	default:
+	}
+	detach()
+	<-released
+	_, _, _, err = status.DebugDatum("job", "datum")
+	require.YesError(t, err)
+}
+
+func TestDebugHoldTimeout(t *testing.T) {
+	status := &Status{}
+	start := time.Now()
+	status.holdForDebug(context.Background(), "job", "datum", nil, 100*time.Millisecond)
+	require.True(t, time.Since(start) >= 100*time.Millisecond)
+	_, _, _, err := status.DebugDatum("job", "datum")
+	require.YesError(t, err)
+}
@@ -0,0 +1,71 @@
+package transform
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+	"github.com/pachyderm/pachyderm/v2/src/internal/sql"
+	"github.com/pachyderm/pachyderm/v2/src/internal/tarutil"
+	"github.com/pachyderm/pachyderm/v2/src/pfs"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+)
+
+// Egress copies the files in 'commit' to the target in 'egress'. Egress may be
+// attempted several times for the same commit, so each target must tolerate
+// receiving the same files again.
+func Egress(pachClient *client.APIClient, commit *pfs.Commit, egress *pps.Egress) error {
+	switch {
+	case egress.GetSqlDatabase() != nil:
+		return egressSQLDatabase(pachClient, commit, egress.GetSqlDatabase())
+	case egress.GetFileSystem() != nil:
+		return egressFileSystem(pachClient, commit, egress.GetFileSystem())
+	}
+	return pachClient.GetFileURL(commit.Repo.Name, commit.ID, "/", egress.URL)
+}
+
+// egressSQLDatabase loads each file in 'commit' into the target table, in a
+// separate transaction per file.
+func egressSQLDatabase(pachClient *client.APIClient, commit *pfs.Commit, target *pps.SQLDatabaseEgress) (retErr error) {
+	dsn, ok := os.LookupEnv(target.DSNEnvVar)
+	if !ok {
+		return errors.Errorf("environment variable %q is not set", target.DSNEnvVar)
+	}
+	db, err := sqlx.Open(target.Driver, dsn)
+	if err != nil {
+		return errors.EnsureStack(err)
+	}
+	defer func() {
+		if err := db.Close(); retErr == nil {
+			retErr = errors.EnsureStack(err)
+		}
+	}()
+	return pachClient.WalkFile(commit.Repo.Name, commit.ID, "/", func(fi *pfs.FileInfo) error {
+		if fi.FileType != pfs.FileType_FILE {
+			return nil
+		}
+		buf := &bytes.Buffer{}
+		if err := pachClient.GetFile(commit.Repo.Name, commit.ID, fi.File.Path, buf); err != nil {
+			return err
+		}
+		if err := sql.LoadRows(pachClient.Ctx(), db, target.Table, target.PrimaryKey, target.Format, buf); err != nil {
+			return errors.Wrapf(err, "error loading %s into %s", fi.File.Path, target.Table)
+		}
+		return nil
+	})
+}
+
+// egressFileSystem copies the files in 'commit' into the target directory.
+func egressFileSystem(pachClient *client.APIClient, commit *pfs.Commit, target *pps.FileSystemEgress) error {
+	if err := os.MkdirAll(target.Path, 0755); err != nil {
+		return errors.EnsureStack(err)
+	}
+	r, err := pachClient.GetFileTar(commit.Repo.Name, commit.ID, "/")
+	if err != nil {
+		return err
+	}
+	return tarutil.Import(target.Path, r)
+}
@@ -0,0 +1,103 @@
+package transform
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/server/worker/stats"
+)
+
+// maxStatsdPacket is the largest statsd packet that the worker receives
+const maxStatsdPacket = 64 * 1024
+
+// ListenMetrics starts recording the statsd lines that user code emits in
+// 'metrics'. User code can send the lines to a local UDP port, whose address
+// is passed to it in $PACH_STATSD_ADDR, or write them to 'metricsFile', which
+// is passed to it in $PACH_METRICS_FILE and read after each datum. Lines are
+// labelled with the job that was being processed when they were received.
+func (s *Status) ListenMetrics(metrics *stats.UserMetrics, metricsFile string) error {
+	if err := os.MkdirAll(filepath.Dir(metricsFile), 0777); err != nil {
+		return err
+	}
+	conn, err := net.ListenPacket("udp", fmt.Sprintf("127.0.0.1:%d", stats.StatsdPort))
+	if err != nil {
+		// The default statsd port is taken, so use any free port instead.
+		conn, err = net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			return err
+		}
+	}
+	s.withLock(func() {
+		s.metrics = metrics
+		s.statsdAddr = conn.LocalAddr().String()
+		s.metricsFile = metricsFile
+	})
+	go func() {
+		buf := make([]byte, maxStatsdPacket)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				log.Errorf("statsd listener exited: %v", err)
+				return
+			}
+			s.recordMetrics(string(buf[:n]))
+		}
+	}()
+	return nil
+}
+
+// metricsEnv returns the environment variables that tell user code where to
+// emit its metrics.
+func (s *Status) metricsEnv() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.metrics == nil {
+		return nil
+	}
+	return []string{
+		fmt.Sprintf("%s=%s", client.StatsdAddrEnv, s.statsdAddr),
+		fmt.Sprintf("%s=%s", client.MetricsFileEnv, s.metricsFile),
+	}
+}
+
+// recordMetrics records statsd lines emitted by user code, labelled with the
+// current job.
+func (s *Status) recordMetrics(data string) {
+	s.mutex.Lock()
+	metrics, jobID := s.metrics, s.jobID
+	s.mutex.Unlock()
+	if metrics == nil {
+		return
+	}
+	if err := metrics.Record(data, jobID); err != nil {
+		log.Errorf("error recording user metrics: %v", err)
+	}
+}
+
+// flushMetricsFile records the statsd lines that user code wrote to the
+// metrics file, and removes the file.
+func (s *Status) flushMetricsFile() {
+	s.mutex.Lock()
+	metricsFile := s.metricsFile
+	s.mutex.Unlock()
+	if metricsFile == "" {
+		return
+	}
+	data, err := ioutil.ReadFile(metricsFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Errorf("error reading user metrics: %v", err)
+		}
+		return
+	}
+	if err := os.Remove(metricsFile); err != nil {
+		log.Errorf("error removing user metrics file: %v", err)
+	}
+	s.recordMetrics(string(data))
+}
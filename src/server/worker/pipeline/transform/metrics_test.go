@@ -0,0 +1,56 @@
+package transform
+
+import (
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/server/worker/stats"
+)
+
+func TestUserMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	status := &Status{}
+	metricsFile := filepath.Join(t.TempDir(), "scratch", "metrics")
+	require.NoError(t, status.ListenMetrics(stats.NewUserMetrics("pipeline", registry), metricsFile))
+	env := make(map[string]string)
+	for _, kv := range status.metricsEnv() {
+		parts := strings.SplitN(kv, "=", 2)
+		env[parts[0]] = parts[1]
+	}
+	require.Equal(t, metricsFile, env[client.MetricsFileEnv])
+
+	require.NoError(t, status.withJob("job", func() {}, func() error {
+		return status.withDatum(nil, func() {}, func() error {
+			conn, err := net.Dial("udp", env[client.StatsdAddrEnv])
+			require.NoError(t, err)
+			defer conn.Close()
+			_, err = conn.Write([]byte("rows:2|c"))
+			require.NoError(t, err)
+			require.NoErrorWithinTRetry(t, 5*time.Second, func() error {
+				return testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP pachyderm_user_rows Counter emitted by user code
+# TYPE pachyderm_user_rows counter
+pachyderm_user_rows{job="job",pipeline="pipeline"} 2
+`), "pachyderm_user_rows")
+			})
+			return ioutil.WriteFile(metricsFile, []byte("accuracy:0.5|g\n"), 0600)
+		})
+	}))
+	// the metrics file is read, and removed, once the datum finishes
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP pachyderm_user_accuracy Gauge emitted by user code
+# TYPE pachyderm_user_accuracy gauge
+pachyderm_user_accuracy{job="job",pipeline="pipeline"} 0.5
+`), "pachyderm_user_accuracy"))
+	_, err := ioutil.ReadFile(metricsFile)
+	require.YesError(t, err)
+}
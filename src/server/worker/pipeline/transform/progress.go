@@ -0,0 +1,100 @@
+package transform
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/pachyderm/pachyderm/v2/src/client"
+)
+
+// ListenProgress starts a local HTTP server through which user code reports
+// its progress (see progressHandler). The server's URL is passed to user code
+// in $PACH_PROGRESS_URL.
+func (s *Status) ListenProgress() error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	s.withLock(func() {
+		s.progressURL = "http://" + listener.Addr().String()
+	})
+	go func() {
+		if err := http.Serve(listener, s.progressHandler()); err != nil {
+			log.Errorf("progress server exited: %v", err)
+		}
+	}()
+	return nil
+}
+
+// progressEnv returns the environment variables that tell user code where to
+// report its progress.
+func (s *Status) progressEnv() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.progressURL == "" {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s=%s", client.ProgressURLEnv, s.progressURL)}
+}
+
+// progressHandler serves the endpoints that user code reports its progress to:
+//
+//	POST /progress?value=<fraction>     sets the progress of the current datum
+//	POST /counters/<name>?add=<delta>   adds to a custom counter
+//	POST /counters/<name>?value=<value> sets a custom counter
+//
+// Progress is reset for each datum, and counters are reset for each job.
+func (s *Status) progressHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/progress", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		value, err := strconv.ParseFloat(r.FormValue("value"), 64)
+		if err != nil || value < 0 || value > 1 {
+			http.Error(w, "value must be a number between 0 and 1", http.StatusBadRequest)
+			return
+		}
+		s.withLock(func() {
+			s.progress = value
+		})
+	})
+	mux.HandleFunc("/counters/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		name := strings.TrimPrefix(r.URL.Path, "/counters/")
+		if name == "" {
+			http.Error(w, "missing counter name", http.StatusBadRequest)
+			return
+		}
+		add, set := r.FormValue("add"), r.FormValue("value")
+		if (add == "") == (set == "") {
+			http.Error(w, "exactly one of add and value must be set", http.StatusBadRequest)
+			return
+		}
+		n, err := strconv.ParseInt(add+set, 10, 64)
+		if err != nil {
+			http.Error(w, "counter values must be integers", http.StatusBadRequest)
+			return
+		}
+		s.withLock(func() {
+			if s.counters == nil {
+				s.counters = make(map[string]int64)
+			}
+			if add != "" {
+				s.counters[name] += n
+			} else {
+				s.counters[name] = n
+			}
+		})
+	})
+	return mux
+}
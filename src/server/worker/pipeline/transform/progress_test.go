@@ -0,0 +1,44 @@
+package transform
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+)
+
+func TestProgressHandler(t *testing.T) {
+	status := &Status{}
+	handler := status.progressHandler()
+	post := func(url string) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, url, nil))
+		return w.Code
+	}
+
+	require.NoError(t, status.withJob("job", func() {}, func() error {
+		return status.withDatum(nil, func() {}, func() error {
+			require.Equal(t, http.StatusOK, post("/progress?value=0.25"))
+			require.Equal(t, http.StatusOK, post("/counters/rows?add=3"))
+			require.Equal(t, http.StatusOK, post("/counters/rows?add=2"))
+			require.Equal(t, http.StatusOK, post("/counters/files?value=7"))
+			require.Equal(t, http.StatusBadRequest, post("/progress?value=2"))
+			require.Equal(t, http.StatusBadRequest, post("/counters/rows?add=1&value=1"))
+			require.Equal(t, http.StatusBadRequest, post("/counters/?add=1"))
+
+			workerStatus, err := status.GetStatus()
+			require.NoError(t, err)
+			require.Equal(t, "job", workerStatus.JobID)
+			require.Equal(t, 0.25, workerStatus.Progress)
+			require.Equal(t, map[string]int64{"rows": 5, "files": 7}, workerStatus.Counters)
+			return nil
+		})
+	}))
+
+	// progress and counters are reset once the datum and job finish
+	workerStatus, err := status.GetStatus()
+	require.NoError(t, err)
+	require.Equal(t, 0.0, workerStatus.Progress)
+	require.Equal(t, 0, len(workerStatus.Counters))
+}
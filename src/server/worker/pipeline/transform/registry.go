@@ -49,6 +49,54 @@ type pendingJob struct {
 	jdit                       *chain.JobDatumIterator
 	taskMaster                 *work.Master
 	cancel                     context.CancelFunc
+	// timedOut is closed when the job exceeds its timeout and its timeout
+	// policy is TIMEOUT_PARTIAL.
+	timedOut chan struct{}
+	// stateCommit is the meta commit of the last successful job, which holds
+	// the state that this job's datums start from (see resetState).
+	stateCommit *pfs.Commit
+}
+
+// jobTimedOut returns true if the job exceeded its timeout under the
+// TIMEOUT_PARTIAL policy.
+func (pj *pendingJob) jobTimedOut() bool {
+	select {
+	case <-pj.timedOut:
+		return true
+	default:
+		return false
+	}
+}
+
+// withTimeout returns a context that is also canceled when the job times out
+// under the TIMEOUT_PARTIAL policy.
+func (pj *pendingJob) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		select {
+		case <-pj.timedOut:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// jobDeadline returns the time at which the job times out, or the zero time if
+// the job has no timeout.
+func jobDeadline(ji *pps.JobInfo) (time.Time, error) {
+	if ji.JobTimeout == nil {
+		return time.Time{}, nil
+	}
+	startTime, err := types.TimestampFromProto(ji.Started)
+	if err != nil {
+		return time.Time{}, err
+	}
+	timeout, err := types.DurationFromProto(ji.JobTimeout)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return startTime.Add(timeout), nil
 }
 
 func (pj *pendingJob) writeJobInfo() error {
@@ -97,6 +145,53 @@ func (pj *pendingJob) withDeleter(pachClient *client.APIClient, cb func() error)
 	})
 }
 
+// resetState finds the meta commit of the last successful job, which holds the
+// pipeline state that this job's datums start from. The state of failed jobs
+// is discarded: if the job's parent failed, the state that its meta commit
+// inherited is replaced with the state of the last successful job.
+func (pj *pendingJob) resetState() error {
+	if !ppsutil.IsStateful(pj.driver.PipelineInfo()) {
+		return nil
+	}
+	pachClient := pj.driver.PachClient()
+	parent := pj.metaCommitInfo.ParentCommit
+	pj.stateCommit = nil
+	for commit := parent; commit != nil; {
+		// The parent job must finish before it's known whether its state is kept
+		ci, err := pachClient.PfsAPIClient.InspectCommit(pachClient.Ctx(),
+			&pfs.InspectCommitRequest{
+				Commit:     commit,
+				BlockState: pfs.CommitState_FINISHED,
+			})
+		if err != nil {
+			return err
+		}
+		if !strings.Contains(ci.Description, pfs.EmptyStr) {
+			pj.stateCommit = ci.Commit
+			break
+		}
+		commit = ci.ParentCommit
+	}
+	if parent == nil || (pj.stateCommit != nil && pj.stateCommit.ID == parent.ID) {
+		return nil // the inherited state (if any) is already correct
+	}
+	metaCommit := pj.metaCommitInfo.Commit
+	statePath := "/" + datum.StatePrefix + "/"
+	if err := pachClient.WithModifyFileClient(metaCommit.Repo.Name, metaCommit.ID, func(mfc *client.ModifyFileClient) error {
+		return mfc.DeleteFile(statePath)
+	}); err != nil {
+		return err
+	}
+	if pj.stateCommit == nil {
+		return nil
+	}
+	if err := pachClient.CopyFile(pj.stateCommit.Repo.Name, pj.stateCommit.ID, statePath,
+		metaCommit.Repo.Name, metaCommit.ID, statePath, false); err != nil && !pfsserver.IsFileNotFoundErr(err) {
+		return err
+	}
+	return nil
+}
+
 type registry struct {
 	driver      driver.Driver
 	logger      logs.TaggedLogger
@@ -128,11 +223,11 @@ func newRegistry(driver driver.Driver, logger logs.TaggedLogger) (*registry, err
 	}, nil
 }
 
-func (reg *registry) succeedJob(pj *pendingJob) error {
+func (reg *registry) succeedJob(pj *pendingJob, reason string) error {
 	pj.logger.Logf("job successful, closing commits")
 	defer pj.jdit.Finish()
 	// Use the registry's driver so that the job's supervision goroutine cannot cancel us
-	return ppsutil.FinishJob(reg.driver.PachClient(), pj.ji, pps.JobState_JOB_SUCCESS, "")
+	return ppsutil.FinishJob(reg.driver.PachClient(), pj.ji, pps.JobState_JOB_SUCCESS, reason)
 }
 
 func (reg *registry) failJob(pj *pendingJob, reason string) error {
@@ -149,6 +244,18 @@ func (reg *registry) killJob(pj *pendingJob, reason string) error {
 	return ppsutil.FinishJob(reg.driver.PachClient(), pj.ji, pps.JobState_JOB_KILLED, reason)
 }
 
+func (reg *registry) skipJob(pj *pendingJob, reason string) error {
+	pj.logger.Logf("skipping job with reason: %s", reason)
+	// The skipped job's meta commit must still carry the last successful state
+	if err := pj.resetState(); err != nil {
+		return err
+	}
+	// The job was never added to the job chain, so there is nothing to finish
+	// there. The output commit is finished without changes, so it retains the
+	// output of its parent.
+	return ppsutil.FinishJob(reg.driver.PachClient(), pj.ji, pps.JobState_JOB_SKIPPED, reason)
+}
+
 func (reg *registry) initializeJobChain(metaCommitInfo *pfs.CommitInfo) error {
 	if reg.jobChain == nil {
 		pi := reg.driver.PipelineInfo()
@@ -237,6 +344,7 @@ func (reg *registry) startJob(commitInfo *pfs.CommitInfo) error {
 		commitInfo:     commitInfo,
 		metaCommitInfo: metaCommitInfo,
 		cancel:         cancel,
+		timedOut:       make(chan struct{}),
 	}
 	// Inputs must be ready before we can construct a datum iterator, so do this
 	// synchronously to ensure correct order in the jobChain.
@@ -245,6 +353,9 @@ func (reg *registry) startJob(commitInfo *pfs.CommitInfo) error {
 	}); err != nil {
 		return err
 	}
+	if pj.ji.State == pps.JobState_JOB_SKIPPED {
+		return nil
+	}
 	// TODO: This could probably be scoped to a callback, and we could move job specific features
 	// in the chain package (timeouts for example).
 	// TODO: I use the registry pachclient for the iterators, so I can reuse across jobs for skipping.
@@ -255,25 +366,26 @@ func (reg *registry) startJob(commitInfo *pfs.CommitInfo) error {
 	}
 	outputDit := datum.NewCommitIterator(pachClient, pj.metaCommitInfo.Commit.Repo.Name, pj.metaCommitInfo.Commit.ID)
 	pj.jdit = reg.jobChain.CreateJob(pj.driver.PachClient().Ctx(), pj.ji.Job.ID, dit, outputDit)
-	var afterTime time.Duration
-	if pj.ji.JobTimeout != nil {
-		startTime, err := types.TimestampFromProto(pj.ji.Started)
-		if err != nil {
-			return err
-		}
-		timeout, err := types.DurationFromProto(pj.ji.JobTimeout)
-		if err != nil {
-			return err
-		}
-		afterTime = time.Until(startTime.Add(timeout))
+	deadline, err := jobDeadline(pj.ji)
+	if err != nil {
+		return err
 	}
+	afterTime := time.Until(deadline)
 	asyncEg, jobCtx = errgroup.WithContext(pj.driver.PachClient().Ctx())
 	pj.driver = reg.driver.WithContext(jobCtx)
 	asyncEg.Go(func() error {
 		defer pj.cancel()
 		if pj.ji.JobTimeout != nil {
 			pj.logger.Logf("cancelling job at: %+v", afterTime)
+			policy := pj.ji.TimeoutPolicy
 			timer := time.AfterFunc(afterTime, func() {
+				if policy == pps.TimeoutPolicy_TIMEOUT_PARTIAL {
+					// processJobRunning stops processing datums and finishes
+					// the job with the output it has, and processJobEgressing
+					// stops egressing it
+					close(pj.timedOut)
+					return
+				}
 				reg.killJob(pj, "job timed out")
 			})
 			defer timer.Stop()
@@ -411,15 +523,42 @@ func (reg *registry) processJob(pj *pendingJob) error {
 }
 
 func (reg *registry) processJobStarting(pj *pendingJob) error {
+	// The job's timeout also covers the wait for its inputs, which happens
+	// before the job's timer is started
+	pachClient := pj.driver.PachClient()
+	deadline, err := jobDeadline(pj.ji)
+	if err != nil {
+		return err
+	}
+	if !deadline.IsZero() {
+		ctx, cancel := context.WithDeadline(pachClient.Ctx(), deadline)
+		defer cancel()
+		pachClient = pachClient.WithCtx(ctx)
+	}
 	// block until job inputs are ready
-	failed, err := failedInputs(pj.driver.PachClient(), pj.ji)
+	failed, err := failedInputs(pachClient, pj.ji)
 	if err != nil {
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			// The job has no output yet, so it's killed under either timeout
+			// policy. It was never added to the job chain, so there is
+			// nothing to finish there.
+			pj.logger.Logf("killing job with reason: job timed out waiting for its inputs")
+			return ppsutil.FinishJob(reg.driver.PachClient(), pj.ji, pps.JobState_JOB_KILLED, "job timed out waiting for its inputs")
+		}
 		return err
 	}
 	if len(failed) > 0 {
 		reason := fmt.Sprintf("inputs failed: %s", strings.Join(failed, ", "))
 		return reg.failJob(pj, reason)
 	}
+	// skip the job if none of its path-filtered inputs changed a matching path
+	reason, err := ppsutil.PathFilterSkipReason(pj.driver.PachClient(), pj.ji.Input)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		return reg.skipJob(pj, reason)
+	}
 	pj.ji.State = pps.JobState_JOB_RUNNING
 	return pj.writeJobInfo()
 }
@@ -428,15 +567,27 @@ func (reg *registry) processJobStarting(pj *pendingJob) error {
 // Need to put some more thought into the context use.
 func (reg *registry) processJobRunning(pj *pendingJob) error {
 	pachClient := pj.driver.PachClient()
-	eg, ctx := errgroup.WithContext(pachClient.Ctx())
+	// Datums are processed in a context that is also canceled when the job
+	// times out under the TIMEOUT_PARTIAL policy, so that the job can be
+	// finished with the output of the datum sets that completed.
+	processCtx, cancel := pj.withTimeout(pachClient.Ctx())
+	defer cancel()
+	eg, ctx := errgroup.WithContext(processCtx)
 	pachClient = pachClient.WithCtx(ctx)
 	stats := &datum.Stats{ProcessStats: &pps.ProcessStats{}}
 
+	if err := pj.logger.LogStep("resetting pipeline state", pj.resetState); err != nil {
+		return err
+	}
+
 	// TODO: We need to delete the output for S3Out since we don't have a clear way to track the output in the stats commit (which means datums cannot be skipped with S3Out).
 	// If we had a way to map the output added through the S3 gateway back to the datums, and stored this in the appropriate place in the stats commit, then we would be able
 	// handle datums the same way we handle normal pipelines.
 	if pj.driver.PipelineInfo().S3Out {
 		if err := pachClient.DeleteFile(pj.commitInfo.Commit.Repo.Name, pj.commitInfo.Commit.ID, "/"); err != nil {
+			if pj.jobTimedOut() {
+				return reg.killJob(pj, "job timed out before any datums were processed")
+			}
 			return err
 		}
 	}
@@ -445,9 +596,16 @@ func (reg *registry) processJobRunning(pj *pendingJob) error {
 	// This may be resolved by either explicitly generating deletes first (somewhat similar to this hack) or
 	// relying on temporary fileset identifiers being associated with the commit after the datumsets have been
 	// generated (and therefore after the deletes).
+	var numDatums int64
 	if err := pj.withDeleter(pachClient, func() error {
-		return pj.jdit.Iterate(func(_ *datum.Meta) error { return nil })
+		return pj.jdit.Iterate(func(_ *datum.Meta) error {
+			numDatums++
+			return nil
+		})
 	}); err != nil {
+		if pj.jobTimedOut() {
+			return reg.killJob(pj, "job timed out before any datums were processed")
+		}
 		return err
 	}
 
@@ -498,7 +656,7 @@ func (reg *registry) processJobRunning(pj *pendingJob) error {
 			})
 		})
 		return eg.Wait()
-	}); err != nil {
+	}); err != nil && !pj.jobTimedOut() {
 		return err
 	}
 	// TODO: This shouldn't be necessary.
@@ -509,14 +667,28 @@ func (reg *registry) processJobRunning(pj *pendingJob) error {
 	}
 	pj.saveJobStats(pj.jdit.Stats())
 	pj.saveJobStats(stats)
+	var reasons []string
+	if pj.jobTimedOut() {
+		// Datums in datum sets that didn't complete before the timeout are
+		// counted as failed, and are processed again by the next job.
+		unprocessed := numDatums - (stats.Processed + stats.Failed + stats.Recovered)
+		pj.ji.DataFailed += unprocessed
+		pj.ji.DataTotal += unprocessed
+		reasons = append(reasons, fmt.Sprintf("job timed out with %d datums unprocessed", unprocessed))
+	}
 	if stats.FailedID != "" {
-		return reg.failJob(pj, fmt.Sprintf("datum %v failed", stats.FailedID))
+		if pj.ji.TimeoutPolicy != pps.TimeoutPolicy_TIMEOUT_PARTIAL || stats.Failed > stats.TimedOut {
+			return reg.failJob(pj, fmt.Sprintf("datum %v failed", stats.FailedID))
+		}
+		reasons = append(reasons, fmt.Sprintf("%d datums timed out", stats.TimedOut))
 	}
+	reason := strings.Join(reasons, ", ")
 	if pj.ji.Egress != nil {
 		pj.ji.State = pps.JobState_JOB_EGRESSING
+		pj.ji.Reason = reason
 		return pj.writeJobInfo()
 	}
-	return reg.succeedJob(pj)
+	return reg.succeedJob(pj, reason)
 }
 
 func createDatumSetSubtask(pachClient *client.APIClient, pj *pendingJob, upload func(datum.Client) error, renewer *renew.StringSet) (*work.Task, error) {
@@ -534,6 +706,7 @@ func createDatumSetSubtask(pachClient *client.APIClient, pj *pendingJob, upload
 		FileSet:      resp.FilesetId,
 		OutputCommit: pj.commitInfo.Commit,
 		MetaCommit:   pj.metaCommitInfo.Commit,
+		StateCommit:  pj.stateCommit,
 	})
 	if err != nil {
 		return nil, err
@@ -562,13 +735,56 @@ func deserializeDatumSet(any *types.Any) (*DatumSet, error) {
 }
 
 func (reg *registry) processJobEgressing(pj *pendingJob) error {
-	repo := pj.commitInfo.Commit.Repo.Name
-	commit := pj.commitInfo.Commit.ID
-	url := pj.ji.Egress.URL
-	if err := pj.driver.PachClient().GetFileURL(repo, commit, "/", url); err != nil {
+	if pj.ji.EgressStatus == nil {
+		pj.ji.EgressStatus = &pps.EgressStatus{}
+	}
+	maxAttempts := pj.ji.Egress.MaxAttempts
+	// Egress is stopped if the job times out under the TIMEOUT_PARTIAL policy,
+	// and the job's output is kept so that the egress can be retried.
+	ctx, cancel := pj.withTimeout(pj.driver.PachClient().Ctx())
+	defer cancel()
+	pachClient := pj.driver.PachClient().WithCtx(ctx)
+	// Egress failures are retried here, rather than by returning an error,
+	// which would cause the job's datums to be reprocessed.
+	var egressErr error
+	if err := backoff.RetryUntilCancel(ctx, func() error {
+		egressErr = Egress(pachClient, pj.commitInfo.Commit, pj.ji.Egress)
+		pj.ji.EgressStatus.Attempts++
+		pj.ji.EgressStatus.LastAttempt = types.TimestampNow()
+		if egressErr == nil {
+			pj.ji.EgressStatus.LastError = ""
+			return nil
+		}
+		pj.ji.EgressStatus.LastError = egressErr.Error()
+		if maxAttempts > 0 && pj.ji.EgressStatus.Attempts >= maxAttempts {
+			return nil
+		}
+		if err := pj.writeJobInfo(); err != nil {
+			return err
+		}
+		return egressErr
+	}, backoff.NewInfiniteBackOff(), func(err error, d time.Duration) error {
+		pj.logger.Logf("error egressing job output: %v, retrying in %v", err, d)
+		return nil
+	}); err != nil && !pj.jobTimedOut() {
 		return err
 	}
-	return reg.succeedJob(pj)
+	reason := pj.ji.Reason
+	if egressErr != nil {
+		egressReason := fmt.Sprintf("egress failed after %d attempts: %v", pj.ji.EgressStatus.Attempts, egressErr)
+		if pj.jobTimedOut() {
+			egressReason = fmt.Sprintf("job timed out during egress after %d attempts", pj.ji.EgressStatus.Attempts)
+		}
+		// The job's output was computed successfully, so its output commit is
+		// kept rather than emptied, and its egress can be retried with
+		// EgressJob.
+		pj.ji.EgressStatus.Failed = true
+		if reason != "" {
+			reason += ", "
+		}
+		reason += egressReason
+	}
+	return reg.succeedJob(pj, reason)
 }
 
 func failedInputs(pachClient *client.APIClient, jobInfo *pps.JobInfo) ([]string, error) {
@@ -598,9 +814,15 @@ func failedInputs(pachClient *client.APIClient, jobInfo *pps.JobInfo) ([]string,
 		if input.Cron != nil && input.Cron.Commit != "" {
 			blockCommit(input.Cron.Name, client.NewCommit(input.Cron.Repo, input.Cron.Commit))
 		}
+		if input.SQL != nil && input.SQL.Commit != "" {
+			blockCommit(input.SQL.Name, client.NewCommit(input.SQL.Repo, input.SQL.Commit))
+		}
 		if input.Git != nil && input.Git.Commit != "" {
 			blockCommit(input.Git.Name, client.NewCommit(input.Git.Name, input.Git.Commit))
 		}
+		if input.Window != nil && input.Window.Commit != "" {
+			blockCommit(input.Window.Name, client.NewCommit(input.Window.Repo, input.Window.Commit))
+		}
 	})
 	return failed, vistErr
 }
@@ -0,0 +1,49 @@
+package transform
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+	"github.com/pachyderm/pachyderm/v2/src/pps"
+)
+
+func TestJobDeadline(t *testing.T) {
+	deadline, err := jobDeadline(&pps.JobInfo{})
+	require.NoError(t, err)
+	require.True(t, deadline.IsZero())
+
+	started := time.Now()
+	startedProto, err := types.TimestampProto(started)
+	require.NoError(t, err)
+	deadline, err = jobDeadline(&pps.JobInfo{
+		Started:    startedProto,
+		JobTimeout: types.DurationProto(time.Minute),
+	})
+	require.NoError(t, err)
+	require.True(t, deadline.Equal(started.Add(time.Minute)))
+}
+
+func TestPendingJobWithTimeout(t *testing.T) {
+	pj := &pendingJob{timedOut: make(chan struct{})}
+	ctx, cancel := pj.withTimeout(context.Background())
+	defer cancel()
+	require.False(t, pj.jobTimedOut())
+	require.NoError(t, ctx.Err())
+
+	// Timing out cancels the job's contexts, and is visible to every state
+	// that checks for it
+	close(pj.timedOut)
+	select {
+	case <-ctx.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("context wasn't canceled when the job timed out")
+	}
+	require.True(t, pj.jobTimedOut())
+	ctx, cancel = pj.withTimeout(context.Background())
+	defer cancel()
+	<-ctx.Done()
+}
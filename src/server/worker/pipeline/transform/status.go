@@ -1,6 +1,7 @@
 package transform
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/pachyderm/pachyderm/v2/src/pps"
 	"github.com/pachyderm/pachyderm/v2/src/server/worker/common"
+	"github.com/pachyderm/pachyderm/v2/src/server/worker/stats"
 )
 
 // Status is a struct representing the current status of the transform worker,
@@ -24,6 +26,20 @@ type Status struct {
 	datum         []*pps.InputFile
 	cancel        func()
 	started       time.Time
+	// jobStop stops processing the current job (see common.WithJobStop), and
+	// jobDone is closed once it's no longer processed
+	jobStop func()
+	jobDone chan struct{}
+	// progress and counters are reported by the user code (see progress.go)
+	progress    float64
+	counters    map[string]int64
+	progressURL string
+	// debugHold is the failed datum being held for debugging, if any
+	debugHold *debugHold
+	// metrics records the metrics emitted by the user code (see metrics.go)
+	metrics     *stats.UserMetrics
+	statsdAddr  string
+	metricsFile string
 }
 
 func convertInputs(inputs []*common.Input) []*pps.InputFile {
@@ -43,13 +59,21 @@ func (s *Status) withLock(cb func()) {
 	s.mutex.Unlock()
 }
 
-func (s *Status) withJob(jobID string, cb func() error) error {
+func (s *Status) withJob(jobID string, stop func(), cb func() error) error {
+	jobDone := make(chan struct{})
 	s.withLock(func() {
 		s.jobID = jobID
+		s.counters = nil
+		s.jobStop = stop
+		s.jobDone = jobDone
 	})
 
 	defer s.withLock(func() {
 		s.jobID = ""
+		s.counters = nil
+		s.jobStop = nil
+		s.jobDone = nil
+		close(jobDone)
 	})
 
 	return cb()
@@ -79,13 +103,17 @@ func (s *Status) withDatum(inputs []*common.Input, cancel func(), cb func() erro
 		s.datum = convertInputs(inputs)
 		s.cancel = cancel
 		s.started = time.Now()
+		s.progress = 0
 	})
 
 	defer s.withLock(func() {
 		s.datum = nil
 		s.cancel = nil
 		s.started = time.Time{}
+		s.progress = 0
 	})
+	// The metrics file is read after each datum (see ListenMetrics).
+	defer s.flushMetricsFile()
 
 	return cb()
 }
@@ -100,10 +128,17 @@ func (s *Status) GetStatus() (*pps.WorkerStatus, error) {
 		return nil, err
 	}
 	result := &pps.WorkerStatus{
-		JobID:   s.jobID,
-		Data:    s.datum,
-		Started: started,
-		Stats:   s.stats,
+		JobID:    s.jobID,
+		Data:     s.datum,
+		Started:  started,
+		Stats:    s.stats,
+		Progress: s.progress,
+	}
+	if len(s.counters) > 0 {
+		result.Counters = make(map[string]int64, len(s.counters))
+		for name, value := range s.counters {
+			result.Counters[name] = value
+		}
 	}
 	if s.queueSize != nil {
 		result.QueueSize = atomic.LoadInt64(s.queueSize)
@@ -129,3 +164,21 @@ func (s *Status) Cancel(jobID string, datumFilter []string) bool {
 	}
 	return false
 }
+
+// StopJob stops processing the job 'jobID', if it's being processed, and waits
+// until its user code has shut down or 'ctx' is done.
+func (s *Status) StopJob(ctx context.Context, jobID string) bool {
+	s.mutex.Lock()
+	if jobID != s.jobID || s.jobStop == nil {
+		s.mutex.Unlock()
+		return false
+	}
+	s.jobStop()
+	jobDone := s.jobDone
+	s.mutex.Unlock()
+	select {
+	case <-jobDone:
+	case <-ctx.Done():
+	}
+	return true
+}
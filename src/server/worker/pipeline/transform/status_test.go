@@ -0,0 +1,32 @@
+package transform
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+)
+
+func TestStopJob(t *testing.T) {
+	status := &Status{}
+	require.False(t, status.StopJob(context.Background(), "job"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan bool)
+	require.NoError(t, status.withJob("job", cancel, func() error {
+		go func() {
+			require.False(t, status.StopJob(context.Background(), "other"))
+			stopped <- status.StopJob(context.Background(), "job")
+		}()
+		<-ctx.Done()
+		// StopJob waits for the job to stop being processed
+		select {
+		case <-stopped:
+			t.Fatal("StopJob returned while the job was being processed")
+		case <-time.After(100 * time.Millisecond):
+		}
+		return nil
+	}))
+	require.True(t, <-stopped)
+}
@@ -31,6 +31,9 @@ type DatumSet struct {
 	FileSet      string      `protobuf:"bytes,2,opt,name=file_set,json=fileSet,proto3" json:"file_set,omitempty"`
 	OutputCommit *pfs.Commit `protobuf:"bytes,3,opt,name=output_commit,json=outputCommit,proto3" json:"output_commit,omitempty"`
 	MetaCommit   *pfs.Commit `protobuf:"bytes,4,opt,name=meta_commit,json=metaCommit,proto3" json:"meta_commit,omitempty"`
+	// state_commit is the meta commit of the last successful job, which datums
+	// read the pipeline's state from (only set for stateful pipelines)
+	StateCommit *pfs.Commit `protobuf:"bytes,6,opt,name=state_commit,json=stateCommit,proto3" json:"state_commit,omitempty"`
 	// Outputs
 	Stats                *datum.Stats `protobuf:"bytes,5,opt,name=stats,proto3" json:"stats,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
@@ -99,6 +102,13 @@ func (m *DatumSet) GetMetaCommit() *pfs.Commit {
 	return nil
 }
 
+func (m *DatumSet) GetStateCommit() *pfs.Commit {
+	if m != nil {
+		return m.StateCommit
+	}
+	return nil
+}
+
 func (m *DatumSet) GetStats() *datum.Stats {
 	if m != nil {
 		return m.Stats
@@ -115,26 +125,27 @@ func init() {
 }
 
 var fileDescriptor_21583a759eb7fa97 = []byte{
-	// 303 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x91, 0xcf, 0x4e, 0x02, 0x31,
-	0x10, 0xc6, 0x53, 0x75, 0x11, 0x0a, 0x5c, 0x36, 0x1e, 0x56, 0x0e, 0x40, 0xf0, 0xc2, 0xc1, 0xb4,
-	0x04, 0xdf, 0x00, 0x89, 0x09, 0x1e, 0x17, 0x4f, 0x5e, 0xc8, 0xfe, 0xe9, 0xc2, 0x22, 0x65, 0x9a,
-	0x76, 0x16, 0xe3, 0x1b, 0x9a, 0x78, 0xf1, 0x09, 0x8c, 0xd9, 0x27, 0x31, 0xdd, 0x02, 0x6a, 0x3c,
-	0x78, 0x69, 0xbe, 0x6f, 0xe6, 0xf7, 0xa5, 0xed, 0x0c, 0x1d, 0x19, 0xa1, 0x77, 0x42, 0xf3, 0x67,
-	0xd0, 0x4f, 0x42, 0x73, 0x95, 0x2b, 0xb1, 0xc9, 0xb7, 0x82, 0xa3, 0x8e, 0xb6, 0x26, 0x03, 0x2d,
-	0xbf, 0x15, 0x53, 0x1a, 0x10, 0xfc, 0x2b, 0x15, 0x25, 0xab, 0x97, 0x54, 0x68, 0xc9, 0x5c, 0x88,
-	0x1d, 0x42, 0xec, 0x88, 0x76, 0x2e, 0x96, 0xb0, 0x84, 0x8a, 0xe7, 0x56, 0xb9, 0x68, 0xa7, 0xad,
-	0x32, 0xc3, 0x55, 0x66, 0xf6, 0xb6, 0xf7, 0xfb, 0xee, 0x34, 0xc2, 0x42, 0xba, 0xd3, 0x01, 0x83,
-	0x37, 0x42, 0xeb, 0x53, 0xeb, 0xe7, 0x02, 0xfd, 0x3e, 0xad, 0xad, 0x21, 0x5e, 0xe4, 0x69, 0x40,
-	0xfa, 0x64, 0xd8, 0x98, 0x34, 0xca, 0x8f, 0x9e, 0x77, 0x0f, 0xf1, 0x6c, 0x1a, 0x7a, 0x6b, 0x88,
-	0x67, 0xa9, 0x7f, 0x49, 0xeb, 0x59, 0xbe, 0x11, 0x0b, 0x23, 0x30, 0x38, 0xb1, 0x4c, 0x78, 0x6e,
-	0xbd, 0x0d, 0x8f, 0x68, 0x1b, 0x0a, 0x54, 0x05, 0x2e, 0x12, 0x90, 0x32, 0xc7, 0xe0, 0xb4, 0x4f,
-	0x86, 0xcd, 0x71, 0x93, 0xd9, 0xd7, 0xdc, 0x56, 0xa5, 0xb0, 0xe5, 0x08, 0xe7, 0xfc, 0x6b, 0xda,
-	0x94, 0x02, 0xa3, 0x03, 0x7f, 0xf6, 0x97, 0xa7, 0xb6, 0xbf, 0xa7, 0x07, 0xd4, 0x33, 0x18, 0xa1,
-	0x09, 0xbc, 0x8a, 0x6b, 0x31, 0xf7, 0x8d, 0xb9, 0xad, 0x85, 0xae, 0x35, 0x79, 0x78, 0x2d, 0xbb,
-	0xe4, 0xbd, 0xec, 0x92, 0xcf, 0xb2, 0x4b, 0x1e, 0xef, 0x96, 0x39, 0xae, 0x8a, 0x98, 0x25, 0x20,
-	0xf9, 0x71, 0xa2, 0x3f, 0xd4, 0x6e, 0xcc, 0x8d, 0x4e, 0xf8, 0x7f, 0xeb, 0x89, 0x6b, 0xd5, 0xa8,
-	0x6e, 0xbe, 0x02, 0x00, 0x00, 0xff, 0xff, 0x1d, 0xeb, 0xee, 0xd6, 0xc9, 0x01, 0x00, 0x00,
+	// 314 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x91, 0xcf, 0x4e, 0x32, 0x31,
+	0x14, 0xc5, 0xd3, 0xef, 0x73, 0x10, 0x3a, 0xb0, 0x99, 0xb8, 0x18, 0x59, 0x00, 0xc1, 0x0d, 0x0b,
+	0xd3, 0x12, 0x7c, 0x03, 0x24, 0x26, 0xb8, 0x1c, 0x5c, 0xb9, 0x21, 0xf3, 0xa7, 0x03, 0x45, 0x4a,
+	0x9b, 0xf6, 0x0e, 0xc6, 0x37, 0x74, 0xe9, 0x13, 0x18, 0x33, 0xaf, 0xe1, 0xc6, 0x74, 0xca, 0xa0,
+	0x86, 0x85, 0x9b, 0xe6, 0x9c, 0x7b, 0x7f, 0x27, 0x6d, 0xef, 0xc5, 0x63, 0xc3, 0xf4, 0x9e, 0x69,
+	0xfa, 0x2c, 0xf5, 0x13, 0xd3, 0x54, 0x71, 0xc5, 0xb6, 0x7c, 0xc7, 0x28, 0xe8, 0x78, 0x67, 0x72,
+	0xa9, 0xc5, 0xb7, 0x22, 0x4a, 0x4b, 0x90, 0xc1, 0x95, 0x8a, 0xd3, 0xf5, 0x4b, 0xc6, 0xb4, 0x20,
+	0x2e, 0x44, 0xea, 0x10, 0x39, 0xa2, 0xdd, 0x8b, 0x95, 0x5c, 0xc9, 0x8a, 0xa7, 0x56, 0xb9, 0x68,
+	0xb7, 0xa3, 0x72, 0x43, 0x55, 0x6e, 0x0e, 0xb6, 0xff, 0xfb, 0xee, 0x2c, 0x86, 0x42, 0xb8, 0xd3,
+	0x01, 0xc3, 0x4f, 0x84, 0x9b, 0x33, 0xeb, 0x17, 0x0c, 0x82, 0x01, 0x6e, 0x6c, 0x64, 0xb2, 0xe4,
+	0x59, 0x88, 0x06, 0x68, 0xd4, 0x9a, 0xb6, 0xca, 0xf7, 0xbe, 0x77, 0x2f, 0x93, 0xf9, 0x2c, 0xf2,
+	0x36, 0x32, 0x99, 0x67, 0xc1, 0x25, 0x6e, 0xe6, 0x7c, 0xcb, 0x96, 0x86, 0x41, 0xf8, 0xcf, 0x32,
+	0xd1, 0xb9, 0xf5, 0x36, 0x3c, 0xc6, 0x1d, 0x59, 0x80, 0x2a, 0x60, 0x99, 0x4a, 0x21, 0x38, 0x84,
+	0xff, 0x07, 0x68, 0xe4, 0x4f, 0x7c, 0x62, 0x5f, 0x73, 0x5b, 0x95, 0xa2, 0xb6, 0x23, 0x9c, 0x0b,
+	0xae, 0xb1, 0x2f, 0x18, 0xc4, 0x35, 0x7f, 0x76, 0xca, 0x63, 0xdb, 0x3f, 0xd0, 0x04, 0xb7, 0x0d,
+	0xc4, 0xc0, 0x6a, 0xbc, 0x71, 0x8a, 0xfb, 0x15, 0x70, 0xe0, 0x87, 0xd8, 0xb3, 0xd6, 0x84, 0x5e,
+	0x05, 0xb6, 0x89, 0xfb, 0xf6, 0xc2, 0xd6, 0x22, 0xd7, 0x9a, 0x3e, 0xbc, 0x96, 0x3d, 0xf4, 0x56,
+	0xf6, 0xd0, 0x47, 0xd9, 0x43, 0x8f, 0x77, 0x2b, 0x0e, 0xeb, 0x22, 0x21, 0xa9, 0x14, 0xf4, 0xb8,
+	0x81, 0x1f, 0x6a, 0x3f, 0xa1, 0x46, 0xa7, 0xf4, 0xaf, 0x75, 0x26, 0x8d, 0x6a, 0xb4, 0x37, 0x5f,
+	0x03, 0x00, 0x76, 0x43, 0xb3, 0x86, 0xf9, 0x01, 0x00, 0x00,
 }
 
 func (m *DatumSet) Marshal() (dAtA []byte, err error) {
@@ -161,6 +172,18 @@ func (m *DatumSet) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.StateCommit != nil {
+		{
+			size, err := m.StateCommit.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintTransform(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x32
+	}
 	if m.Stats != nil {
 		{
 			size, err := m.Stats.MarshalToSizedBuffer(dAtA[:i])
@@ -251,6 +274,10 @@ func (m *DatumSet) Size() (n int) {
 		l = m.Stats.Size()
 		n += 1 + l + sovTransform(uint64(l))
 	}
+	if m.StateCommit != nil {
+		l = m.StateCommit.Size()
+		n += 1 + l + sovTransform(uint64(l))
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -464,6 +491,42 @@ func (m *DatumSet) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StateCommit", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowTransform
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthTransform
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthTransform
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.StateCommit == nil {
+				m.StateCommit = &pfs.Commit{}
+			}
+			if err := m.StateCommit.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipTransform(dAtA[iNdEx:])
@@ -6,7 +6,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -186,6 +188,10 @@ func mockBasicJob(t *testing.T, env *testEnv, pi *pps.PipelineInfo) (context.Con
 		etcdJobInfo.DataRecovered = request.DataRecovered
 		etcdJobInfo.StatsCommit = request.StatsCommit
 		etcdJobInfo.Started = request.Started
+		if etcdJobInfo.Started == nil {
+			// pachd sets the start time when the job is created
+			etcdJobInfo.Started = types.TimestampNow()
+		}
 		etcdJobInfo.Finished = request.Finished
 		return etcdJobInfo.Job, nil
 	})
@@ -226,6 +232,7 @@ func mockBasicJob(t *testing.T, env *testEnv, pi *pps.PipelineInfo) (context.Con
 			ChunkSpec:        pi.ChunkSpec,
 			DatumTimeout:     pi.DatumTimeout,
 			JobTimeout:       pi.JobTimeout,
+			TimeoutPolicy:    pi.TimeoutPolicy,
 			DatumTries:       pi.DatumTries,
 			SchedulingSpec:   pi.SchedulingSpec,
 			PodSpec:          pi.PodSpec,
@@ -308,6 +315,28 @@ func TestJobSuccessEgress(t *testing.T) {
 	}
 }
 
+func TestJobSuccessEgressFileSystem(t *testing.T) {
+	dir := t.TempDir()
+	pi := defaultPipelineInfo()
+	pi.Egress = &pps.Egress{
+		Target: &pps.Egress_FileSystem{FileSystem: &pps.FileSystemEgress{Path: dir}},
+	}
+	files := []tarutil.File{
+		tarutil.NewMemFile("/file1", []byte("foo")),
+		tarutil.NewMemFile("/file2", []byte("bar")),
+	}
+	testJobSuccess(t, pi, files)
+	for _, file := range files {
+		hdr, err := file.Header()
+		require.NoError(t, err)
+		buf := &bytes.Buffer{}
+		require.NoError(t, file.Content(buf))
+		data, err := ioutil.ReadFile(filepath.Join(dir, hdr.Name))
+		require.NoError(t, err)
+		require.True(t, bytes.Equal(buf.Bytes(), data))
+	}
+}
+
 func testJobSuccess(t *testing.T, pi *pps.PipelineInfo, files []tarutil.File) {
 	db := dbutil.NewTestDB(t)
 	require.NoError(t, withWorkerSpawnerPair(db, pi, func(env *testEnv) error {
@@ -358,6 +387,32 @@ func TestJobFailedDatum(t *testing.T) {
 	}))
 }
 
+func TestJobTimeoutPartialEgress(t *testing.T) {
+	// The egress target is inside a regular file, so egress keeps failing
+	// until the job times out
+	target := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, ioutil.WriteFile(target, []byte("foo"), 0666))
+	pi := defaultPipelineInfo()
+	pi.JobTimeout = types.DurationProto(5 * time.Second)
+	pi.TimeoutPolicy = pps.TimeoutPolicy_TIMEOUT_PARTIAL
+	pi.Egress = &pps.Egress{
+		Target: &pps.Egress_FileSystem{FileSystem: &pps.FileSystemEgress{Path: filepath.Join(target, "egress")}},
+	}
+	db := dbutil.NewTestDB(t)
+	require.NoError(t, withWorkerSpawnerPair(db, pi, func(env *testEnv) error {
+		ctx, etcdJobInfo := mockBasicJob(t, env, pi)
+		triggerJob(t, env, pi, []tarutil.File{
+			tarutil.NewMemFile("/file", []byte("foobar")),
+		})
+		ctx = withTimeout(ctx, 20*time.Second)
+		<-ctx.Done()
+		// The output is kept so that its egress can be retried
+		require.Equal(t, pps.JobState_JOB_SUCCESS, etcdJobInfo.State)
+		require.Matches(t, "job timed out during egress", etcdJobInfo.Reason)
+		return nil
+	}))
+}
+
 func TestJobMultiDatum(t *testing.T) {
 	pi := defaultPipelineInfo()
 	db := dbutil.NewTestDB(t)
@@ -16,6 +16,7 @@ import (
 	"github.com/pachyderm/pachyderm/v2/src/internal/work"
 	"github.com/pachyderm/pachyderm/v2/src/pfs"
 	"github.com/pachyderm/pachyderm/v2/src/pps"
+	"github.com/pachyderm/pachyderm/v2/src/server/worker/common"
 	"github.com/pachyderm/pachyderm/v2/src/server/worker/datum"
 	"github.com/pachyderm/pachyderm/v2/src/server/worker/driver"
 	"github.com/pachyderm/pachyderm/v2/src/server/worker/logs"
@@ -33,7 +34,12 @@ func Worker(driver driver.Driver, logger logs.TaggedLogger, subtask *work.Task,
 	if err != nil {
 		return err
 	}
-	return status.withJob(datumSet.JobID, func() error {
+	// The job's processing is cancelled if it's stopped (see Status.StopJob)
+	ctx, cancel := context.WithCancel(driver.PachClient().Ctx())
+	defer cancel()
+	ctx, stop := common.WithJobStop(ctx)
+	driver = driver.WithContext(ctx)
+	return status.withJob(datumSet.JobID, stop, func() error {
 		logger = logger.WithJob(datumSet.JobID)
 		if err := logger.LogStep("datum task", func() error {
 			if ppsutil.ContainsS3Inputs(driver.PipelineInfo().Input) || driver.PipelineInfo().S3Out {
@@ -87,15 +93,52 @@ func handleDatumSet(driver driver.Driver, logger logs.TaggedLogger, datumSet *Da
 				datum.WithPFSOutput(newDatumClient(mfcPFS, pachClient, outputCommit)),
 				datum.WithStats(datumSet.Stats),
 			}
+			if ppsutil.IsStateful(driver.PipelineInfo()) {
+				opts = append(opts, datum.WithState(mfcMeta, datumSet.StateCommit))
+			}
+			if spec := driver.PipelineInfo().Prefetch; spec != nil && spec.Datums > 0 {
+				disk, memory, err := ppsutil.PrefetchLimits(spec)
+				if err != nil {
+					return err
+				}
+				opts = append(opts, datum.WithPrefetch(int(spec.Datums), disk, memory))
+			}
 			// Setup datum set for processing.
 			return datum.WithSet(pachClient, storageRoot, func(s *datum.Set) error {
-				di := datum.NewFileSetIterator(pachClient, datumSet.FileSet)
+				runUserCode := func(runCtx context.Context, _ *datum.Datum, logger logs.TaggedLogger, env []string) error {
+					return driver.RunUserCode(runCtx, logger, env)
+				}
+				if driver.PipelineInfo().Transform.DatumBatching {
+					// Start a single user process for the datum set, which the
+					// datums are handed to one at a time.
+					env, err := driver.UserCodeEnv(logger.JobID(), outputCommit, nil)
+					if err != nil {
+						return err
+					}
+					env = append(env, status.progressEnv()...)
+					env = append(env, status.metricsEnv()...)
+					batch := driver.NewDatumBatch(pachClient.Ctx(), logger, env)
+					defer func() {
+						if err := batch.Close(pachClient.Ctx()); err != nil {
+							logger.Logf("error closing datum batch: %v", err)
+						}
+					}()
+					runUserCode = func(runCtx context.Context, d *datum.Datum, _ logs.TaggedLogger, _ []string) error {
+						return batch.Process(runCtx, d.ID)
+					}
+				}
+				di := s.PrefetchIterator(datum.NewFileSetIterator(pachClient, datumSet.FileSet))
 				// Process each datum in the assigned datum set.
 				return di.Iterate(func(meta *datum.Meta) error {
 					ctx := pachClient.Ctx()
 					inputs := meta.Inputs
 					logger = logger.WithData(inputs)
-					env := driver.UserCodeEnv(logger.JobID(), outputCommit, inputs)
+					env, err := driver.UserCodeEnv(logger.JobID(), outputCommit, inputs)
+					if err != nil {
+						return err
+					}
+					env = append(env, status.progressEnv()...)
+					env = append(env, status.metricsEnv()...)
 					var opts []datum.Option
 					if driver.PipelineInfo().DatumTimeout != nil {
 						timeout, err := types.DurationFromProto(driver.PipelineInfo().DatumTimeout)
@@ -104,6 +147,17 @@ func handleDatumSet(driver driver.Driver, logger logs.TaggedLogger, datumSet *Da
 						}
 						opts = append(opts, datum.WithTimeout(timeout))
 					}
+					if spec := driver.PipelineInfo().DebugOnFailure; spec != nil && spec.Enabled {
+						timeout, err := debugTimeout(spec)
+						if err != nil {
+							return err
+						}
+						datumID := common.DatumID(inputs)
+						opts = append(opts, datum.WithFailureCallback(func() {
+							logger.Logf("holding failed datum %v for debugging for up to %v", datumID, timeout)
+							status.holdForDebug(ctx, logger.JobID(), datumID, env, timeout)
+						}))
+					}
 					if driver.PipelineInfo().Transform.ErrCmd != nil {
 						opts = append(opts, datum.WithRecoveryCallback(func(runCtx context.Context) error {
 							return driver.RunUserErrorHandlingCode(runCtx, logger, env)
@@ -115,7 +169,7 @@ func handleDatumSet(driver driver.Driver, logger logs.TaggedLogger, datumSet *Da
 						return status.withDatum(inputs, cancel, func() error {
 							return driver.WithActiveData(inputs, d.PFSStorageRoot(), func() error {
 								return d.Run(cancelCtx, func(runCtx context.Context) error {
-									return driver.RunUserCode(runCtx, logger, env)
+									return runUserCode(runCtx, d, logger, env)
 								})
 							})
 						})
@@ -1,11 +1,17 @@
 package server
 
 import (
+	"io"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 	"golang.org/x/net/context"
 
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
 	"github.com/pachyderm/pachyderm/v2/src/pps"
 	"github.com/pachyderm/pachyderm/v2/src/server/worker/driver"
+	"github.com/pachyderm/pachyderm/v2/src/server/worker/pipeline/transform"
 )
 
 // WorkerInterface is an interface for getting or canceling the
@@ -13,8 +19,14 @@ import (
 type WorkerInterface interface {
 	GetStatus() (*pps.WorkerStatus, error)
 	Cancel(jobID string, datumFilter []string) bool
+	StopJob(ctx context.Context, jobID string) bool
+	DebugDatum(jobID, datumID string) (context.Context, []string, func(), error)
 }
 
+// statusWatchPeriod is how often WatchStatus checks for changes to the
+// worker's status
+const statusWatchPeriod = time.Second
+
 // APIServer implements the worker API
 type APIServer struct {
 	driver          driver.Driver
@@ -41,8 +53,109 @@ func (a *APIServer) Status(ctx context.Context, _ *types.Empty) (*pps.WorkerStat
 	return status, nil
 }
 
-// Cancel cancels the currently running datum
+// WatchStatus streams the status of the current worker task each time it
+// changes, until the client goes away.
+func (a *APIServer) WatchStatus(_ *types.Empty, server Worker_WatchStatusServer) error {
+	var prev *pps.WorkerStatus
+	ticker := time.NewTicker(statusWatchPeriod)
+	defer ticker.Stop()
+	for {
+		status, err := a.Status(server.Context(), &types.Empty{})
+		if err != nil {
+			return err
+		}
+		if prev == nil || !proto.Equal(status, prev) {
+			if err := server.Send(status); err != nil {
+				return err
+			}
+			prev = status
+		}
+		select {
+		case <-ticker.C:
+		case <-server.Context().Done():
+			return nil
+		}
+	}
+}
+
+// Egress copies the files in a job's output commit to its egress target. It's
+// used to retry the egress of a job whose egress failed.
+func (a *APIServer) Egress(ctx context.Context, request *EgressRequest) (*types.Empty, error) {
+	if request.Commit == nil || request.Egress == nil {
+		return nil, errors.Errorf("must specify a commit and egress")
+	}
+	if err := transform.Egress(a.driver.PachClient().WithCtx(ctx), request.Commit, request.Egress); err != nil {
+		return nil, err
+	}
+	return &types.Empty{}, nil
+}
+
+// DebugDatum runs an interactive shell in the environment of a failed datum
+// that the worker is holding for debugging.
+func (a *APIServer) DebugDatum(server Worker_DebugDatumServer) error {
+	req, err := server.Recv()
+	if err != nil {
+		return err
+	}
+	if req.Job == nil || req.DatumID == "" {
+		return errors.Errorf("must specify a job and datum")
+	}
+	holdCtx, env, detach, err := a.workerInterface.DebugDatum(req.Job.ID, req.DatumID)
+	if err != nil {
+		return err
+	}
+	defer detach()
+	ctx, cancel := context.WithCancel(holdCtx)
+	defer cancel()
+	go func() {
+		select {
+		case <-server.Context().Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	// tell the client that the shell is starting
+	if err := server.Send(&pps.DebugDatumResponse{}); err != nil {
+		return err
+	}
+	stdin, stdinW := io.Pipe()
+	defer stdin.Close()
+	go func() {
+		for {
+			req, err := server.Recv()
+			if err != nil {
+				stdinW.Close()
+				return
+			}
+			if _, err := stdinW.Write(req.Stdin); err != nil {
+				return
+			}
+		}
+	}()
+	return a.driver.RunDebugShell(ctx, env, stdin, &debugOutput{server: server})
+}
+
+// debugOutput forwards a debug shell's output to the client
+type debugOutput struct {
+	server Worker_DebugDatumServer
+}
+
+func (o *debugOutput) Write(p []byte) (int, error) {
+	output := make([]byte, len(p))
+	copy(output, p)
+	if err := o.server.Send(&pps.DebugDatumResponse{Output: output}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Cancel cancels the currently running datum, or stops the job if
+// request.Stop is set
 func (a *APIServer) Cancel(ctx context.Context, request *CancelRequest) (*CancelResponse, error) {
+	if request.Stop {
+		success := a.workerInterface.StopJob(ctx, request.JobID)
+		return &CancelResponse{Success: success}, nil
+	}
 	success := a.workerInterface.Cancel(request.JobID, request.DataFilters)
 	return &CancelResponse{Success: success}, nil
 }
@@ -16,6 +16,7 @@ import (
 
 	etcd "github.com/coreos/etcd/clientv3"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
 )
 
@@ -77,6 +78,29 @@ func Cancel(ctx context.Context, pipelineRcName string, etcdClient *etcd.Client,
 	return nil
 }
 
+// StopJob stops the processing of a job on the workers referenced by
+// pipelineRcName, and waits for the job's user code to shut down, which takes
+// up to the pipeline's cancel grace period.
+func StopJob(ctx context.Context, pipelineRcName string, etcdClient *etcd.Client,
+	etcdPrefix string, workerGrpcPort uint16, jobID string) error {
+	workerClients, err := Clients(ctx, pipelineRcName, etcdClient, etcdPrefix, workerGrpcPort)
+	if err != nil {
+		return err
+	}
+	var eg errgroup.Group
+	for _, workerClient := range workerClients {
+		workerClient := workerClient
+		eg.Go(func() error {
+			_, err := workerClient.Cancel(ctx, &CancelRequest{
+				JobID: jobID,
+				Stop:  true,
+			})
+			return err
+		})
+	}
+	return eg.Wait()
+}
+
 // Conns returns a slice of connections to worker servers.
 // pipelineRcName is the name of the pipeline's RC and can be gotten with
 // ppsutil.PipelineRcName. You can also pass "" for pipelineRcName to get all
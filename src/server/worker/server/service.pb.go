@@ -9,6 +9,7 @@ import (
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
 	types "github.com/gogo/protobuf/types"
+	pfs "github.com/pachyderm/pachyderm/v2/src/pfs"
 	pps "github.com/pachyderm/pachyderm/v2/src/pps"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
@@ -30,8 +31,11 @@ var _ = math.Inf
 const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
 
 type CancelRequest struct {
-	JobID                string   `protobuf:"bytes,2,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
-	DataFilters          []string `protobuf:"bytes,1,rep,name=data_filters,json=dataFilters,proto3" json:"data_filters,omitempty"`
+	JobID       string   `protobuf:"bytes,2,opt,name=job_id,json=jobId,proto3" json:"job_id,omitempty"`
+	DataFilters []string `protobuf:"bytes,1,rep,name=data_filters,json=dataFilters,proto3" json:"data_filters,omitempty"`
+	// stop, if set, stops the worker's processing of the job instead of
+	// restarting the matching datum, and waits for its user code to shut down.
+	Stop                 bool     `protobuf:"varint,3,opt,name=stop,proto3" json:"stop,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -84,6 +88,13 @@ func (m *CancelRequest) GetDataFilters() []string {
 	return nil
 }
 
+func (m *CancelRequest) GetStop() bool {
+	if m != nil {
+		return m.Stop
+	}
+	return false
+}
+
 type CancelResponse struct {
 	Success              bool     `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -131,9 +142,65 @@ func (m *CancelResponse) GetSuccess() bool {
 	return false
 }
 
+type EgressRequest struct {
+	Commit               *pfs.Commit `protobuf:"bytes,1,opt,name=commit,proto3" json:"commit,omitempty"`
+	Egress               *pps.Egress `protobuf:"bytes,2,opt,name=egress,proto3" json:"egress,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *EgressRequest) Reset()         { *m = EgressRequest{} }
+func (m *EgressRequest) String() string { return proto.CompactTextString(m) }
+func (*EgressRequest) ProtoMessage()    {}
+func (*EgressRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c4407c0c45dc0204, []int{2}
+}
+func (m *EgressRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *EgressRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_EgressRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *EgressRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EgressRequest.Merge(m, src)
+}
+func (m *EgressRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *EgressRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_EgressRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EgressRequest proto.InternalMessageInfo
+
+func (m *EgressRequest) GetCommit() *pfs.Commit {
+	if m != nil {
+		return m.Commit
+	}
+	return nil
+}
+
+func (m *EgressRequest) GetEgress() *pps.Egress {
+	if m != nil {
+		return m.Egress
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*CancelRequest)(nil), "server.CancelRequest")
 	proto.RegisterType((*CancelResponse)(nil), "server.CancelResponse")
+	proto.RegisterType((*EgressRequest)(nil), "server.EgressRequest")
 }
 
 func init() {
@@ -141,27 +208,35 @@ func init() {
 }
 
 var fileDescriptor_c4407c0c45dc0204 = []byte{
-	// 319 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x91, 0x5f, 0x4b, 0xf3, 0x30,
-	0x18, 0xc5, 0xd7, 0xf7, 0x65, 0xd5, 0x45, 0x27, 0x18, 0x74, 0x8c, 0x0a, 0x73, 0xf6, 0x6a, 0x78,
-	0x91, 0xc0, 0xc4, 0x0b, 0xbd, 0x9c, 0x7f, 0x70, 0x5e, 0x56, 0x41, 0xf0, 0x66, 0xb4, 0xe9, 0xb3,
-	0xae, 0x73, 0xdb, 0x13, 0x93, 0x74, 0x32, 0x3f, 0xa1, 0x97, 0x7e, 0x02, 0x91, 0x7e, 0x12, 0x69,
-	0xb3, 0x82, 0x8a, 0x57, 0x79, 0xce, 0x2f, 0x87, 0xe4, 0x9c, 0x84, 0xf8, 0x1a, 0xd4, 0x12, 0x14,
-	0x7f, 0x41, 0xf5, 0x04, 0x8a, 0xaf, 0x55, 0xb1, 0xa4, 0x02, 0x98, 0x54, 0x68, 0x90, 0xba, 0x96,
-	0x7a, 0x4d, 0x29, 0x35, 0x97, 0x52, 0x5b, 0xec, 0xed, 0x25, 0x98, 0x60, 0x39, 0xf2, 0x62, 0x5a,
-	0xd3, 0x83, 0x04, 0x31, 0x99, 0x01, 0x2f, 0x55, 0x94, 0x8d, 0x39, 0xcc, 0xa5, 0x59, 0xd9, 0x4d,
-	0xff, 0x9e, 0x34, 0x2f, 0xc2, 0x85, 0x80, 0x59, 0x00, 0xcf, 0x19, 0x68, 0x43, 0xbb, 0xc4, 0x9d,
-	0x62, 0x34, 0x4a, 0xe3, 0xf6, 0xbf, 0xae, 0xd3, 0x6b, 0x0c, 0x1a, 0xf9, 0xc7, 0x61, 0xfd, 0x16,
-	0xa3, 0xe1, 0x65, 0x50, 0x9f, 0x62, 0x34, 0x8c, 0xe9, 0x11, 0xd9, 0x8e, 0x43, 0x13, 0x8e, 0xc6,
-	0xe9, 0xcc, 0x80, 0xd2, 0x6d, 0xa7, 0xfb, 0xbf, 0xd7, 0x08, 0xb6, 0x0a, 0x76, 0x6d, 0x91, 0x7f,
-	0x4c, 0x76, 0xaa, 0x53, 0xb5, 0xc4, 0x85, 0x06, 0xda, 0x26, 0x1b, 0x3a, 0x13, 0x02, 0x74, 0xe1,
-	0x77, 0x7a, 0x9b, 0x41, 0x25, 0xfb, 0xaf, 0xc4, 0x7d, 0x28, 0xab, 0xd2, 0x53, 0xe2, 0xde, 0x99,
-	0xd0, 0x64, 0x9a, 0xb6, 0x98, 0xcd, 0xcc, 0xaa, 0xcc, 0xec, 0xaa, 0xc8, 0xec, 0xed, 0xb2, 0xa2,
-	0xac, 0xb5, 0x5b, 0xab, 0x5f, 0xa3, 0x67, 0xc4, 0xb5, 0x97, 0xd1, 0x7d, 0x66, 0xdf, 0x85, 0xfd,
-	0xa8, 0xe4, 0xb5, 0x7e, 0x63, 0x9b, 0xc9, 0xaf, 0x0d, 0x6e, 0xde, 0xf2, 0x8e, 0xf3, 0x9e, 0x77,
-	0x9c, 0xcf, 0xbc, 0xe3, 0x3c, 0x9e, 0x27, 0xa9, 0x99, 0x64, 0x11, 0x13, 0x38, 0xe7, 0x32, 0x14,
-	0x93, 0x55, 0x0c, 0xea, 0xfb, 0xb4, 0xec, 0x73, 0xad, 0x04, 0xff, 0xeb, 0x7f, 0x22, 0xb7, 0x4c,
-	0x7a, 0xf2, 0x15, 0x00, 0x00, 0xff, 0xff, 0xd7, 0xf3, 0xda, 0x1a, 0xbe, 0x01, 0x00, 0x00,
+	// 434 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x92, 0xcf, 0x8e, 0xd3, 0x30,
+	0x10, 0xc6, 0xeb, 0x2e, 0x1b, 0xa8, 0x4b, 0x91, 0xb0, 0xa0, 0x44, 0x41, 0x2a, 0x25, 0x5c, 0x2a,
+	0x0e, 0xf6, 0xaa, 0x88, 0x03, 0x88, 0xd3, 0xb6, 0x8b, 0x58, 0x8e, 0xe1, 0xb0, 0x82, 0xcb, 0x2a,
+	0x71, 0xa7, 0x69, 0x96, 0x66, 0x6d, 0x3c, 0xce, 0xa2, 0x7d, 0x33, 0x1e, 0x81, 0x23, 0x4f, 0x80,
+	0x50, 0x9f, 0x04, 0xd9, 0x4e, 0xb4, 0x2c, 0x7f, 0x2e, 0x9c, 0x32, 0xf3, 0x9b, 0x6f, 0xc6, 0x93,
+	0xcf, 0xa6, 0x29, 0x82, 0xb9, 0x00, 0x23, 0x3e, 0x2b, 0xf3, 0x11, 0x8c, 0x68, 0x33, 0xf7, 0xa9,
+	0x24, 0x70, 0x6d, 0x94, 0x55, 0x2c, 0x0a, 0x34, 0x19, 0xe9, 0x35, 0x0a, 0xbd, 0xc6, 0x80, 0x93,
+	0x91, 0xd6, 0x28, 0xb4, 0xee, 0xd2, 0x7b, 0xa5, 0x2a, 0x95, 0x0f, 0x85, 0x8b, 0x5a, 0xfa, 0xb0,
+	0x54, 0xaa, 0xdc, 0x82, 0xf0, 0x59, 0xd1, 0xac, 0x05, 0xd4, 0xda, 0x5e, 0x86, 0x62, 0xba, 0xa1,
+	0xa3, 0x45, 0x7e, 0x2e, 0x61, 0x9b, 0xc1, 0xa7, 0x06, 0xd0, 0xb2, 0x29, 0x8d, 0xce, 0x54, 0x71,
+	0x5a, 0xad, 0xe2, 0xfe, 0x94, 0xcc, 0x06, 0x87, 0x83, 0xdd, 0xf7, 0x47, 0xfb, 0x6f, 0x55, 0x71,
+	0xbc, 0xcc, 0xf6, 0xcf, 0x54, 0x71, 0xbc, 0x62, 0x8f, 0xe9, 0xed, 0x55, 0x6e, 0xf3, 0xd3, 0x75,
+	0xb5, 0xb5, 0x60, 0x30, 0x26, 0xd3, 0xbd, 0xd9, 0x20, 0x1b, 0x3a, 0xf6, 0x3a, 0x20, 0xc6, 0xe8,
+	0x0d, 0xb4, 0x4a, 0xc7, 0x7b, 0x53, 0x32, 0xbb, 0x95, 0xf9, 0x38, 0x7d, 0x4a, 0xef, 0x74, 0x27,
+	0xa1, 0x56, 0xe7, 0x08, 0x2c, 0xa6, 0x37, 0xb1, 0x91, 0x12, 0xd0, 0xcd, 0x70, 0xc2, 0x2e, 0x4d,
+	0xdf, 0xd3, 0xd1, 0x51, 0x69, 0x00, 0xb1, 0xdb, 0xea, 0x09, 0x8d, 0xa4, 0xaa, 0xeb, 0xca, 0x7a,
+	0xe5, 0x70, 0x3e, 0xe4, 0xce, 0x84, 0x85, 0x47, 0x59, 0x5b, 0x72, 0x22, 0xf0, 0x5d, 0x71, 0xbf,
+	0x13, 0x69, 0xe4, 0xed, 0xa0, 0xb6, 0x34, 0xff, 0xd2, 0xa7, 0xd1, 0x89, 0x77, 0x9a, 0x3d, 0xa7,
+	0xd1, 0x3b, 0x9b, 0xdb, 0x06, 0xd9, 0x98, 0x07, 0x8f, 0x78, 0xe7, 0x11, 0x3f, 0x72, 0x1e, 0x25,
+	0x77, 0xfd, 0x84, 0x20, 0x0f, 0xd2, 0xb4, 0xc7, 0x5e, 0xd1, 0xe1, 0x49, 0x6e, 0xe5, 0xe6, 0x3f,
+	0x7a, 0x0f, 0x08, 0x7b, 0x41, 0xa3, 0x60, 0x03, 0xbb, 0xcf, 0xc3, 0xa5, 0xf2, 0x6b, 0x17, 0x90,
+	0x8c, 0x7f, 0xc7, 0xc1, 0xad, 0xb4, 0xe7, 0x5a, 0xc3, 0xcf, 0x5c, 0xb5, 0x5e, 0x73, 0x29, 0xf9,
+	0xc7, 0x2a, 0x69, 0x8f, 0x2d, 0x28, 0x5d, 0x42, 0xd1, 0x94, 0xcb, 0xdc, 0x36, 0x35, 0x1b, 0xfb,
+	0xd5, 0xae, 0x40, 0xd7, 0xff, 0xe0, 0x0f, 0xde, 0x9d, 0x3d, 0x23, 0x07, 0xe4, 0xf0, 0xcd, 0xd7,
+	0xdd, 0x84, 0x7c, 0xdb, 0x4d, 0xc8, 0x8f, 0xdd, 0x84, 0x7c, 0x78, 0x59, 0x56, 0x76, 0xd3, 0x14,
+	0x5c, 0xaa, 0x5a, 0xe8, 0x5c, 0x6e, 0x2e, 0x57, 0x60, 0x7e, 0x8d, 0x2e, 0xe6, 0x02, 0x8d, 0x14,
+	0x7f, 0x7b, 0xdc, 0x45, 0xe4, 0x17, 0x7c, 0xf6, 0x73, 0x00, 0x4e, 0x60, 0x9f, 0xa4, 0xfb, 0x02,
+	0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -177,7 +252,14 @@ const _ = grpc.SupportPackageIsVersion4
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type WorkerClient interface {
 	Status(ctx context.Context, in *types.Empty, opts ...grpc.CallOption) (*pps.WorkerStatus, error)
+	// WatchStatus streams the worker's status each time it changes.
+	WatchStatus(ctx context.Context, in *types.Empty, opts ...grpc.CallOption) (Worker_WatchStatusClient, error)
 	Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResponse, error)
+	// Egress copies the files in a job's output commit to its egress target.
+	Egress(ctx context.Context, in *EgressRequest, opts ...grpc.CallOption) (*types.Empty, error)
+	// DebugDatum runs an interactive shell in the environment of a failed datum
+	// that the worker is holding for debugging.
+	DebugDatum(ctx context.Context, opts ...grpc.CallOption) (Worker_DebugDatumClient, error)
 }
 
 type workerClient struct {
@@ -197,6 +279,38 @@ func (c *workerClient) Status(ctx context.Context, in *types.Empty, opts ...grpc
 	return out, nil
 }
 
+func (c *workerClient) WatchStatus(ctx context.Context, in *types.Empty, opts ...grpc.CallOption) (Worker_WatchStatusClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Worker_serviceDesc.Streams[0], "/server.Worker/WatchStatus", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &workerWatchStatusClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Worker_WatchStatusClient interface {
+	Recv() (*pps.WorkerStatus, error)
+	grpc.ClientStream
+}
+
+type workerWatchStatusClient struct {
+	grpc.ClientStream
+}
+
+func (x *workerWatchStatusClient) Recv() (*pps.WorkerStatus, error) {
+	m := new(pps.WorkerStatus)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *workerClient) Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResponse, error) {
 	out := new(CancelResponse)
 	err := c.cc.Invoke(ctx, "/server.Worker/Cancel", in, out, opts...)
@@ -206,10 +320,57 @@ func (c *workerClient) Cancel(ctx context.Context, in *CancelRequest, opts ...gr
 	return out, nil
 }
 
+func (c *workerClient) Egress(ctx context.Context, in *EgressRequest, opts ...grpc.CallOption) (*types.Empty, error) {
+	out := new(types.Empty)
+	err := c.cc.Invoke(ctx, "/server.Worker/Egress", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *workerClient) DebugDatum(ctx context.Context, opts ...grpc.CallOption) (Worker_DebugDatumClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Worker_serviceDesc.Streams[1], "/server.Worker/DebugDatum", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &workerDebugDatumClient{stream}
+	return x, nil
+}
+
+type Worker_DebugDatumClient interface {
+	Send(*pps.DebugDatumRequest) error
+	Recv() (*pps.DebugDatumResponse, error)
+	grpc.ClientStream
+}
+
+type workerDebugDatumClient struct {
+	grpc.ClientStream
+}
+
+func (x *workerDebugDatumClient) Send(m *pps.DebugDatumRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *workerDebugDatumClient) Recv() (*pps.DebugDatumResponse, error) {
+	m := new(pps.DebugDatumResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // WorkerServer is the server API for Worker service.
 type WorkerServer interface {
 	Status(context.Context, *types.Empty) (*pps.WorkerStatus, error)
+	// WatchStatus streams the worker's status each time it changes.
+	WatchStatus(*types.Empty, Worker_WatchStatusServer) error
 	Cancel(context.Context, *CancelRequest) (*CancelResponse, error)
+	// Egress copies the files in a job's output commit to its egress target.
+	Egress(context.Context, *EgressRequest) (*types.Empty, error)
+	// DebugDatum runs an interactive shell in the environment of a failed datum
+	// that the worker is holding for debugging.
+	DebugDatum(Worker_DebugDatumServer) error
 }
 
 // UnimplementedWorkerServer can be embedded to have forward compatible implementations.
@@ -219,9 +380,18 @@ type UnimplementedWorkerServer struct {
 func (*UnimplementedWorkerServer) Status(ctx context.Context, req *types.Empty) (*pps.WorkerStatus, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
 }
+func (*UnimplementedWorkerServer) WatchStatus(req *types.Empty, srv Worker_WatchStatusServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchStatus not implemented")
+}
 func (*UnimplementedWorkerServer) Cancel(ctx context.Context, req *CancelRequest) (*CancelResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Cancel not implemented")
 }
+func (*UnimplementedWorkerServer) Egress(ctx context.Context, req *EgressRequest) (*types.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Egress not implemented")
+}
+func (*UnimplementedWorkerServer) DebugDatum(srv Worker_DebugDatumServer) error {
+	return status.Errorf(codes.Unimplemented, "method DebugDatum not implemented")
+}
 
 func RegisterWorkerServer(s *grpc.Server, srv WorkerServer) {
 	s.RegisterService(&_Worker_serviceDesc, srv)
@@ -245,6 +415,27 @@ func _Worker_Status_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Worker_WatchStatus_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(types.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(WorkerServer).WatchStatus(m, &workerWatchStatusServer{stream})
+}
+
+type Worker_WatchStatusServer interface {
+	Send(*pps.WorkerStatus) error
+	grpc.ServerStream
+}
+
+type workerWatchStatusServer struct {
+	grpc.ServerStream
+}
+
+func (x *workerWatchStatusServer) Send(m *pps.WorkerStatus) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Worker_Cancel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CancelRequest)
 	if err := dec(in); err != nil {
@@ -263,6 +454,50 @@ func _Worker_Cancel_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Worker_Egress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EgressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WorkerServer).Egress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/server.Worker/Egress",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WorkerServer).Egress(ctx, req.(*EgressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Worker_DebugDatum_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(WorkerServer).DebugDatum(&workerDebugDatumServer{stream})
+}
+
+type Worker_DebugDatumServer interface {
+	Send(*pps.DebugDatumResponse) error
+	Recv() (*pps.DebugDatumRequest, error)
+	grpc.ServerStream
+}
+
+type workerDebugDatumServer struct {
+	grpc.ServerStream
+}
+
+func (x *workerDebugDatumServer) Send(m *pps.DebugDatumResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *workerDebugDatumServer) Recv() (*pps.DebugDatumRequest, error) {
+	m := new(pps.DebugDatumRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 var _Worker_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "server.Worker",
 	HandlerType: (*WorkerServer)(nil),
@@ -275,8 +510,24 @@ var _Worker_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Cancel",
 			Handler:    _Worker_Cancel_Handler,
 		},
+		{
+			MethodName: "Egress",
+			Handler:    _Worker_Egress_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchStatus",
+			Handler:       _Worker_WatchStatus_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "DebugDatum",
+			Handler:       _Worker_DebugDatum_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "server/worker/server/service.proto",
 }
 
@@ -304,6 +555,16 @@ func (m *CancelRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i -= len(m.XXX_unrecognized)
 		copy(dAtA[i:], m.XXX_unrecognized)
 	}
+	if m.Stop {
+		i--
+		if m.Stop {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x18
+	}
 	if len(m.JobID) > 0 {
 		i -= len(m.JobID)
 		copy(dAtA[i:], m.JobID)
@@ -360,6 +621,57 @@ func (m *CancelResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
+func (m *EgressRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *EgressRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *EgressRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Egress != nil {
+		{
+			size, err := m.Egress.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintService(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Commit != nil {
+		{
+			size, err := m.Commit.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintService(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
 func encodeVarintService(dAtA []byte, offset int, v uint64) int {
 	offset -= sovService(v)
 	base := offset
@@ -387,6 +699,9 @@ func (m *CancelRequest) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovService(uint64(l))
 	}
+	if m.Stop {
+		n += 2
+	}
 	if m.XXX_unrecognized != nil {
 		n += len(m.XXX_unrecognized)
 	}
@@ -408,6 +723,26 @@ func (m *CancelResponse) Size() (n int) {
 	return n
 }
 
+func (m *EgressRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Commit != nil {
+		l = m.Commit.Size()
+		n += 1 + l + sovService(uint64(l))
+	}
+	if m.Egress != nil {
+		l = m.Egress.Size()
+		n += 1 + l + sovService(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func sovService(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -507,6 +842,26 @@ func (m *CancelRequest) Unmarshal(dAtA []byte) error {
 			}
 			m.JobID = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Stop", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowService
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Stop = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipService(dAtA[iNdEx:])
@@ -600,6 +955,129 @@ func (m *CancelResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *EgressRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowService
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: EgressRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: EgressRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Commit", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowService
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthService
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthService
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Commit == nil {
+				m.Commit = &pfs.Commit{}
+			}
+			if err := m.Commit.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Egress", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowService
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthService
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthService
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Egress == nil {
+				m.Egress = &pps.Egress{}
+			}
+			if err := m.Egress.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipService(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthService
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipService(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
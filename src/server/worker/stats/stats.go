@@ -183,8 +183,9 @@ var (
 )
 
 // InitPrometheus sets up the default datum stats collectors for use by worker
-// code, and exposes the stats on an http endpoint.
-func InitPrometheus() {
+// code, and exposes the stats, and the metrics in the worker's 'registry', on
+// an http endpoint.
+func InitPrometheus(registry prometheus.Gatherer) {
 	metrics := []prometheus.Collector{
 		DatumCount,
 		DatumProcTime,
@@ -206,7 +207,7 @@ func InitPrometheus() {
 			}
 		}
 	}
-	http.Handle("/metrics", promhttp.Handler())
+	http.Handle("/metrics", promhttp.HandlerFor(prometheus.Gatherers{prometheus.DefaultGatherer, registry}, promhttp.HandlerOpts{}))
 	go func() {
 		if err := http.ListenAndServe(fmt.Sprintf(":%v", PrometheusPort), nil); err != nil {
 			logrus.Errorf("error serving prometheus metrics: %v", err)
@@ -0,0 +1,186 @@
+package stats
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/errors"
+)
+
+// StatsdPort is the UDP port, on localhost, that workers receive statsd lines
+// from user code on, if it's free
+const StatsdPort = 8125
+
+// userMetricLabels are the labels of every metric exported by UserMetrics.
+// There's deliberately no datum label, as it would create a time series for
+// every datum that a pipeline processes.
+var userMetricLabels = []string{"pipeline", "job"}
+
+var invalidMetricChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// UserMetrics re-exports the metrics that user code emits as statsd lines, as
+// Prometheus metrics named pachyderm_user_<name> and labelled with the
+// pipeline and job that emitted them. Counters ('c') and gauges ('g')
+// are supported. Sample rates are applied to counters, and tags are ignored.
+type UserMetrics struct {
+	pipeline   string
+	registerer prometheus.Registerer
+
+	mu       sync.Mutex
+	counters map[string]*prometheus.CounterVec
+	gauges   map[string]*prometheus.GaugeVec
+}
+
+// NewUserMetrics creates a UserMetrics for 'pipeline' that registers its
+// metrics with 'registerer'.
+func NewUserMetrics(pipeline string, registerer prometheus.Registerer) *UserMetrics {
+	return &UserMetrics{
+		pipeline:   pipeline,
+		registerer: registerer,
+		counters:   make(map[string]*prometheus.CounterVec),
+		gauges:     make(map[string]*prometheus.GaugeVec),
+	}
+}
+
+// Record records the statsd lines in 'data', which were emitted while
+// processing the job 'job'. It records every valid line, and returns the error
+// of the first invalid one.
+func (m *UserMetrics) Record(data, job string) error {
+	var retErr error
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if err := m.recordLine(line, job); err != nil && retErr == nil {
+			retErr = errors.Wrapf(err, "invalid statsd line %q", line)
+		}
+	}
+	return retErr
+}
+
+func (m *UserMetrics) recordLine(line, job string) error {
+	// <name>:<value>|<type>[|@<sample rate>][|#<tags>]
+	nameValue, rest := split(line, "|")
+	name, value := split(nameValue, ":")
+	if name == "" || value == "" || rest == "" {
+		return errors.Errorf("expected <name>:<value>|<type>")
+	}
+	metricType, rest := split(rest, "|")
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return errors.Errorf("value must be a number")
+	}
+	labels := prometheus.Labels{"pipeline": m.pipeline, "job": job}
+	switch metricType {
+	case "c":
+		for _, field := range strings.Split(rest, "|") {
+			if strings.HasPrefix(field, "@") {
+				rate, err := strconv.ParseFloat(field[1:], 64)
+				if err != nil || rate <= 0 || rate > 1 {
+					return errors.Errorf("sample rate must be in (0, 1]")
+				}
+				n /= rate
+			}
+		}
+		if n < 0 {
+			return errors.Errorf("counters can't be decreased")
+		}
+		counter, err := m.counter(name)
+		if err != nil {
+			return err
+		}
+		counter.With(labels).Add(n)
+	case "g":
+		gauge, err := m.gauge(name)
+		if err != nil {
+			return err
+		}
+		// Signed values change the gauge instead of setting it.
+		if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+			gauge.With(labels).Add(n)
+		} else {
+			gauge.With(labels).Set(n)
+		}
+	default:
+		return errors.Errorf("unsupported metric type %q", metricType)
+	}
+	return nil
+}
+
+func (m *UserMetrics) counter(name string) (*prometheus.CounterVec, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = metricName(name)
+	if counter, ok := m.counters[name]; ok {
+		return counter, nil
+	}
+	if _, ok := m.gauges[name]; ok {
+		return nil, errors.Errorf("%s is already a gauge", name)
+	}
+	counter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "pachyderm",
+			Subsystem: "user",
+			Name:      name,
+			Help:      "Counter emitted by user code",
+		},
+		userMetricLabels,
+	)
+	if err := m.registerer.Register(counter); err != nil {
+		return nil, err
+	}
+	m.counters[name] = counter
+	return counter, nil
+}
+
+func (m *UserMetrics) gauge(name string) (*prometheus.GaugeVec, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = metricName(name)
+	if gauge, ok := m.gauges[name]; ok {
+		return gauge, nil
+	}
+	if _, ok := m.counters[name]; ok {
+		return nil, errors.Errorf("%s is already a counter", name)
+	}
+	gauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "pachyderm",
+			Subsystem: "user",
+			Name:      name,
+			Help:      "Gauge emitted by user code",
+		},
+		userMetricLabels,
+	)
+	if err := m.registerer.Register(gauge); err != nil {
+		return nil, err
+	}
+	m.gauges[name] = gauge
+	return gauge, nil
+}
+
+// metricName converts a statsd metric name, such as "model.accuracy", to a
+// valid Prometheus metric name, such as "model_accuracy". Names can't start
+// with a digit, so "_" is prepended to those that do.
+func metricName(name string) string {
+	name = invalidMetricChars.ReplaceAllString(name, "_")
+	if name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+// split splits 's' at the first 'sep', returning "" as the second half if 's'
+// doesn't contain 'sep'.
+func split(s, sep string) (string, string) {
+	parts := strings.SplitN(s, sep, 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
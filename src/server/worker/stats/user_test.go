@@ -0,0 +1,28 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/pachyderm/pachyderm/v2/src/internal/require"
+)
+
+func TestUserMetrics(t *testing.T) {
+	m := NewUserMetrics("pipeline", prometheus.NewRegistry())
+	require.NoError(t, m.Record("rows:3|c\nrows:1|c|@0.5\n\nmodel.accuracy:0.75|g|#tag:ignored", "job"))
+	require.NoError(t, m.Record("model.accuracy:+0.1|g", "job"))
+	require.Equal(t, 5.0, testutil.ToFloat64(m.counters["rows"].WithLabelValues("pipeline", "job")))
+	require.Equal(t, 0.85, testutil.ToFloat64(m.gauges["model_accuracy"].WithLabelValues("pipeline", "job")))
+
+	// valid lines are recorded even if other lines are invalid
+	require.YesError(t, m.Record("rows:1|c\nrows:-1|c\nrows|c\nrows:x|c\nlatency:3|ms\nrows:1|g", "other"))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.counters["rows"].WithLabelValues("pipeline", "other")))
+	require.Equal(t, 1, len(m.counters))
+	require.Equal(t, 1, len(m.gauges))
+
+	// names are converted to valid Prometheus names
+	require.Equal(t, "model_accuracy", metricName("model.accuracy"))
+	require.Equal(t, "_2xx_responses", metricName("2xx-responses"))
+}
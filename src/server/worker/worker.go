@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
 	"time"
 
 	etcd "github.com/coreos/etcd/clientv3"
 	docker "github.com/fsouza/go-dockerclient"
 	"github.com/gogo/protobuf/types"
+	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/pachyderm/pachyderm/v2/src/auth"
@@ -54,7 +56,10 @@ func NewWorker(
 	namespace string,
 	rootPath string,
 ) (*Worker, error) {
-	stats.InitPrometheus()
+	// User metrics are registered separately from the worker's own metrics, so
+	// the names that user code chooses can't conflict with them.
+	registry := prometheus.NewRegistry()
+	stats.InitPrometheus(registry)
 
 	hasDocker := true
 	if _, err := os.Stat("/var/run/docker.sock"); err != nil {
@@ -103,6 +108,14 @@ func NewWorker(
 		status: &transform.Status{},
 	}
 
+	if err := worker.status.ListenProgress(); err != nil {
+		return nil, errors.Wrapf(err, "error starting progress server")
+	}
+	metrics := stats.NewUserMetrics(pipelineInfo.Pipeline.Name, registry)
+	metricsFile := filepath.Join(driver.InputDir(), client.PPSScratchSpace, "metrics")
+	if err := worker.status.ListenMetrics(metrics, metricsFile); err != nil {
+		return nil, errors.Wrapf(err, "error starting metrics listener")
+	}
 	worker.APIServer = server.NewAPIServer(driver, worker.status, workerName)
 
 	go worker.master(etcdClient, etcdPrefix)